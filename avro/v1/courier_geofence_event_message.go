@@ -0,0 +1,358 @@
+// Code generated by github.com/actgardner/gogen-avro/v10. DO NOT EDIT.
+/*
+ * SOURCE:
+ *     courier_geofence_event_message.avsc
+ */
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/actgardner/gogen-avro/v10/compiler"
+	"github.com/actgardner/gogen-avro/v10/vm"
+	"github.com/actgardner/gogen-avro/v10/vm/types"
+)
+
+var _ = fmt.Printf
+
+// this event describes that courier entered or exited geofence, for example restaurant or drop-off radius of customer, courier id is used as a key for the partition in order to keep events of courier in the correct sequence
+type CourierGeofenceEventMessage struct {
+	Event string `json:"event"`
+
+	Courier_id string `json:"courier_id"`
+
+	Geofence_id string `json:"geofence_id"`
+
+	Geofence_type string `json:"geofence_type"`
+
+	Order_id *UnionStringNull `json:"order_id"`
+
+	Latitude float64 `json:"latitude"`
+
+	Longitude float64 `json:"longitude"`
+
+	Created_at int64 `json:"created_at"`
+}
+
+const CourierGeofenceEventMessageAvroCRC64Fingerprint = "\xb4\x05\x11<\xcf\xe8\b+"
+
+func NewCourierGeofenceEventMessage() CourierGeofenceEventMessage {
+	r := CourierGeofenceEventMessage{}
+	r.Order_id = NewUnionStringNull()
+
+	return r
+}
+
+func DeserializeCourierGeofenceEventMessage(r io.Reader) (CourierGeofenceEventMessage, error) {
+	t := NewCourierGeofenceEventMessage()
+	deser, err := compiler.CompileSchemaBytes([]byte(t.Schema()), []byte(t.Schema()))
+	if err != nil {
+		return t, err
+	}
+
+	err = vm.Eval(r, deser, &t)
+	return t, err
+}
+
+func DeserializeCourierGeofenceEventMessageFromSchema(r io.Reader, schema string) (CourierGeofenceEventMessage, error) {
+	t := NewCourierGeofenceEventMessage()
+
+	deser, err := compiler.CompileSchemaBytes([]byte(schema), []byte(t.Schema()))
+	if err != nil {
+		return t, err
+	}
+
+	err = vm.Eval(r, deser, &t)
+	return t, err
+}
+
+func writeCourierGeofenceEventMessage(r CourierGeofenceEventMessage, w io.Writer) error {
+	var err error
+	err = vm.WriteString(r.Event, w)
+	if err != nil {
+		return err
+	}
+	err = vm.WriteString(r.Courier_id, w)
+	if err != nil {
+		return err
+	}
+	err = vm.WriteString(r.Geofence_id, w)
+	if err != nil {
+		return err
+	}
+	err = vm.WriteString(r.Geofence_type, w)
+	if err != nil {
+		return err
+	}
+	err = writeUnionStringNull(r.Order_id, w)
+	if err != nil {
+		return err
+	}
+	err = vm.WriteDouble(r.Latitude, w)
+	if err != nil {
+		return err
+	}
+	err = vm.WriteDouble(r.Longitude, w)
+	if err != nil {
+		return err
+	}
+	err = vm.WriteLong(r.Created_at, w)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func (r CourierGeofenceEventMessage) Serialize(w io.Writer) error {
+	return writeCourierGeofenceEventMessage(r, w)
+}
+
+func (r CourierGeofenceEventMessage) Schema() string {
+	return "{\"doc\":\"this event describes that courier entered or exited geofence, for example restaurant or drop-off radius of customer, courier id is used as a key for the partition in order to keep events of courier in the correct sequence\",\"fields\":[{\"name\":\"event\",\"type\":\"string\"},{\"name\":\"courier_id\",\"type\":{\"logicalType\":\"uuid\",\"type\":\"string\"}},{\"name\":\"geofence_id\",\"type\":{\"logicalType\":\"uuid\",\"type\":\"string\"}},{\"name\":\"geofence_type\",\"type\":\"string\"},{\"name\":\"order_id\",\"type\":[{\"logicalType\":\"uuid\",\"type\":\"string\"},\"null\"]},{\"name\":\"latitude\",\"type\":\"double\"},{\"name\":\"longitude\",\"type\":\"double\"},{\"name\":\"created_at\",\"type\":{\"logicalType\":\"timestamp-millis\",\"type\":\"long\"}}],\"name\":\"CourierGeofenceEventMessage\",\"type\":\"record\"}"
+}
+
+func (r CourierGeofenceEventMessage) SchemaName() string {
+	return "CourierGeofenceEventMessage"
+}
+
+func (_ CourierGeofenceEventMessage) SetBoolean(v bool)    { panic("Unsupported operation") }
+func (_ CourierGeofenceEventMessage) SetInt(v int32)       { panic("Unsupported operation") }
+func (_ CourierGeofenceEventMessage) SetLong(v int64)      { panic("Unsupported operation") }
+func (_ CourierGeofenceEventMessage) SetFloat(v float32)   { panic("Unsupported operation") }
+func (_ CourierGeofenceEventMessage) SetDouble(v float64)  { panic("Unsupported operation") }
+func (_ CourierGeofenceEventMessage) SetBytes(v []byte)    { panic("Unsupported operation") }
+func (_ CourierGeofenceEventMessage) SetString(v string)   { panic("Unsupported operation") }
+func (_ CourierGeofenceEventMessage) SetUnionElem(v int64) { panic("Unsupported operation") }
+
+func (r *CourierGeofenceEventMessage) Get(i int) types.Field {
+	switch i {
+	case 0:
+		w := types.String{Target: &r.Event}
+
+		return w
+
+	case 1:
+		w := types.String{Target: &r.Courier_id}
+
+		return w
+
+	case 2:
+		w := types.String{Target: &r.Geofence_id}
+
+		return w
+
+	case 3:
+		w := types.String{Target: &r.Geofence_type}
+
+		return w
+
+	case 4:
+		r.Order_id = NewUnionStringNull()
+
+		return r.Order_id
+	case 5:
+		w := types.Double{Target: &r.Latitude}
+
+		return w
+
+	case 6:
+		w := types.Double{Target: &r.Longitude}
+
+		return w
+
+	case 7:
+		w := types.Long{Target: &r.Created_at}
+
+		return w
+
+	}
+	panic("Unknown field index")
+}
+
+func (r *CourierGeofenceEventMessage) SetDefault(i int) {
+	switch i {
+	}
+	panic("Unknown field index")
+}
+
+func (r *CourierGeofenceEventMessage) NullField(i int) {
+	switch i {
+	case 4:
+		r.Order_id = nil
+		return
+	}
+	panic("Not a nullable field index")
+}
+
+func (_ CourierGeofenceEventMessage) AppendMap(key string) types.Field {
+	panic("Unsupported operation")
+}
+func (_ CourierGeofenceEventMessage) AppendArray() types.Field { panic("Unsupported operation") }
+func (_ CourierGeofenceEventMessage) HintSize(int)             { panic("Unsupported operation") }
+func (_ CourierGeofenceEventMessage) Finalize()                {}
+
+func (_ CourierGeofenceEventMessage) AvroCRC64Fingerprint() []byte {
+	return []byte(CourierGeofenceEventMessageAvroCRC64Fingerprint)
+}
+
+func (r CourierGeofenceEventMessage) MarshalJSON() ([]byte, error) {
+	var err error
+	output := make(map[string]json.RawMessage)
+	output["event"], err = json.Marshal(r.Event)
+	if err != nil {
+		return nil, err
+	}
+	output["courier_id"], err = json.Marshal(r.Courier_id)
+	if err != nil {
+		return nil, err
+	}
+	output["geofence_id"], err = json.Marshal(r.Geofence_id)
+	if err != nil {
+		return nil, err
+	}
+	output["geofence_type"], err = json.Marshal(r.Geofence_type)
+	if err != nil {
+		return nil, err
+	}
+	output["order_id"], err = json.Marshal(r.Order_id)
+	if err != nil {
+		return nil, err
+	}
+	output["latitude"], err = json.Marshal(r.Latitude)
+	if err != nil {
+		return nil, err
+	}
+	output["longitude"], err = json.Marshal(r.Longitude)
+	if err != nil {
+		return nil, err
+	}
+	output["created_at"], err = json.Marshal(r.Created_at)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(output)
+}
+
+func (r *CourierGeofenceEventMessage) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var val json.RawMessage
+	val = func() json.RawMessage {
+		if v, ok := fields["event"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Event); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("no value specified for event")
+	}
+	val = func() json.RawMessage {
+		if v, ok := fields["courier_id"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Courier_id); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("no value specified for courier_id")
+	}
+	val = func() json.RawMessage {
+		if v, ok := fields["geofence_id"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Geofence_id); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("no value specified for geofence_id")
+	}
+	val = func() json.RawMessage {
+		if v, ok := fields["geofence_type"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Geofence_type); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("no value specified for geofence_type")
+	}
+	val = func() json.RawMessage {
+		if v, ok := fields["order_id"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Order_id); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("no value specified for order_id")
+	}
+	val = func() json.RawMessage {
+		if v, ok := fields["latitude"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Latitude); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("no value specified for latitude")
+	}
+	val = func() json.RawMessage {
+		if v, ok := fields["longitude"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Longitude); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("no value specified for longitude")
+	}
+	val = func() json.RawMessage {
+		if v, ok := fields["created_at"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Created_at); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("no value specified for created_at")
+	}
+	return nil
+}
@@ -26,12 +26,18 @@ type LatestCourierLocationMessage struct {
 	Longitude float64 `json:"longitude"`
 
 	Created_at int64 `json:"created_at"`
+	// foot, bicycle, scooter or car, empty when courier did not send it.
+	Vehicle_type string `json:"vehicle_type"`
+	// gps filter flagged position, because courier moved faster than his vehicle can.
+	Is_implausible bool `json:"is_implausible"`
 }
 
-const LatestCourierLocationMessageAvroCRC64Fingerprint = "\x15APo\xde¥\x11"
+const LatestCourierLocationMessageAvroCRC64Fingerprint = "\x03\xd2ȫm\xde\x19\xdf"
 
 func NewLatestCourierLocationMessage() LatestCourierLocationMessage {
 	r := LatestCourierLocationMessage{}
+	r.Vehicle_type = ""
+	r.Is_implausible = false
 	return r
 }
 
@@ -76,6 +82,14 @@ func writeLatestCourierLocationMessage(r LatestCourierLocationMessage, w io.Writ
 	if err != nil {
 		return err
 	}
+	err = vm.WriteString(r.Vehicle_type, w)
+	if err != nil {
+		return err
+	}
+	err = vm.WriteBool(r.Is_implausible, w)
+	if err != nil {
+		return err
+	}
 	return err
 }
 
@@ -84,7 +98,7 @@ func (r LatestCourierLocationMessage) Serialize(w io.Writer) error {
 }
 
 func (r LatestCourierLocationMessage) Schema() string {
-	return "{\"doc\":\"this event describes latest coords courier and we can track movement courier.\",\"fields\":[{\"name\":\"courier_id\",\"type\":\"string\"},{\"name\":\"latitude\",\"type\":\"double\"},{\"name\":\"longitude\",\"type\":\"double\"},{\"name\":\"created_at\",\"type\":{\"logicalType\":\"timestamp-millis\",\"type\":\"long\"}}],\"name\":\"LatestCourierLocationMessage\",\"type\":\"record\"}"
+	return "{\"doc\":\"this event describes latest coords courier and we can track movement courier.\",\"fields\":[{\"name\":\"courier_id\",\"type\":\"string\"},{\"name\":\"latitude\",\"type\":\"double\"},{\"name\":\"longitude\",\"type\":\"double\"},{\"name\":\"created_at\",\"type\":{\"logicalType\":\"timestamp-millis\",\"type\":\"long\"}},{\"default\":\"\",\"doc\":\"foot, bicycle, scooter or car, empty when courier did not send it.\",\"name\":\"vehicle_type\",\"type\":\"string\"},{\"default\":false,\"doc\":\"gps filter flagged position, because courier moved faster than his vehicle can.\",\"name\":\"is_implausible\",\"type\":\"boolean\"}],\"name\":\"LatestCourierLocationMessage\",\"type\":\"record\"}"
 }
 
 func (r LatestCourierLocationMessage) SchemaName() string {
@@ -122,12 +136,28 @@ func (r *LatestCourierLocationMessage) Get(i int) types.Field {
 
 		return w
 
+	case 4:
+		w := types.String{Target: &r.Vehicle_type}
+
+		return w
+
+	case 5:
+		w := types.Boolean{Target: &r.Is_implausible}
+
+		return w
+
 	}
 	panic("Unknown field index")
 }
 
 func (r *LatestCourierLocationMessage) SetDefault(i int) {
 	switch i {
+	case 4:
+		r.Vehicle_type = ""
+		return
+	case 5:
+		r.Is_implausible = false
+		return
 	}
 	panic("Unknown field index")
 }
@@ -168,6 +198,14 @@ func (r LatestCourierLocationMessage) MarshalJSON() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	output["vehicle_type"], err = json.Marshal(r.Vehicle_type)
+	if err != nil {
+		return nil, err
+	}
+	output["is_implausible"], err = json.Marshal(r.Is_implausible)
+	if err != nil {
+		return nil, err
+	}
 	return json.Marshal(output)
 }
 
@@ -234,5 +272,33 @@ func (r *LatestCourierLocationMessage) UnmarshalJSON(data []byte) error {
 	} else {
 		return fmt.Errorf("no value specified for created_at")
 	}
+	val = func() json.RawMessage {
+		if v, ok := fields["vehicle_type"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Vehicle_type); err != nil {
+			return err
+		}
+	} else {
+		r.Vehicle_type = ""
+	}
+	val = func() json.RawMessage {
+		if v, ok := fields["is_implausible"]; ok {
+			return v
+		}
+		return nil
+	}()
+
+	if val != nil {
+		if err := json.Unmarshal([]byte(val), &r.Is_implausible); err != nil {
+			return err
+		}
+	} else {
+		r.Is_implausible = false
+	}
 	return nil
 }
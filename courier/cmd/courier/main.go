@@ -12,6 +12,7 @@ import (
 
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
+	"github.com/steteruk/go-delivery-service/avro/v1"
 	"github.com/steteruk/go-delivery-service/courier/domain"
 	"github.com/steteruk/go-delivery-service/courier/env"
 	courierGrpc "github.com/steteruk/go-delivery-service/courier/grpc"
@@ -20,6 +21,7 @@ import (
 	"github.com/steteruk/go-delivery-service/courier/storage/postgres"
 	pkghttp "github.com/steteruk/go-delivery-service/pkg/http"
 	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/tracing"
 )
 
 func main() {
@@ -29,6 +31,13 @@ func main() {
 		return
 	}
 
+	shutdownTracing, err := tracing.NewTracerProvider(context.Background(), "courier", config.Tracing)
+	if err != nil {
+		log.Printf("failed to create tracer provider: %v\n", err)
+		return
+	}
+	defer shutdownTracing(context.Background())
+
 	credsPostgres := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable", config.DBUser, config.DBPassword, config.DBName)
 	client, err := sql.Open("postgres", credsPostgres)
 	if err != nil {
@@ -38,10 +47,24 @@ func main() {
 
 	courierRepo := postgres.NewCourierRepository(client)
 
-	publisher, err := pkgkafka.NewPublisher([]string{config.KafkaAddress}, []string{config.KafkaSchemaRegistryAddress}, kafka.OrderTopicValidation)
+	publisher, err := pkgkafka.NewPublisher(pkgkafka.PublisherOptions{
+		Address:               []string{config.KafkaAddress},
+		SchemaRegistryAddress: []string{config.KafkaSchemaRegistryAddress},
+		Topic:                 kafka.OrderTopicValidation,
+		Partitioner:           config.PublisherPartitioner,
+		Security:              config.KafkaSecurity,
+		Producer:              config.KafkaPublisher,
+	})
 	if err != nil {
 		log.Panicf("failed to create publisher: %v\n", err)
 	}
+	publisher.SetSync(config.PublisherSync)
+	defer publisher.Close()
+	if config.SchemaCompatibilityCheck {
+		if err := publisher.CheckSchemaCompatibility(context.Background(), avro.NewOrderValidationMessage().Schema(), config.SchemaCompatibilityLevel); err != nil {
+			log.Panicf("failed to check schema compatibility: %v\n", err)
+		}
+	}
 	orderValidationPublisher := kafka.NewOrderValidationPublisher(publisher)
 
 	courierGrpcConn, err := courierGrpc.NewCourierConnection(config.CourierGrpcPort)
@@ -58,13 +81,21 @@ func main() {
 
 	defer stop()
 
-	wg.Add(2)
+	consumerAdminHandler := pkghttp.NewConsumerAdminHandler(pkghttp.NewHandler())
+
+	wg.Add(3)
 	go runHttpServer(ctx, config, &wg, courierService)
-	go runOrderConsumer(ctx, courierService, &wg, config)
+	go runAdminServer(ctx, config, &wg, consumerAdminHandler)
+	go runOrderConsumer(ctx, stop, courierService, &wg, config, consumerAdminHandler)
 	wg.Wait()
 }
 
-func runHttpServer(ctx context.Context, config env.Config, wg *sync.WaitGroup, courierService domain.CourierService) {
+func runHttpServer(
+	ctx context.Context,
+	config env.Config,
+	wg *sync.WaitGroup,
+	courierService domain.CourierService,
+) {
 	courierHandler := handler.NewCourierHandler(courierService, pkghttp.NewHandler())
 	courierLatestPositionURL := fmt.Sprintf(
 		"/couriers/{courier_id:%s}",
@@ -80,6 +111,7 @@ func runHttpServer(ctx context.Context, config env.Config, wg *sync.WaitGroup, c
 			Handler: courierHandler.GetCourierHandler,
 			Method:  "GET",
 		},
+		"/metrics": pkghttp.MetricsRoute(),
 	}
 
 	router := pkghttp.NewRoute(routes, mux.NewRouter())
@@ -87,26 +119,81 @@ func runHttpServer(ctx context.Context, config env.Config, wg *sync.WaitGroup, c
 	wg.Done()
 }
 
-func runOrderConsumer(ctx context.Context, courierService domain.CourierService, wg *sync.WaitGroup, config env.Config) {
+// runAdminServer serves admin routes of consumers on internal admin port, they are not exposed with public api.
+func runAdminServer(ctx context.Context, config env.Config, wg *sync.WaitGroup, consumerAdminHandler *pkghttp.ConsumerAdminHandler) {
+	defer wg.Done()
+	pkghttp.ServerRun(ctx, consumerAdminHandler.Router(), config.AdminPortServer)
+}
+
+func runOrderConsumer(
+	ctx context.Context,
+	stop context.CancelFunc,
+	courierService domain.CourierService,
+	wg *sync.WaitGroup,
+	config env.Config,
+	consumerAdminHandler *pkghttp.ConsumerAdminHandler,
+) {
 	defer wg.Done()
 	orderConsumer := kafka.NewOrderConsumer(courierService)
-	consumer, err := pkgkafka.NewConsumer(
-		orderConsumer,
-		config.KafkaAddress,
-		config.Verbose,
-		config.Oldest,
-		config.Assignor,
-		kafka.OrderTopic,
-		[]string{config.KafkaSchemaRegistryAddress},
+	consumer, err := pkgkafka.NewAvroConsumer(
+		pkgkafka.NewAvroHandler[avro.OrderMessage](orderConsumer),
+		pkgkafka.ConsumerOptions{
+			Brokers:               config.KafkaAddress,
+			SchemaRegistryAddress: []string{config.KafkaSchemaRegistryAddress},
+			Topic:                 kafka.OrderTopic,
+			Group:                 kafka.OrderTopic,
+			Assignor:              config.Assignor,
+			Oldest:                config.Oldest,
+			Verbose:               config.Verbose,
+			Security:              config.KafkaSecurity,
+			// retry topics are written in transactions of exactly-once consumer.
+			ReadCommitted: config.ConsumerExactlyOnce,
+		},
 	)
 
 	if err != nil {
 		log.Panicf("Failed to create kafka consumer group: %v\n", err)
 	}
 
+	if config.ConsumerDeadLetterEnabled {
+		deadLetterPublisher, err := pkgkafka.NewDeadLetterPublisherWithSecurity([]string{config.KafkaAddress}, config.KafkaSecurity)
+		if err != nil {
+			log.Panicf("Failed to create dead letter publisher: %v\n", err)
+		}
+		defer deadLetterPublisher.Close()
+		consumer.SetDeadLetterPublisher(deadLetterPublisher)
+	}
+	if config.ConsumerExactlyOnce {
+		// validation of order and offset of order are committed together, so crash does not duplicate validations.
+		consumer.SetTransactionalProducers(pkgkafka.NewTransactionalProducerFactory(pkgkafka.PublisherOptions{
+			Address:     []string{config.KafkaAddress},
+			Partitioner: config.PublisherPartitioner,
+			Security:    config.KafkaSecurity,
+			Producer:    config.KafkaPublisher,
+		}))
+	}
+	consumer.SetKeyWorkers(config.ConsumerKeyWorkers)
+	err = consumer.SetRetryPolicy(pkgkafka.RetryPolicy{
+		MaxAttempts:      config.ConsumerMaxAttempts,
+		InitialBackoff:   config.ConsumerRetryInitialBackoff,
+		MaxBackoff:       config.ConsumerRetryMaxBackoff,
+		RetryTopicDelays: config.ConsumerRetryTopicDelays,
+	})
+	if err != nil {
+		log.Panicf("Failed to set retry policy of consumer: %v\n", err)
+	}
+	consumer.SetReconnectPolicy(pkgkafka.RetryPolicy{
+		MaxAttempts:    config.ConsumerReconnectMaxAttempts,
+		InitialBackoff: config.ConsumerReconnectInitialBackoff,
+		MaxBackoff:     config.ConsumerReconnectMaxBackoff,
+	})
+	consumerAdminHandler.AddConsumer(consumer)
+
 	err = consumer.ConsumeMessage(ctx)
 
 	if err != nil {
-		log.Panicf("Failed to consume message: %v\n", err)
+		log.Printf("Failed to consume message: %v\n", err)
+		// service without consumer is useless, so other consumers and servers are stopped too.
+		stop()
 	}
 }
@@ -1,25 +1,50 @@
 package env
 
 import (
+	"time"
+
 	coreEnv "github.com/caarlos0/env/v9"
+	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/tracing"
 )
 
 type Config struct {
-	DBName                     string `env:"POSTGRES_DB" envDefault:"courier"`
-	DBPassword                 string `env:"POSTGRES_PASSWORD" envDefault:"S3cret"`
-	DBUser                     string `env:"POSTGRES_USER" envDefault:"citizix_user"`
-	PortServer                 string `env:"PORT_SERVER" envDefault:":8883"`
-	CourierGrpcPort            string `env:"COURIER_GRPC_PORT" envDefault:":9667"`
-	AssignCourierGrpcPort      string `env:"ASSIGN_COURIER_GRPC_PORT" envDefault:":9671"`
-	KafkaAddress               string `env:"KAFKA_BROKERS" envDefault:"localhost:9092"`
-	KafkaSchemaRegistryAddress string `env:"KAFKA_SCHEMA_REGISTRY_ADDRESS" envDefault:"http://localhost:8085"`
-	Assignor                   string `env:"KAFKA_CONSUMER_ASSIGNOR" envDefault:"range"`
-	Oldest                     bool   `env:"KAFKA_CONSUMER_OLDEST" envDefault:"true"`
-	Verbose                    bool   `env:"KAFKA_CONSUMER_VERBOSE" envDefault:"false"`
+	DBName                          string                   `env:"POSTGRES_DB" envDefault:"courier"`
+	DBPassword                      string                   `env:"POSTGRES_PASSWORD" envDefault:"S3cret"`
+	DBUser                          string                   `env:"POSTGRES_USER" envDefault:"citizix_user"`
+	PortServer                      string                   `env:"PORT_SERVER" envDefault:":8883"`
+	AdminPortServer                 string                   `env:"ADMIN_PORT_SERVER" envDefault:"127.0.0.1:8884"`
+	CourierGrpcPort                 string                   `env:"COURIER_GRPC_PORT" envDefault:":9667"`
+	AssignCourierGrpcPort           string                   `env:"ASSIGN_COURIER_GRPC_PORT" envDefault:":9671"`
+	KafkaAddress                    string                   `env:"KAFKA_BROKERS" envDefault:"localhost:9092"`
+	KafkaSchemaRegistryAddress      string                   `env:"KAFKA_SCHEMA_REGISTRY_ADDRESS" envDefault:"http://localhost:8085"`
+	Assignor                        string                   `env:"KAFKA_CONSUMER_ASSIGNOR" envDefault:"range"`
+	Oldest                          bool                     `env:"KAFKA_CONSUMER_OLDEST" envDefault:"true"`
+	Verbose                         bool                     `env:"KAFKA_CONSUMER_VERBOSE" envDefault:"false"`
+	PublisherSync                   bool                     `env:"KAFKA_PUBLISHER_SYNC" envDefault:"true"`
+	PublisherPartitioner            string                   `env:"KAFKA_PUBLISHER_PARTITIONER" envDefault:"murmur2"`
+	SchemaCompatibilityCheck        bool                     `env:"KAFKA_SCHEMA_COMPATIBILITY_CHECK" envDefault:"true"`
+	SchemaCompatibilityLevel        string                   `env:"KAFKA_SCHEMA_COMPATIBILITY_LEVEL"`
+	ConsumerDeadLetterEnabled       bool                     `env:"KAFKA_CONSUMER_DEAD_LETTER_ENABLED" envDefault:"true"`
+	ConsumerMaxAttempts             int                      `env:"KAFKA_CONSUMER_MAX_ATTEMPTS" envDefault:"3"`
+	ConsumerKeyWorkers              int                      `env:"KAFKA_CONSUMER_KEY_WORKERS" envDefault:"0"`
+	ConsumerRetryInitialBackoff     time.Duration            `env:"KAFKA_CONSUMER_RETRY_INITIAL_BACKOFF" envDefault:"100ms"`
+	ConsumerRetryMaxBackoff         time.Duration            `env:"KAFKA_CONSUMER_RETRY_MAX_BACKOFF" envDefault:"5s"`
+	ConsumerReconnectMaxAttempts    int                      `env:"KAFKA_CONSUMER_RECONNECT_MAX_ATTEMPTS" envDefault:"0"`
+	ConsumerReconnectInitialBackoff time.Duration            `env:"KAFKA_CONSUMER_RECONNECT_INITIAL_BACKOFF" envDefault:"1s"`
+	ConsumerReconnectMaxBackoff     time.Duration            `env:"KAFKA_CONSUMER_RECONNECT_MAX_BACKOFF" envDefault:"30s"`
+	ConsumerRetryTopicDelays        []time.Duration          `env:"KAFKA_CONSUMER_RETRY_TOPIC_DELAYS" envSeparator:","`
+	ConsumerExactlyOnce             bool                     `env:"KAFKA_CONSUMER_EXACTLY_ONCE" envDefault:"true"`
+	KafkaSecurity                   pkgkafka.SecurityOptions `envPrefix:"KAFKA_"`
+	KafkaPublisher                  pkgkafka.ProducerOptions `envPrefix:"KAFKA_PUBLISHER_"`
+	Tracing                         tracing.Options          `envPrefix:"TRACING_"`
 }
 
 func GetConfig() (config Config, err error) {
-	cfg := Config{}
+	cfg := Config{
+		// validations of orders must not be duplicated on retries of producer, so publisher is idempotent by default.
+		KafkaPublisher: pkgkafka.ProducerOptions{Idempotent: true},
+	}
 	err = coreEnv.Parse(&cfg)
 
 	return cfg, err
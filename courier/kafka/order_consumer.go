@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/steteruk/go-delivery-service/avro/v1"
-	"log"
 
 	"github.com/steteruk/go-delivery-service/courier/domain"
 )
@@ -39,15 +38,8 @@ func NewOrderConsumer(
 	return courierConsumer
 }
 
-// HandleJSONMessage Handle kafka message in json format
-func (orderConsumer *OrderConsumer) HandleJSONMessage(ctx context.Context, message []byte) error {
-	orderMessage := avro.NewOrderMessage()
-	if err := orderMessage.UnmarshalJSON(message); err != nil {
-		log.Printf("failed to unmarshal Kafka message into courier order message struct: %v\n", err)
-
-		return nil
-	}
-
+// HandleMessage Handle order message, message of newer schema version is resolved to schema of avro.OrderMessage
+func (orderConsumer *OrderConsumer) HandleMessage(ctx context.Context, orderMessage avro.OrderMessage) error {
 	if orderMessage.Event == "updated" {
 		return nil
 	}
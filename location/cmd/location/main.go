@@ -7,6 +7,7 @@ import (
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
 	coreRedis "github.com/redis/go-redis/v9"
+	"github.com/steteruk/go-delivery-service/avro/v1"
 	"github.com/steteruk/go-delivery-service/location/domain"
 	"github.com/steteruk/go-delivery-service/location/env"
 	server "github.com/steteruk/go-delivery-service/location/grpc"
@@ -17,6 +18,7 @@ import (
 	wp "github.com/steteruk/go-delivery-service/location/workerpool"
 	pkghttp "github.com/steteruk/go-delivery-service/pkg/http"
 	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/tracing"
 	pb "github.com/steteruk/go-delivery-service/proto/generate/location/v1"
 	"google.golang.org/grpc"
 	"log"
@@ -35,13 +37,39 @@ func main() {
 		return
 	}
 
-	publisher, err := pkgkafka.NewPublisher([]string{config.KafkaAddress}, []string{config.KafkaSchemaRegistryAddress}, kafka.LatestPositionCourierTopic)
+	shutdownTracing, err := tracing.NewTracerProvider(context.Background(), "location", config.Tracing)
+	if err != nil {
+		log.Printf("failed to create tracer provider: %v\n", err)
+		return
+	}
+	defer shutdownTracing(context.Background())
+
+	publisher, err := pkgkafka.NewPublisher(pkgkafka.PublisherOptions{
+		Address:               []string{config.KafkaAddress},
+		SchemaRegistryAddress: []string{config.KafkaSchemaRegistryAddress},
+		Topic:                 kafka.LatestPositionCourierTopic,
+		Partitioner:           config.PublisherPartitioner,
+		Security:              config.KafkaSecurity,
+		Producer:              config.KafkaPublisher,
+	})
 
 	if err != nil {
 		log.Printf("failed to create publisher: %v\n", err)
 		return
 	}
-	courierLocationPublisher := kafka.NewCourierLocationPublisher(publisher)
+	publisher.SetSync(config.PublisherSync)
+	defer publisher.Close()
+	if config.SchemaCompatibilityCheck {
+		if err := publisher.CheckSchemaCompatibility(context.Background(), avro.NewLatestCourierLocationMessage().Schema(), config.SchemaCompatibilityLevel); err != nil {
+			log.Printf("failed to check schema compatibility: %v\n", err)
+			return
+		}
+	}
+	courierLocationPublisher, err := kafka.NewCourierLocationPublisher(publisher)
+	if err != nil {
+		log.Printf("failed to create courier location publisher: %v\n", err)
+		return
+	}
 
 	clientRedis := coreRedis.NewClient(&coreRedis.Options{
 		Addr: config.AddrRedis,
@@ -50,7 +78,26 @@ func main() {
 	defer clientRedis.Close()
 	repoRedis := redisStorage.NewCourierRepository(clientRedis)
 
-	courierService := domain.NewCourierService(repoRedis, courierLocationPublisher)
+	var locationFilter domain.CourierLocationFilterInterface
+	if config.GpsFilterEnabled {
+		locationFilter = domain.NewLocationFilter(domain.LocationFilterConfig{
+			MaxSpeeds: map[string]float64{
+				domain.VehicleTypeFoot:    config.GpsFilterMaxSpeedFoot,
+				domain.VehicleTypeBicycle: config.GpsFilterMaxSpeedBicycle,
+				domain.VehicleTypeScooter: config.GpsFilterMaxSpeedScooter,
+				domain.VehicleTypeCar:     config.GpsFilterMaxSpeedCar,
+			},
+			DefaultVehicleType: config.GpsFilterDefaultVehicleType,
+			RejectImplausible:  config.GpsFilterRejectImplausible,
+			Smoothing:          config.GpsFilterSmoothing,
+			ProcessNoise:       config.GpsFilterProcessNoise,
+			Accuracy:           config.GpsFilterAccuracy,
+			MaxRejections:      config.GpsFilterMaxRejections,
+			MaxGap:             time.Duration(config.GpsFilterMaxGap) * time.Second,
+		})
+	}
+
+	courierService := domain.NewCourierService(repoRedis, courierLocationPublisher, locationFilter)
 
 	credsDb := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable", config.DbUser, config.DbPassword, config.DbName)
 	dbClient, err := sql.Open("postgres", credsDb)
@@ -60,6 +107,17 @@ func main() {
 	defer dbClient.Close()
 	repoPostgres := postgres.NewCourierRepository(dbClient)
 
+	var latestPositionRepo domain.CourierRepositoryInterface
+	switch config.CourierLatestPositionStorage {
+	case "redis":
+		latestPositionRepo = redisStorage.NewCourierRepositoryWithFallback(clientRedis, repoPostgres)
+	case "postgres":
+		latestPositionRepo = repoPostgres
+	default:
+		log.Printf("unrecognized courier latest position storage: %s\n", config.CourierLatestPositionStorage)
+		return
+	}
+
 	var wg sync.WaitGroup
 	locationWorkerPool := wp.NewLocationPool(
 		courierService,
@@ -74,25 +132,62 @@ func main() {
 
 	wg.Add(3)
 	go locationWorkerPool.Run(ctx, &wg)
-	go runHttpServer(ctx, config, &wg, locationWorkerPool)
-	go runGrpc(ctx, config, &wg, repoPostgres)
+	go runHttpServer(
+		ctx,
+		config,
+		&wg,
+		locationWorkerPool,
+		domain.NewCourierActivityService(repoPostgres),
+		domain.NewGeofenceService(postgres.NewGeofenceRepository(dbClient)),
+	)
+	go runGrpc(ctx, config, &wg, latestPositionRepo)
 	wg.Wait()
 }
 
-func runHttpServer(ctx context.Context, config env.Config, wg *sync.WaitGroup, locationWorkerPool domain.CourierLocationWorkerPool) {
+func runHttpServer(
+	ctx context.Context,
+	config env.Config,
+	wg *sync.WaitGroup,
+	locationWorkerPool domain.CourierLocationWorkerPool,
+	activityService *domain.CourierActivityService,
+	geofenceService *domain.GeofenceService,
+) {
 	locationHandler := handler.NewLocationHandler(locationWorkerPool, pkghttp.NewHandler())
+	activityHandler := handler.NewActivityHandler(activityService, pkghttp.NewHandler())
+	geofenceHandler := handler.NewGeofenceHandler(geofenceService, pkghttp.NewHandler())
 	var courierLocationURL = fmt.Sprintf(
 		"/courier/{courier_id:%s}/location",
 		"[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}",
 	)
+	var courierActivityURL = fmt.Sprintf(
+		"/courier/{courier_id:%s}/activity",
+		"[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}",
+	)
+	var geofenceURL = fmt.Sprintf(
+		"/geofences/{geofence_id:%s}",
+		"[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}",
+	)
 	routes := map[string]pkghttp.Route{
 		courierLocationURL: {
 			Handler: locationHandler.LatestLocationHandler,
 			Method:  "POST",
 		},
+		courierActivityURL: {
+			Handler: activityHandler.GetActivityHandler,
+			Method:  "GET",
+		},
+		geofenceURL: {
+			Handler: geofenceHandler.DeleteGeofenceHandler,
+			Method:  "DELETE",
+		},
+		"/metrics": pkghttp.MetricsRoute(),
 	}
 
-	router := pkghttp.NewRoute(routes, mux.NewRouter())
+	router := mux.NewRouter()
+	router.HandleFunc("/geofences", geofenceHandler.CreateGeofenceHandler).Methods("POST")
+	router.HandleFunc("/geofences", geofenceHandler.GetGeofencesHandler).Methods("GET")
+
+	router = pkghttp.NewRoute(routes, router)
 	pkghttp.ServerRun(ctx, router, config.PortServer)
 	wg.Done()
 }
@@ -4,12 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
+	"github.com/steteruk/go-delivery-service/avro/v1"
+	"github.com/steteruk/go-delivery-service/location/domain"
 	"github.com/steteruk/go-delivery-service/location/env"
 	"github.com/steteruk/go-delivery-service/location/kafka"
 	"github.com/steteruk/go-delivery-service/location/storage/postgres"
+	pkghttp "github.com/steteruk/go-delivery-service/pkg/http"
 	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/tracing"
 	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
 func main() {
@@ -17,7 +27,14 @@ func main() {
 	if err != nil {
 		log.Panicf("failed to parse variable env: %v\n", err)
 	}
-	ctx := context.Background()
+	shutdownTracing, err := tracing.NewTracerProvider(context.Background(), "location-track", config.Tracing)
+	if err != nil {
+		log.Panicf("failed to create tracer provider: %v\n", err)
+	}
+	defer shutdownTracing(context.Background())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	credsPostgres := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable", config.DbUser, config.DbPassword, config.DbName)
 	client, err := sql.Open("postgres", credsPostgres)
 	if err != nil {
@@ -27,24 +44,107 @@ func main() {
 
 	courierRepo := postgres.NewCourierRepository(client)
 
-	courierLocationConsumer := kafka.NewCourierLocationConsumer(courierRepo)
-	consumer, err := pkgkafka.NewConsumer(
-		courierLocationConsumer,
-		config.KafkaAddress,
-		config.Verbose,
-		config.Oldest,
-		config.Assignor,
-		kafka.LatestPositionCourierTopic,
-		[]string{config.KafkaSchemaRegistryAddress},
+	publisher, err := pkgkafka.NewPublisher(pkgkafka.PublisherOptions{
+		Address:               []string{config.KafkaAddress},
+		SchemaRegistryAddress: []string{config.KafkaSchemaRegistryAddress},
+		Topic:                 kafka.CourierGeofenceEventsTopic,
+		Partitioner:           config.PublisherPartitioner,
+		Security:              config.KafkaSecurity,
+		Producer:              config.KafkaPublisher,
+	})
+	if err != nil {
+		log.Panicf("failed to create publisher: %v\n", err)
+	}
+	publisher.SetSync(config.PublisherSync)
+	defer publisher.Close()
+
+	geofenceEventPublisher, err := kafka.NewGeofenceEventPublisher(publisher)
+	if err != nil {
+		log.Panicf("failed to create geofence event publisher: %v\n", err)
+	}
+
+	if config.SchemaCompatibilityCheck {
+		if err := publisher.CheckSchemaCompatibility(context.Background(), avro.NewCourierGeofenceEventMessage().Schema(), config.SchemaCompatibilityLevel); err != nil {
+			log.Panicf("failed to check schema compatibility: %v\n", err)
+		}
+	}
+	geofenceRepo := postgres.NewGeofenceRepository(client)
+	geofenceTracker := domain.NewGeofenceTracker(
+		geofenceRepo,
+		geofenceRepo,
+		geofenceEventPublisher,
+		time.Duration(config.GeofenceRefreshInterval)*time.Second,
+		config.GeofenceExitMargin,
+		time.Duration(config.GeofenceDropOffTTL)*time.Second,
+	)
+
+	courierLocationConsumer := kafka.NewCourierLocationConsumer(
+		courierRepo,
+		courierRepo,
+		time.Duration(config.CourierActivityMaxIdle)*time.Second,
+		geofenceTracker,
+	)
+	consumer, err := pkgkafka.NewAvroConsumer(
+		pkgkafka.NewAvroHandler[avro.LatestCourierLocationMessage](courierLocationConsumer),
+		pkgkafka.ConsumerOptions{
+			Brokers:               config.KafkaAddress,
+			SchemaRegistryAddress: []string{config.KafkaSchemaRegistryAddress},
+			Topic:                 kafka.LatestPositionCourierTopic,
+			Group:                 kafka.LatestPositionCourierTopic,
+			Assignor:              config.Assignor,
+			Oldest:                config.Oldest,
+			Verbose:               config.Verbose,
+			Security:              config.KafkaSecurity,
+		},
 	)
 
 	if err != nil {
 		log.Panicf("Failed to create kafka consumer group: %v\n", err)
 	}
 
+	if config.ConsumerDeadLetterEnabled {
+		deadLetterPublisher, err := pkgkafka.NewDeadLetterPublisherWithSecurity([]string{config.KafkaAddress}, config.KafkaSecurity)
+		if err != nil {
+			log.Panicf("Failed to create dead letter publisher: %v\n", err)
+		}
+		defer deadLetterPublisher.Close()
+		consumer.SetDeadLetterPublisher(deadLetterPublisher)
+	}
+	consumer.SetKeyWorkers(config.ConsumerKeyWorkers)
+	err = consumer.SetRetryPolicy(pkgkafka.RetryPolicy{
+		MaxAttempts:      config.ConsumerMaxAttempts,
+		InitialBackoff:   config.ConsumerRetryInitialBackoff,
+		MaxBackoff:       config.ConsumerRetryMaxBackoff,
+		RetryTopicDelays: config.ConsumerRetryTopicDelays,
+	})
+	if err != nil {
+		log.Panicf("Failed to set retry policy of consumer: %v\n", err)
+	}
+	consumer.SetReconnectPolicy(pkgkafka.RetryPolicy{
+		MaxAttempts:    config.ConsumerReconnectMaxAttempts,
+		InitialBackoff: config.ConsumerReconnectInitialBackoff,
+		MaxBackoff:     config.ConsumerReconnectMaxBackoff,
+	})
+
+	consumerAdminHandler := pkghttp.NewConsumerAdminHandler(pkghttp.NewHandler(), consumer)
+	router := pkghttp.NewRoute(map[string]pkghttp.Route{"/metrics": pkghttp.MetricsRoute()}, mux.NewRouter())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		pkghttp.ServerRun(ctx, router, config.TrackPortServer)
+	}()
+	// admin routes of consumer are served only on internal admin port.
+	go func() {
+		defer wg.Done()
+		pkghttp.ServerRun(ctx, consumerAdminHandler.Router(), config.TrackAdminPortServer)
+	}()
+
 	err = consumer.ConsumeMessage(ctx)
 
 	if err != nil {
-		log.Panicf("Failed to consume message: %v\n", err)
+		log.Printf("Failed to consume message: %v\n", err)
 	}
+	stop()
+	wg.Wait()
 }
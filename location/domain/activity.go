@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/geo"
+)
+
+const (
+	ActivityPeriodDaily  = "daily"
+	ActivityPeriodWeekly = "weekly"
+)
+
+// CourierDailyActivity keeps distance and active time of courier for one day.
+type CourierDailyActivity struct {
+	CourierID      string
+	Day            time.Time
+	DistanceMeters float64
+	ActiveSeconds  int64
+}
+
+// CourierActivityTotal shows how many kilometres courier drove and how many minutes he was active for a period.
+type CourierActivityTotal struct {
+	PeriodStart   time.Time `json:"period_start"`
+	DistanceKm    float64   `json:"distance_km"`
+	ActiveMinutes float64   `json:"active_minutes"`
+}
+
+// CourierActivityRepositoryInterface saves running aggregates of courier activity in storage.
+type CourierActivityRepositoryInterface interface {
+	SaveCourierActivity(ctx context.Context, courierLocation *CourierLocation, maxIdle time.Duration) error
+	GetCourierDailyActivities(ctx context.Context, courierID string, from time.Time, to time.Time) ([]*CourierDailyActivity, error)
+}
+
+// CourierActivityService returns totals of courier activity for courier pay.
+type CourierActivityService struct {
+	activityRepository CourierActivityRepositoryInterface
+}
+
+// NewCourierActivityService creates service for getting courier activity.
+func NewCourierActivityService(activityRepository CourierActivityRepositoryInterface) *CourierActivityService {
+	return &CourierActivityService{activityRepository: activityRepository}
+}
+
+// CourierActivityDeltas returns distance and time between latest position and new position by days, way which crosses
+// midnight is split between days in proportion of time. We don't count time when courier was idle more than maxIdle,
+// there is nothing to count for the first position of courier and for position which is older than latest one.
+func CourierActivityDeltas(latestLocation *CourierLocation, courierLocation *CourierLocation, maxIdle time.Duration) []*CourierDailyActivity {
+	if latestLocation == nil {
+		return nil
+	}
+
+	duration := courierLocation.CreatedAt.Sub(latestLocation.CreatedAt)
+	if duration <= 0 || duration > maxIdle {
+		return nil
+	}
+
+	distance := geo.DistanceMeters(latestLocation.Point(), courierLocation.Point())
+	started := latestLocation.CreatedAt.UTC()
+	finished := courierLocation.CreatedAt.UTC()
+
+	var deltas []*CourierDailyActivity
+	for start := started; start.Before(finished); {
+		day := start.Truncate(24 * time.Hour)
+		end := day.AddDate(0, 0, 1)
+		if end.After(finished) {
+			end = finished
+		}
+
+		// seconds are counted from start of way, so seconds of days give the same sum as whole way.
+		deltas = append(deltas, &CourierDailyActivity{
+			CourierID:      courierLocation.CourierID,
+			Day:            day,
+			DistanceMeters: distance * float64(end.Sub(start)) / float64(duration),
+			ActiveSeconds:  int64(end.Sub(started).Seconds()) - int64(start.Sub(started).Seconds()),
+		})
+		start = end
+	}
+
+	return deltas
+}
+
+// GetCourierActivityTotals returns daily or weekly totals for courier between from and to days.
+func (s *CourierActivityService) GetCourierActivityTotals(
+	ctx context.Context,
+	courierID string,
+	period string,
+	from time.Time,
+	to time.Time,
+) ([]*CourierActivityTotal, error) {
+	activities, err := s.activityRepository.GetCourierDailyActivities(ctx, courierID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier activities from the repository: %w", err)
+	}
+
+	totals := make([]*CourierActivityTotal, 0, len(activities))
+	totalsByPeriod := make(map[time.Time]*CourierActivityTotal)
+	for _, activity := range activities {
+		periodStart := activity.Day
+		if period == ActivityPeriodWeekly {
+			periodStart = startOfWeek(activity.Day)
+		}
+
+		total, ok := totalsByPeriod[periodStart]
+		if !ok {
+			total = &CourierActivityTotal{PeriodStart: periodStart}
+			totalsByPeriod[periodStart] = total
+			totals = append(totals, total)
+		}
+
+		total.DistanceKm += activity.DistanceMeters / 1000
+		total.ActiveMinutes += float64(activity.ActiveSeconds) / 60
+	}
+
+	return totals, nil
+}
+
+// startOfWeek returns monday of the week.
+func startOfWeek(day time.Time) time.Time {
+	weekday := (int(day.Weekday()) + 6) % 7
+
+	return day.AddDate(0, 0, -weekday)
+}
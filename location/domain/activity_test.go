@@ -0,0 +1,93 @@
+package domain_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/location/domain"
+	"github.com/steteruk/go-delivery-service/pkg/geo"
+)
+
+func courierLocationAt(latitude float64, createdAt time.Time) *domain.CourierLocation {
+	return &domain.CourierLocation{CourierID: courierID, Latitude: latitude, Longitude: 30.52, CreatedAt: createdAt}
+}
+
+func expectDelta(t *testing.T, delta *domain.CourierDailyActivity, day time.Time, distanceMeters float64, activeSeconds int64) {
+	t.Helper()
+
+	if !delta.Day.Equal(day) || math.Abs(delta.DistanceMeters-distanceMeters) > 0.001 || delta.ActiveSeconds != activeSeconds {
+		t.Fatalf("expected %v, %.3f m, %d s, got %v, %.3f m, %d s", day, distanceMeters, activeSeconds, delta.Day, delta.DistanceMeters, delta.ActiveSeconds)
+	}
+}
+
+func TestCourierActivityDeltasCountsWayInsideDay(t *testing.T) {
+	started := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	latest := courierLocationAt(50.45, started)
+	current := courierLocationAt(50.46, started.Add(10*time.Minute))
+
+	deltas := domain.CourierActivityDeltas(latest, current, time.Hour)
+	if len(deltas) != 1 {
+		t.Fatalf("expected 1 delta, got %d", len(deltas))
+	}
+
+	expectDelta(t, deltas[0], time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), geo.DistanceMeters(latest.Point(), current.Point()), 600)
+}
+
+func TestCourierActivityDeltasSplitsWayAtMidnight(t *testing.T) {
+	latest := courierLocationAt(50.45, time.Date(2026, 10, 19, 23, 55, 0, 0, time.UTC))
+	current := courierLocationAt(50.46, time.Date(2026, 10, 20, 0, 5, 0, 0, time.UTC))
+	distance := geo.DistanceMeters(latest.Point(), current.Point())
+
+	deltas := domain.CourierActivityDeltas(latest, current, time.Hour)
+	if len(deltas) != 2 {
+		t.Fatalf("expected 2 deltas, got %d", len(deltas))
+	}
+
+	expectDelta(t, deltas[0], time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), distance/2, 300)
+	expectDelta(t, deltas[1], time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), distance/2, 300)
+}
+
+func TestCourierActivityDeltasSplitsGapAcrossSeveralDays(t *testing.T) {
+	// 60 hours: 6 hours of the first day, two whole days and 6 hours of the last day.
+	latest := courierLocationAt(50.45, time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC))
+	current := courierLocationAt(50.55, time.Date(2026, 10, 22, 6, 0, 0, 0, time.UTC))
+	distance := geo.DistanceMeters(latest.Point(), current.Point())
+
+	deltas := domain.CourierActivityDeltas(latest, current, 72*time.Hour)
+	if len(deltas) != 4 {
+		t.Fatalf("expected 4 deltas, got %d", len(deltas))
+	}
+
+	expectDelta(t, deltas[0], time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), distance*6/60, 6*3600)
+	expectDelta(t, deltas[1], time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), distance*24/60, 24*3600)
+	expectDelta(t, deltas[2], time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC), distance*24/60, 24*3600)
+	expectDelta(t, deltas[3], time.Date(2026, 10, 22, 0, 0, 0, 0, time.UTC), distance*6/60, 6*3600)
+}
+
+func TestCourierActivityDeltasKeepsSumOfSecondsOfWay(t *testing.T) {
+	latest := courierLocationAt(50.45, time.Date(2026, 10, 19, 23, 59, 59, 600_000_000, time.UTC))
+	current := courierLocationAt(50.46, time.Date(2026, 10, 20, 0, 0, 1, 200_000_000, time.UTC))
+
+	deltas := domain.CourierActivityDeltas(latest, current, time.Hour)
+	if len(deltas) != 2 || deltas[0].ActiveSeconds+deltas[1].ActiveSeconds != 1 {
+		t.Fatalf("expected 1 second of way split between 2 days, got %+v", deltas)
+	}
+}
+
+func TestCourierActivityDeltasSkipsIdleAndOldPositions(t *testing.T) {
+	started := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	latest := courierLocationAt(50.45, started)
+
+	if deltas := domain.CourierActivityDeltas(nil, latest, time.Hour); deltas != nil {
+		t.Fatalf("expected no deltas for the first position, got %+v", deltas)
+	}
+
+	if deltas := domain.CourierActivityDeltas(latest, courierLocationAt(50.46, started.Add(2*time.Hour)), time.Hour); deltas != nil {
+		t.Fatalf("expected no deltas after idle, got %+v", deltas)
+	}
+
+	if deltas := domain.CourierActivityDeltas(latest, courierLocationAt(50.46, started.Add(-time.Minute)), time.Hour); deltas != nil {
+		t.Fatalf("expected no deltas for old position, got %+v", deltas)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/geo"
 )
 
 var ErrCourierLocationNotFound = errors.New("courier location was not found")
@@ -25,6 +27,7 @@ type CourierLocationWorkerPool interface {
 type CourierService struct {
 	courierRepository CourierLocationRepositoryInterface
 	courierPublisher  CourierLocationPublisherInterface
+	locationFilter    CourierLocationFilterInterface
 }
 
 // CourierLocationRepositoryInterface saves latest location position courier in storage.
@@ -43,11 +46,16 @@ type CourierLocationPublisherInterface interface {
 	PublishLatestCourierLocation(ctx context.Context, courierLocation *CourierLocation) error
 }
 
-// NewCourierService creates model currier location with current data.
-func NewCourierService(repo CourierLocationRepositoryInterface, publisher CourierLocationPublisherInterface) *CourierService {
+// NewCourierService creates model currier location with current data, filter can be nil when we don't filter positions.
+func NewCourierService(
+	repo CourierLocationRepositoryInterface,
+	publisher CourierLocationPublisherInterface,
+	filter CourierLocationFilterInterface,
+) *CourierService {
 	return &CourierService{
 		courierRepository: repo,
 		courierPublisher:  publisher,
+		locationFilter:    filter,
 	}
 }
 
@@ -57,9 +65,25 @@ type CourierLocation struct {
 	Latitude  float64   `json:"latitude"`
 	Longitude float64   `json:"longitude"`
 	CreatedAt time.Time `json:"created_at"`
+	// VehicleType is optional, filter uses default vehicle type when it is empty.
+	VehicleType   string `json:"vehicle_type,omitempty"`
+	IsImplausible bool   `json:"is_implausible,omitempty"`
+}
+
+// Point returns coords of courier location.
+func (cl *CourierLocation) Point() geo.Point {
+	return geo.Point{Latitude: cl.Latitude, Longitude: cl.Longitude}
 }
 
 func (cs *CourierService) SaveLatestCourierLocation(ctx context.Context, courierLocation *CourierLocation) error {
+	if cs.locationFilter != nil {
+		filteredLocation, err := cs.locationFilter.FilterCourierLocation(courierLocation)
+		if err != nil {
+			return fmt.Errorf("failed to filter latest courier location: %w", err)
+		}
+		courierLocation = filteredLocation
+	}
+
 	err := cs.courierRepository.SaveLatestCourierGeoPosition(ctx, courierLocation)
 	if err != nil {
 		return fmt.Errorf("failed to store latest courier location in the repository: %w", err)
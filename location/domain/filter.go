@@ -0,0 +1,182 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/geo"
+)
+
+// ErrImplausibleCourierLocation returns when courier moved faster than his vehicle can.
+var ErrImplausibleCourierLocation = errors.New("courier location is implausible")
+
+const (
+	VehicleTypeFoot    = "foot"
+	VehicleTypeBicycle = "bicycle"
+	VehicleTypeScooter = "scooter"
+	VehicleTypeCar     = "car"
+)
+
+// CourierLocationFilterInterface drops or corrects noisy positions before saving.
+type CourierLocationFilterInterface interface {
+	FilterCourierLocation(courierLocation *CourierLocation) (*CourierLocation, error)
+}
+
+// LocationFilterConfig describes how we filter courier positions.
+type LocationFilterConfig struct {
+	// MaxSpeeds max speed in km/h for every vehicle type.
+	MaxSpeeds          map[string]float64
+	DefaultVehicleType string
+	// RejectImplausible rejects point when it is true, otherwise point is only flagged.
+	RejectImplausible bool
+	Smoothing         bool
+	// ProcessNoise how fast in m/s we expect position changes, uses by Kalman filter.
+	ProcessNoise float64
+	// Accuracy of gps position in metres, uses by Kalman filter.
+	Accuracy float64
+	// MaxRejections is number of implausible positions in a row after which track starts again from new position,
+	// so courier is not stuck when previous position was wrong. Zero never starts track again.
+	MaxRejections int
+	// MaxGap is time between positions after which track starts again from new position, tracks which were not
+	// updated for this time are evicted. Zero keeps tracks forever.
+	MaxGap time.Duration
+}
+
+type courierTrack struct {
+	latest     CourierLocation
+	variance   float64
+	rejections int
+	updatedAt  time.Time
+}
+
+// LocationFilter checks speed between consecutive positions of courier and smooths positions with simple Kalman filter.
+type LocationFilter struct {
+	config    LocationFilterConfig
+	mu        sync.Mutex
+	tracks    map[string]*courierTrack
+	evictedAt time.Time
+}
+
+// NewLocationFilter creates filter for courier positions.
+func NewLocationFilter(config LocationFilterConfig) *LocationFilter {
+	return &LocationFilter{
+		config:    config,
+		tracks:    make(map[string]*courierTrack),
+		evictedAt: time.Now(),
+	}
+}
+
+// FilterCourierLocation returns position which we can save or ErrImplausibleCourierLocation.
+func (f *LocationFilter) FilterCourierLocation(courierLocation *CourierLocation) (*CourierLocation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	f.evictTracks(now)
+
+	track, ok := f.tracks[courierLocation.CourierID]
+	if !ok || f.isGap(track, courierLocation) {
+		f.tracks[courierLocation.CourierID] = &courierTrack{
+			latest:    *courierLocation,
+			variance:  f.config.Accuracy * f.config.Accuracy,
+			updatedAt: now,
+		}
+
+		return courierLocation, nil
+	}
+	track.updatedAt = now
+
+	seconds := courierLocation.CreatedAt.Sub(track.latest.CreatedAt).Seconds()
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	distance := geo.DistanceMeters(track.latest.Point(), courierLocation.Point())
+	speed := distance / seconds * 3.6
+	maxSpeed := f.maxSpeed(courierLocation.VehicleType)
+
+	if speed > maxSpeed {
+		track.rejections++
+		if f.config.MaxRejections > 0 && track.rejections >= f.config.MaxRejections {
+			// previous position was wrong more likely than all positions after it, so track starts from new position.
+			log.Printf("courier %s has %d implausible positions in a row, track starts again\n", courierLocation.CourierID, track.rejections)
+			track.latest = *courierLocation
+			track.variance = f.config.Accuracy * f.config.Accuracy
+			track.rejections = 0
+
+			return courierLocation, nil
+		}
+
+		if f.config.RejectImplausible {
+			return nil, fmt.Errorf("%w: speed %.1f km/h is more than %.1f km/h", ErrImplausibleCourierLocation, speed, maxSpeed)
+		}
+
+		log.Printf("courier %s has implausible speed %.1f km/h\n", courierLocation.CourierID, speed)
+		flagged := *courierLocation
+		flagged.IsImplausible = true
+
+		return &flagged, nil
+	}
+
+	track.rejections = 0
+	filtered := *courierLocation
+	if f.config.Smoothing {
+		f.smooth(track, &filtered, seconds)
+	}
+	track.latest = filtered
+
+	return &filtered, nil
+}
+
+// isGap checks that courier did not send positions for too long time, so speed and variance of track are meaningless.
+func (f *LocationFilter) isGap(track *courierTrack, courierLocation *CourierLocation) bool {
+	return f.config.MaxGap > 0 && courierLocation.CreatedAt.Sub(track.latest.CreatedAt) > f.config.MaxGap
+}
+
+// evictTracks deletes tracks which were not updated for max gap, they would start again anyway.
+func (f *LocationFilter) evictTracks(now time.Time) {
+	if f.config.MaxGap <= 0 || now.Sub(f.evictedAt) < f.config.MaxGap {
+		return
+	}
+
+	for courierID, track := range f.tracks {
+		if now.Sub(track.updatedAt) > f.config.MaxGap {
+			delete(f.tracks, courierID)
+		}
+	}
+	f.evictedAt = now
+}
+
+// smooth moves position to the estimate of Kalman filter. Variance is in metres squared, so offset of position
+// is converted to metres to east and north of latest position and back to degrees after correction.
+func (f *LocationFilter) smooth(track *courierTrack, courierLocation *CourierLocation, seconds float64) {
+	track.variance += seconds * f.config.ProcessNoise * f.config.ProcessNoise
+	measurementVariance := f.config.Accuracy * f.config.Accuracy
+	gain := track.variance / (track.variance + measurementVariance)
+
+	metersInDegreeOfLongitude := metersInDegreeOfLatitude * math.Cos(track.latest.Latitude*math.Pi/180)
+	north := (courierLocation.Latitude - track.latest.Latitude) * metersInDegreeOfLatitude
+	east := (courierLocation.Longitude - track.latest.Longitude) * metersInDegreeOfLongitude
+
+	courierLocation.Latitude = track.latest.Latitude + gain*north/metersInDegreeOfLatitude
+	if metersInDegreeOfLongitude > 0 {
+		courierLocation.Longitude = track.latest.Longitude + gain*east/metersInDegreeOfLongitude
+	}
+	track.variance = (1 - gain) * track.variance
+}
+
+func (f *LocationFilter) maxSpeed(vehicleType string) float64 {
+	if maxSpeed, ok := f.config.MaxSpeeds[vehicleType]; ok {
+		return maxSpeed
+	}
+
+	if maxSpeed, ok := f.config.MaxSpeeds[f.config.DefaultVehicleType]; ok {
+		return maxSpeed
+	}
+
+	return math.Inf(1)
+}
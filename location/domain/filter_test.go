@@ -0,0 +1,128 @@
+package domain_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/location/domain"
+	"github.com/steteruk/go-delivery-service/pkg/geo"
+)
+
+func newLocationFilterConfig() domain.LocationFilterConfig {
+	return domain.LocationFilterConfig{
+		MaxSpeeds:          map[string]float64{domain.VehicleTypeBicycle: 40},
+		DefaultVehicleType: domain.VehicleTypeBicycle,
+		RejectImplausible:  true,
+		ProcessNoise:       10,
+		Accuracy:           10,
+	}
+}
+
+func filterCourierLocation(t *testing.T, filter *domain.LocationFilter, courierLocation *domain.CourierLocation) *domain.CourierLocation {
+	t.Helper()
+
+	filtered, err := filter.FilterCourierLocation(courierLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return filtered
+}
+
+func TestFilterCourierLocationRejectsOutlier(t *testing.T) {
+	filter := domain.NewLocationFilter(newLocationFilterConfig())
+	started := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	filterCourierLocation(t, filter, courierLocationAt(50.45, started))
+
+	// 1.1 km in 10 seconds is about 400 km/h.
+	_, err := filter.FilterCourierLocation(courierLocationAt(50.46, started.Add(10*time.Second)))
+	if !errors.Is(err, domain.ErrImplausibleCourierLocation) {
+		t.Fatalf("expected implausible location, got %v", err)
+	}
+
+	// outlier is not latest position of track, so next position is checked from first one.
+	filterCourierLocation(t, filter, courierLocationAt(50.4501, started.Add(20*time.Second)))
+}
+
+func TestFilterCourierLocationFlagsOutlier(t *testing.T) {
+	config := newLocationFilterConfig()
+	config.RejectImplausible = false
+	filter := domain.NewLocationFilter(config)
+	started := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	filterCourierLocation(t, filter, courierLocationAt(50.45, started))
+
+	if filtered := filterCourierLocation(t, filter, courierLocationAt(50.46, started.Add(10*time.Second))); !filtered.IsImplausible {
+		t.Fatal("expected implausible location flagged")
+	}
+}
+
+func TestFilterCourierLocationStartsTrackAgainAfterRejections(t *testing.T) {
+	config := newLocationFilterConfig()
+	config.MaxRejections = 2
+	filter := domain.NewLocationFilter(config)
+	started := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	filterCourierLocation(t, filter, courierLocationAt(50.45, started))
+
+	if _, err := filter.FilterCourierLocation(courierLocationAt(50.46, started.Add(10*time.Second))); err == nil {
+		t.Fatal("expected first outlier rejected")
+	}
+
+	filterCourierLocation(t, filter, courierLocationAt(50.46, started.Add(20*time.Second)))
+	filterCourierLocation(t, filter, courierLocationAt(50.4601, started.Add(30*time.Second)))
+}
+
+func TestFilterCourierLocationStartsTrackAgainAfterMaxGap(t *testing.T) {
+	config := newLocationFilterConfig()
+	config.MaxGap = 5 * time.Minute
+	filter := domain.NewLocationFilter(config)
+	started := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	filterCourierLocation(t, filter, courierLocationAt(50.45, started))
+
+	// 11 km in 6 minutes is faster than bicycle, but speed is not checked after gap.
+	filtered := filterCourierLocation(t, filter, courierLocationAt(50.55, started.Add(6*time.Minute)))
+	if filtered.Latitude != 50.55 {
+		t.Fatalf("expected position after gap not changed, got %v", filtered.Latitude)
+	}
+}
+
+func TestFilterCourierLocationEvictsTrackNotUpdatedForMaxGap(t *testing.T) {
+	config := newLocationFilterConfig()
+	config.MaxGap = 20 * time.Millisecond
+	filter := domain.NewLocationFilter(config)
+	createdAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	filterCourierLocation(t, filter, courierLocationAt(50.45, createdAt))
+
+	if _, err := filter.FilterCourierLocation(courierLocationAt(50.46, createdAt)); err == nil {
+		t.Fatal("expected outlier rejected while track exists")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	// position has the same time as latest one, so only eviction of track lets it be first position of new track.
+	filterCourierLocation(t, filter, courierLocationAt(50.46, createdAt))
+}
+
+func TestFilterCourierLocationSmoothsPositionInMetres(t *testing.T) {
+	config := newLocationFilterConfig()
+	config.Smoothing = true
+	filter := domain.NewLocationFilter(config)
+	started := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	latest := &domain.CourierLocation{CourierID: courierID, Latitude: 50.45, Longitude: 30.52, CreatedAt: started}
+	filterCourierLocation(t, filter, latest)
+
+	// variance is 100 m² of accuracy and 100 m² of process noise for 1 second, so gain is 200 / (200 + 100).
+	current := &domain.CourierLocation{CourierID: courierID, Latitude: 50.45005, Longitude: 30.52005, CreatedAt: started.Add(time.Second)}
+	filtered := filterCourierLocation(t, filter, current)
+
+	distance := geo.DistanceMeters(latest.Point(), current.Point())
+	smoothed := geo.DistanceMeters(latest.Point(), filtered.Point())
+	if math.Abs(smoothed-distance*2/3) > 0.01 {
+		t.Fatalf("expected %.3f m from latest position, got %.3f m", distance*2/3, smoothed)
+	}
+
+	if rest := geo.DistanceMeters(filtered.Point(), current.Point()); math.Abs(rest-distance/3) > 0.01 {
+		t.Fatalf("expected smoothed position on way to measured one, got %.3f m to it", rest)
+	}
+}
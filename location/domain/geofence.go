@@ -0,0 +1,358 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/geo"
+)
+
+var ErrGeofenceNotFound = errors.New("geofence was not found")
+
+const (
+	GeofenceEventCourierEntered = "courier_entered"
+	GeofenceEventCourierExited  = "courier_exited"
+)
+
+const (
+	GeofenceTypeRestaurant = "restaurant"
+	GeofenceTypeWarehouse  = "warehouse"
+	GeofenceTypeDropOff    = "drop_off"
+)
+
+// Geofence is circle around restaurant, warehouse or customer address, drop-off geofence has order id and courier of order.
+type Geofence struct {
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	OrderID      string  `json:"order_id,omitempty"`
+	CourierID    string  `json:"courier_id,omitempty"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	RadiusMeters float64 `json:"radius_meters"`
+}
+
+// GeofenceEvent describes that courier entered or exited geofence.
+type GeofenceEvent struct {
+	Event           string
+	Geofence        *Geofence
+	CourierLocation *CourierLocation
+}
+
+// GeofenceRepositoryInterface saves and gets geofences from storage.
+type GeofenceRepositoryInterface interface {
+	SaveGeofence(ctx context.Context, geofence *Geofence) (*Geofence, error)
+	GetGeofences(ctx context.Context) ([]*Geofence, error)
+	DeleteGeofence(ctx context.Context, geofenceID string) error
+	// DeleteGeofencesCreatedBefore deletes geofences of type which were created before time.
+	DeleteGeofencesCreatedBefore(ctx context.Context, geofenceType string, before time.Time) error
+}
+
+// CourierGeofenceRepositoryInterface keeps geofences where courier is now, so restart of tracker does not send enter events again.
+type CourierGeofenceRepositoryInterface interface {
+	GetCourierGeofenceIDs(ctx context.Context, courierID string) ([]string, error)
+	SaveCourierGeofence(ctx context.Context, courierID string, geofenceID string, enteredAt time.Time) error
+	DeleteCourierGeofence(ctx context.Context, courierID string, geofenceID string) error
+}
+
+// GeofenceEventPublisherInterface publishes geofence events for other services.
+type GeofenceEventPublisherInterface interface {
+	PublishGeofenceEvent(ctx context.Context, geofenceEvent *GeofenceEvent) error
+}
+
+// CourierGeofenceTrackerInterface tracks geofences of courier by his positions.
+type CourierGeofenceTrackerInterface interface {
+	TrackCourierLocation(ctx context.Context, courierLocation *CourierLocation) error
+}
+
+// GeofenceService manages geofences.
+type GeofenceService struct {
+	geofenceRepository GeofenceRepositoryInterface
+}
+
+// NewGeofenceService creates service for managing geofences.
+func NewGeofenceService(geofenceRepository GeofenceRepositoryInterface) *GeofenceService {
+	return &GeofenceService{geofenceRepository: geofenceRepository}
+}
+
+// CreateGeofence saves new geofence.
+func (s *GeofenceService) CreateGeofence(ctx context.Context, geofence *Geofence) (*Geofence, error) {
+	return s.geofenceRepository.SaveGeofence(ctx, geofence)
+}
+
+// GetGeofences returns all geofences.
+func (s *GeofenceService) GetGeofences(ctx context.Context) ([]*Geofence, error) {
+	return s.geofenceRepository.GetGeofences(ctx)
+}
+
+// DeleteGeofence deletes geofence, couriers inside it don't get exit event.
+func (s *GeofenceService) DeleteGeofence(ctx context.Context, geofenceID string) error {
+	return s.geofenceRepository.DeleteGeofence(ctx, geofenceID)
+}
+
+// Contains checks that point is not farther from center than radius plus margin.
+func (g *Geofence) Contains(point geo.Point, marginMeters float64) bool {
+	radius := g.RadiusMeters + marginMeters
+
+	// cheap check of bounding box before haversine, one degree of latitude is about 111 km.
+	deltaLatitude := radius / metersInDegreeOfLatitude
+	if math.Abs(point.Latitude-g.Latitude) > deltaLatitude {
+		return false
+	}
+
+	cosLatitude := math.Cos(g.Latitude * math.Pi / 180)
+	if cosLatitude > 0.01 && math.Abs(point.Longitude-g.Longitude) > deltaLatitude/cosLatitude {
+		return false
+	}
+
+	return geo.DistanceMeters(geo.Point{Latitude: g.Latitude, Longitude: g.Longitude}, point) <= radius
+}
+
+// GeofenceTracker publishes event when courier enters or exits geofence, geofences where courier is now are kept in repository
+// and in memory, so position is checked without round trip to database. Geofences are looked up by grid index near position.
+// Courier exits geofence only when he is farther than radius plus exit margin, so gps noise on the border does not produce events.
+// Drop-off geofence is deleted when courier of order exits it or when it is older than drop-off ttl, so list of geofences does not grow.
+type GeofenceTracker struct {
+	geofenceRepository        GeofenceRepositoryInterface
+	courierGeofenceRepository CourierGeofenceRepositoryInterface
+	eventPublisher            GeofenceEventPublisherInterface
+	refreshInterval           time.Duration
+	exitMarginMeters          float64
+	dropOffTTL                time.Duration
+
+	mu          sync.Mutex
+	index       *geofenceIndex
+	refreshedAt time.Time
+	couriers    map[string]*courierGeofences
+}
+
+// courierGeofences is geofences where courier is now, it is loaded from repository again after refresh interval,
+// so other instance of tracker which handled courier before rebalance does not leave stale state.
+type courierGeofences struct {
+	geofenceIDs map[string]bool
+	loadedAt    time.Time
+}
+
+// NewGeofenceTracker creates tracker, it reloads geofences from repository every refreshInterval.
+// Drop-off geofences older than dropOffTTL are deleted on reload, zero ttl keeps them until courier exits.
+func NewGeofenceTracker(
+	geofenceRepository GeofenceRepositoryInterface,
+	courierGeofenceRepository CourierGeofenceRepositoryInterface,
+	eventPublisher GeofenceEventPublisherInterface,
+	refreshInterval time.Duration,
+	exitMarginMeters float64,
+	dropOffTTL time.Duration,
+) *GeofenceTracker {
+	return &GeofenceTracker{
+		geofenceRepository:        geofenceRepository,
+		courierGeofenceRepository: courierGeofenceRepository,
+		eventPublisher:            eventPublisher,
+		refreshInterval:           refreshInterval,
+		exitMarginMeters:          exitMarginMeters,
+		dropOffTTL:                dropOffTTL,
+		couriers:                  make(map[string]*courierGeofences),
+	}
+}
+
+// TrackCourierLocation compares geofences of courier before and after new position and publishes events for changes.
+// State of courier is saved only after event was published, so failed message is handled again with the same events.
+func (t *GeofenceTracker) TrackCourierLocation(ctx context.Context, courierLocation *CourierLocation) error {
+	events, err := t.detectGeofenceEvents(ctx, courierLocation)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err := t.eventPublisher.PublishGeofenceEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to publish geofence event: %w", err)
+		}
+
+		if err := t.saveGeofenceEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *GeofenceTracker) detectGeofenceEvents(ctx context.Context, courierLocation *CourierLocation) ([]*GeofenceEvent, error) {
+	index, err := t.currentIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	insideGeofenceIDs, err := t.courierGeofenceIDs(ctx, courierLocation.CourierID)
+	if err != nil {
+		return nil, err
+	}
+
+	point := courierLocation.Point()
+	var events []*GeofenceEvent
+	for _, geofenceID := range insideGeofenceIDs {
+		// geofence which was deleted is not in the index, so we forget about it without event.
+		geofence, ok := index.geofence(geofenceID)
+		if ok && !geofence.Contains(point, t.exitMarginMeters) {
+			events = append(events, &GeofenceEvent{
+				Event:           GeofenceEventCourierExited,
+				Geofence:        geofence,
+				CourierLocation: courierLocation,
+			})
+		}
+	}
+
+	insideGeofences := make(map[string]bool, len(insideGeofenceIDs))
+	for _, geofenceID := range insideGeofenceIDs {
+		insideGeofences[geofenceID] = true
+	}
+
+	for _, geofence := range index.nearby(point) {
+		if !insideGeofences[geofence.ID] && geofence.Contains(point, 0) {
+			events = append(events, &GeofenceEvent{
+				Event:           GeofenceEventCourierEntered,
+				Geofence:        geofence,
+				CourierLocation: courierLocation,
+			})
+		}
+	}
+
+	return events, nil
+}
+
+// saveGeofenceEvent saves that courier is inside or outside geofence, drop-off geofence is deleted after courier of order exited it.
+func (t *GeofenceTracker) saveGeofenceEvent(ctx context.Context, event *GeofenceEvent) error {
+	courierID := event.CourierLocation.CourierID
+	if event.Event == GeofenceEventCourierEntered {
+		if err := t.courierGeofenceRepository.SaveCourierGeofence(ctx, courierID, event.Geofence.ID, event.CourierLocation.CreatedAt); err != nil {
+			return fmt.Errorf("failed to save geofence of courier: %w", err)
+		}
+		t.setCourierGeofence(courierID, event.Geofence.ID, true)
+
+		return nil
+	}
+
+	if err := t.courierGeofenceRepository.DeleteCourierGeofence(ctx, courierID, event.Geofence.ID); err != nil {
+		return fmt.Errorf("failed to delete geofence of courier: %w", err)
+	}
+	t.setCourierGeofence(courierID, event.Geofence.ID, false)
+
+	// other courier can pass near address of customer, only courier of order finishes drop-off.
+	if event.Geofence.Type != GeofenceTypeDropOff || event.Geofence.CourierID != courierID {
+		return nil
+	}
+
+	err := t.geofenceRepository.DeleteGeofence(ctx, event.Geofence.ID)
+	if err != nil && !errors.Is(err, ErrGeofenceNotFound) {
+		return fmt.Errorf("failed to delete drop-off geofence: %w", err)
+	}
+
+	t.forgetGeofence(event.Geofence.ID)
+
+	return nil
+}
+
+// courierGeofenceIDs returns sorted ids of geofences where courier is now, repository is used only when state is not in memory or is old.
+func (t *GeofenceTracker) courierGeofenceIDs(ctx context.Context, courierID string) ([]string, error) {
+	t.mu.Lock()
+	state, ok := t.couriers[courierID]
+	t.mu.Unlock()
+
+	if !ok || time.Since(state.loadedAt) >= t.refreshInterval {
+		geofenceIDs, err := t.courierGeofenceRepository.GetCourierGeofenceIDs(ctx, courierID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get geofences of courier from the repository: %w", err)
+		}
+
+		state = &courierGeofences{geofenceIDs: make(map[string]bool, len(geofenceIDs)), loadedAt: time.Now()}
+		for _, geofenceID := range geofenceIDs {
+			state.geofenceIDs[geofenceID] = true
+		}
+
+		t.mu.Lock()
+		t.couriers[courierID] = state
+		t.mu.Unlock()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	geofenceIDs := make([]string, 0, len(state.geofenceIDs))
+	for geofenceID := range state.geofenceIDs {
+		geofenceIDs = append(geofenceIDs, geofenceID)
+	}
+	sort.Strings(geofenceIDs)
+
+	return geofenceIDs, nil
+}
+
+func (t *GeofenceTracker) setCourierGeofence(courierID string, geofenceID string, isInside bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.couriers[courierID]
+	if !ok {
+		return
+	}
+
+	if isInside {
+		state.geofenceIDs[geofenceID] = true
+
+		return
+	}
+
+	delete(state.geofenceIDs, geofenceID)
+}
+
+func (t *GeofenceTracker) forgetGeofence(geofenceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.index != nil {
+		t.index.remove(geofenceID)
+	}
+}
+
+func (t *GeofenceTracker) currentIndex(ctx context.Context) (*geofenceIndex, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.refreshGeofences(ctx); err != nil {
+		return nil, err
+	}
+
+	return t.index, nil
+}
+
+func (t *GeofenceTracker) refreshGeofences(ctx context.Context) error {
+	if t.index != nil && time.Since(t.refreshedAt) < t.refreshInterval {
+		return nil
+	}
+
+	if t.dropOffTTL > 0 {
+		err := t.geofenceRepository.DeleteGeofencesCreatedBefore(ctx, GeofenceTypeDropOff, time.Now().Add(-t.dropOffTTL))
+		if err != nil {
+			return fmt.Errorf("failed to delete expired drop-off geofences: %w", err)
+		}
+	}
+
+	geofences, err := t.geofenceRepository.GetGeofences(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get geofences from the repository: %w", err)
+	}
+
+	t.index = newGeofenceIndex(geofences, t.exitMarginMeters)
+	t.refreshedAt = time.Now()
+
+	// couriers who did not send position since last refresh are loaded from repository again, so memory does not grow.
+	for courierID, state := range t.couriers {
+		if time.Since(state.loadedAt) >= t.refreshInterval {
+			delete(t.couriers, courierID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"math"
+
+	"github.com/steteruk/go-delivery-service/pkg/geo"
+)
+
+const (
+	// geofenceIndexCellDegrees is size of cell of geofence index, it is about 1 km of latitude.
+	geofenceIndexCellDegrees = 0.01
+	// geofenceIndexMaxCells is limit of cells of one geofence, bigger geofences are checked for every position.
+	geofenceIndexMaxCells = 400
+	// metersInDegreeOfLatitude converts degrees to metres for bounding box of geofence and for location filter.
+	metersInDegreeOfLatitude = 111_000
+)
+
+type geofenceCell struct {
+	latitude  int
+	longitude int
+}
+
+func newGeofenceCell(point geo.Point) geofenceCell {
+	return geofenceCell{
+		latitude:  int(math.Floor(point.Latitude / geofenceIndexCellDegrees)),
+		longitude: int(math.Floor(point.Longitude / geofenceIndexCellDegrees)),
+	}
+}
+
+// geofenceIndex finds geofences near position by grid of cells. Geofence is added to every cell of its bounding box
+// with margin, so position inside geofence or inside its margin is always in one of its cells.
+type geofenceIndex struct {
+	geofences map[string]*Geofence
+	cells     map[geofenceCell][]*Geofence
+	large     []*Geofence
+}
+
+func newGeofenceIndex(geofences []*Geofence, marginMeters float64) *geofenceIndex {
+	index := &geofenceIndex{
+		geofences: make(map[string]*Geofence, len(geofences)),
+		cells:     make(map[geofenceCell][]*Geofence),
+	}
+
+	for _, geofence := range geofences {
+		index.add(geofence, marginMeters)
+	}
+
+	return index
+}
+
+func (index *geofenceIndex) add(geofence *Geofence, marginMeters float64) {
+	index.geofences[geofence.ID] = geofence
+
+	deltaLatitude := (geofence.RadiusMeters + marginMeters) / metersInDegreeOfLatitude
+	deltaLongitude := deltaLatitude / math.Max(math.Cos(geofence.Latitude*math.Pi/180), 0.01)
+	from := newGeofenceCell(geo.Point{Latitude: geofence.Latitude - deltaLatitude, Longitude: geofence.Longitude - deltaLongitude})
+	to := newGeofenceCell(geo.Point{Latitude: geofence.Latitude + deltaLatitude, Longitude: geofence.Longitude + deltaLongitude})
+
+	if (to.latitude-from.latitude+1)*(to.longitude-from.longitude+1) > geofenceIndexMaxCells {
+		index.large = append(index.large, geofence)
+
+		return
+	}
+
+	for latitude := from.latitude; latitude <= to.latitude; latitude++ {
+		for longitude := from.longitude; longitude <= to.longitude; longitude++ {
+			cell := geofenceCell{latitude: latitude, longitude: longitude}
+			index.cells[cell] = append(index.cells[cell], geofence)
+		}
+	}
+}
+
+// nearby returns geofences which can contain point, deleted geofences are skipped.
+func (index *geofenceIndex) nearby(point geo.Point) []*Geofence {
+	var geofences []*Geofence
+	for _, candidates := range [][]*Geofence{index.cells[newGeofenceCell(point)], index.large} {
+		for _, geofence := range candidates {
+			if _, ok := index.geofences[geofence.ID]; ok {
+				geofences = append(geofences, geofence)
+			}
+		}
+	}
+
+	return geofences
+}
+
+func (index *geofenceIndex) geofence(geofenceID string) (*Geofence, bool) {
+	geofence, ok := index.geofences[geofenceID]
+
+	return geofence, ok
+}
+
+// remove deletes geofence from index, cells keep it till next reload, but nearby skips it.
+func (index *geofenceIndex) remove(geofenceID string) {
+	delete(index.geofences, geofenceID)
+}
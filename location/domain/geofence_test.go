@@ -0,0 +1,247 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/location/domain"
+)
+
+const (
+	courierID      = "5b1a8c1e-5d3a-4d3f-9f7e-2f0b1c9a6b11"
+	otherCourierID = "0c2f4e2a-6b0d-4a8e-8b61-7e5d3c2b1a00"
+)
+
+type fakeGeofenceRepository struct {
+	geofences map[string]*domain.Geofence
+	gets      int
+}
+
+func (r *fakeGeofenceRepository) SaveGeofence(_ context.Context, geofence *domain.Geofence) (*domain.Geofence, error) {
+	r.geofences[geofence.ID] = geofence
+
+	return geofence, nil
+}
+
+func (r *fakeGeofenceRepository) GetGeofences(_ context.Context) ([]*domain.Geofence, error) {
+	r.gets++
+	geofences := make([]*domain.Geofence, 0, len(r.geofences))
+	for _, geofence := range r.geofences {
+		geofences = append(geofences, geofence)
+	}
+
+	return geofences, nil
+}
+
+func (r *fakeGeofenceRepository) DeleteGeofence(_ context.Context, geofenceID string) error {
+	if _, ok := r.geofences[geofenceID]; !ok {
+		return domain.ErrGeofenceNotFound
+	}
+	delete(r.geofences, geofenceID)
+
+	return nil
+}
+
+func (r *fakeGeofenceRepository) DeleteGeofencesCreatedBefore(_ context.Context, _ string, _ time.Time) error {
+	return nil
+}
+
+type fakeCourierGeofenceRepository struct {
+	geofenceIDs map[string]map[string]bool
+	gets        int
+}
+
+func (r *fakeCourierGeofenceRepository) GetCourierGeofenceIDs(_ context.Context, courierID string) ([]string, error) {
+	r.gets++
+	var geofenceIDs []string
+	for geofenceID := range r.geofenceIDs[courierID] {
+		geofenceIDs = append(geofenceIDs, geofenceID)
+	}
+
+	return geofenceIDs, nil
+}
+
+func (r *fakeCourierGeofenceRepository) SaveCourierGeofence(_ context.Context, courierID string, geofenceID string, _ time.Time) error {
+	if r.geofenceIDs[courierID] == nil {
+		r.geofenceIDs[courierID] = make(map[string]bool)
+	}
+	r.geofenceIDs[courierID][geofenceID] = true
+
+	return nil
+}
+
+func (r *fakeCourierGeofenceRepository) DeleteCourierGeofence(_ context.Context, courierID string, geofenceID string) error {
+	delete(r.geofenceIDs[courierID], geofenceID)
+
+	return nil
+}
+
+type fakeGeofenceEventPublisher struct {
+	events []*domain.GeofenceEvent
+}
+
+func (p *fakeGeofenceEventPublisher) PublishGeofenceEvent(_ context.Context, geofenceEvent *domain.GeofenceEvent) error {
+	p.events = append(p.events, geofenceEvent)
+
+	return nil
+}
+
+type geofenceTrackerTest struct {
+	geofenceRepository        *fakeGeofenceRepository
+	courierGeofenceRepository *fakeCourierGeofenceRepository
+	publisher                 *fakeGeofenceEventPublisher
+	tracker                   *domain.GeofenceTracker
+}
+
+func newGeofenceTrackerTest(geofences ...*domain.Geofence) *geofenceTrackerTest {
+	test := &geofenceTrackerTest{
+		geofenceRepository:        &fakeGeofenceRepository{geofences: make(map[string]*domain.Geofence)},
+		courierGeofenceRepository: &fakeCourierGeofenceRepository{geofenceIDs: make(map[string]map[string]bool)},
+		publisher:                 &fakeGeofenceEventPublisher{},
+	}
+	for _, geofence := range geofences {
+		test.geofenceRepository.geofences[geofence.ID] = geofence
+	}
+	test.tracker = domain.NewGeofenceTracker(
+		test.geofenceRepository,
+		test.courierGeofenceRepository,
+		test.publisher,
+		time.Hour,
+		20,
+		0,
+	)
+
+	return test
+}
+
+// track sends position of courier and returns events which were published for it.
+func (test *geofenceTrackerTest) track(t *testing.T, courierID string, latitude float64, longitude float64) []*domain.GeofenceEvent {
+	t.Helper()
+
+	published := len(test.publisher.events)
+	err := test.tracker.TrackCourierLocation(context.Background(), &domain.CourierLocation{
+		CourierID: courierID,
+		Latitude:  latitude,
+		Longitude: longitude,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return test.publisher.events[published:]
+}
+
+func expectEvent(t *testing.T, events []*domain.GeofenceEvent, event string, geofenceID string) {
+	t.Helper()
+
+	if len(events) != 1 || events[0].Event != event || events[0].Geofence.ID != geofenceID {
+		t.Fatalf("expected %s of geofence %s, got %+v", event, geofenceID, events)
+	}
+}
+
+func restaurantGeofence() *domain.Geofence {
+	return &domain.Geofence{ID: "restaurant", Type: domain.GeofenceTypeRestaurant, Latitude: 50.45, Longitude: 30.52, RadiusMeters: 100}
+}
+
+func dropOffGeofence() *domain.Geofence {
+	return &domain.Geofence{
+		ID:           "drop-off",
+		Type:         domain.GeofenceTypeDropOff,
+		OrderID:      "7f3c9a52-1e8b-4c6d-a0f4-9b2e5d7c8a13",
+		CourierID:    courierID,
+		Latitude:     50.46,
+		Longitude:    30.53,
+		RadiusMeters: 50,
+	}
+}
+
+func TestTrackCourierLocationPublishesEnterAndExitOfGeofence(t *testing.T) {
+	test := newGeofenceTrackerTest(restaurantGeofence())
+
+	if events := test.track(t, courierID, 50.44, 30.52); len(events) != 0 {
+		t.Fatalf("expected no events outside geofence, got %+v", events)
+	}
+
+	expectEvent(t, test.track(t, courierID, 50.4501, 30.52), domain.GeofenceEventCourierEntered, "restaurant")
+
+	if events := test.track(t, courierID, 50.4502, 30.52); len(events) != 0 {
+		t.Fatalf("expected no events inside geofence, got %+v", events)
+	}
+
+	// 110 m from center is outside radius, but inside exit margin.
+	if events := test.track(t, courierID, 50.451, 30.52); len(events) != 0 {
+		t.Fatalf("expected no exit inside exit margin, got %+v", events)
+	}
+
+	expectEvent(t, test.track(t, courierID, 50.453, 30.52), domain.GeofenceEventCourierExited, "restaurant")
+
+	if ids := test.courierGeofenceRepository.geofenceIDs[courierID]; len(ids) != 0 {
+		t.Fatalf("expected courier outside of geofences in repository, got %v", ids)
+	}
+}
+
+func TestTrackCourierLocationKeepsCourierStateInMemory(t *testing.T) {
+	test := newGeofenceTrackerTest(restaurantGeofence())
+
+	test.track(t, courierID, 50.44, 30.52)
+	test.track(t, courierID, 50.4501, 30.52)
+	test.track(t, courierID, 50.453, 30.52)
+
+	if test.courierGeofenceRepository.gets != 1 || test.geofenceRepository.gets != 1 {
+		t.Fatalf(
+			"expected one load of courier state and geofences, got %d and %d",
+			test.courierGeofenceRepository.gets,
+			test.geofenceRepository.gets,
+		)
+	}
+}
+
+func TestTrackCourierLocationDoesNotEnterAgainAfterRestart(t *testing.T) {
+	test := newGeofenceTrackerTest(restaurantGeofence())
+	test.track(t, courierID, 50.4501, 30.52)
+
+	restarted := newGeofenceTrackerTest(restaurantGeofence())
+	restarted.courierGeofenceRepository = test.courierGeofenceRepository
+	restarted.tracker = domain.NewGeofenceTracker(restarted.geofenceRepository, restarted.courierGeofenceRepository, restarted.publisher, time.Hour, 20, 0)
+
+	if events := restarted.track(t, courierID, 50.4502, 30.52); len(events) != 0 {
+		t.Fatalf("expected no events for courier already inside geofence, got %+v", events)
+	}
+}
+
+func TestTrackCourierLocationDeletesDropOffGeofenceWhenCourierOfOrderExits(t *testing.T) {
+	test := newGeofenceTrackerTest(dropOffGeofence())
+
+	expectEvent(t, test.track(t, courierID, 50.46, 30.53), domain.GeofenceEventCourierEntered, "drop-off")
+	expectEvent(t, test.track(t, courierID, 50.47, 30.53), domain.GeofenceEventCourierExited, "drop-off")
+
+	if _, ok := test.geofenceRepository.geofences["drop-off"]; ok {
+		t.Fatal("expected drop-off geofence deleted after courier of order exited it")
+	}
+
+	if events := test.track(t, courierID, 50.46, 30.53); len(events) != 0 {
+		t.Fatalf("expected no events of deleted geofence, got %+v", events)
+	}
+}
+
+func TestTrackCourierLocationKeepsDropOffGeofenceWhenOtherCourierExits(t *testing.T) {
+	test := newGeofenceTrackerTest(dropOffGeofence())
+
+	expectEvent(t, test.track(t, otherCourierID, 50.46, 30.53), domain.GeofenceEventCourierEntered, "drop-off")
+	expectEvent(t, test.track(t, otherCourierID, 50.47, 30.53), domain.GeofenceEventCourierExited, "drop-off")
+
+	if _, ok := test.geofenceRepository.geofences["drop-off"]; !ok {
+		t.Fatal("expected drop-off geofence kept after other courier exited it")
+	}
+
+	expectEvent(t, test.track(t, courierID, 50.46, 30.53), domain.GeofenceEventCourierEntered, "drop-off")
+}
+
+func TestTrackCourierLocationFindsGeofenceOnBorderOfIndexCell(t *testing.T) {
+	// center is on border of cells, position is in neighbour cell, but inside radius.
+	test := newGeofenceTrackerTest(&domain.Geofence{ID: "warehouse", Type: domain.GeofenceTypeWarehouse, Latitude: 50.45, Longitude: 30.5, RadiusMeters: 300})
+
+	expectEvent(t, test.track(t, courierID, 50.4480, 30.4990), domain.GeofenceEventCourierEntered, "warehouse")
+}
@@ -1,29 +1,75 @@
 package env
 
 import (
+	"time"
+
 	coreEnv "github.com/caarlos0/env/v9"
+	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/tracing"
 )
 
 type Config struct {
-	KafkaAddress                                 string `env:"KAFKA_BROKERS" envDefault:"localhost:9092"`
-	KafkaSchemaRegistryAddress                   string `env:"KAFKA_SCHEMA_REGISTRY_ADDRESS" envDefault:"http://localhost:8085"`
-	AddrRedis                                    string `env:"REDIS_ADDRESS" envDefault:"localhost:6379"`
-	PortServer                                   string `env:"PORT_SERVER" envDefault:":8889"`
-	NumberDbRedis                                int    `env:"NUMBER_DB_REDIS" envDefault:"0"`
-	Assignor                                     string `env:"KAFKA_CONSUMER_ASSIGNOR" envDefault:"range"`
-	Oldest                                       bool   `env:"KAFKA_CONSUMER_OLDEST" envDefault:"true"`
-	Verbose                                      bool   `env:"KAFKA_CONSUMER_VERBOSE" envDefault:"false"`
-	DbName                                       string `env:"POSTGRES_DB" envDefault:"courier_location"`
-	DbPassword                                   string `env:"POSTGRES_PASSWORD" envDefault:"S3cret"`
-	DbUser                                       string `env:"POSTGRES_USER" envDefault:"citizix_user"`
-	CourierLatestPositionGrpcPort                string `env:"COURIER_GRPC_PORT" envDefault:":9667"`
-	CourierLocationQueueSizeTasks                int    `env:"COURIER_LOCATION_QUEUE_SIZE_TASKS" envDefault:"10000"`
-	CourierLocationWorkerPoolCount               int    `env:"COURIER_LOCATION_WORKER_POOL_COUNT" envDefault:"10"`
-	CourierLocationWorkerTimeoutGracefulShutdown int    `env:"COURIER_LOCATION_WORKER_TIMEOUT_GRACEFUL_SHUTDOWN" envDefault:"30"`
+	KafkaAddress                                 string                   `env:"KAFKA_BROKERS" envDefault:"localhost:9092"`
+	KafkaSchemaRegistryAddress                   string                   `env:"KAFKA_SCHEMA_REGISTRY_ADDRESS" envDefault:"http://localhost:8085"`
+	AddrRedis                                    string                   `env:"REDIS_ADDRESS" envDefault:"localhost:6379"`
+	PortServer                                   string                   `env:"PORT_SERVER" envDefault:":8889"`
+	TrackPortServer                              string                   `env:"TRACK_PORT_SERVER" envDefault:":8890"`
+	TrackAdminPortServer                         string                   `env:"TRACK_ADMIN_PORT_SERVER" envDefault:"127.0.0.1:8891"`
+	NumberDbRedis                                int                      `env:"NUMBER_DB_REDIS" envDefault:"0"`
+	Assignor                                     string                   `env:"KAFKA_CONSUMER_ASSIGNOR" envDefault:"range"`
+	Oldest                                       bool                     `env:"KAFKA_CONSUMER_OLDEST" envDefault:"true"`
+	Verbose                                      bool                     `env:"KAFKA_CONSUMER_VERBOSE" envDefault:"false"`
+	PublisherSync                                bool                     `env:"KAFKA_PUBLISHER_SYNC" envDefault:"false"`
+	PublisherPartitioner                         string                   `env:"KAFKA_PUBLISHER_PARTITIONER" envDefault:"murmur2"`
+	SchemaCompatibilityCheck                     bool                     `env:"KAFKA_SCHEMA_COMPATIBILITY_CHECK" envDefault:"true"`
+	SchemaCompatibilityLevel                     string                   `env:"KAFKA_SCHEMA_COMPATIBILITY_LEVEL"`
+	ConsumerDeadLetterEnabled                    bool                     `env:"KAFKA_CONSUMER_DEAD_LETTER_ENABLED" envDefault:"true"`
+	ConsumerMaxAttempts                          int                      `env:"KAFKA_CONSUMER_MAX_ATTEMPTS" envDefault:"3"`
+	ConsumerKeyWorkers                           int                      `env:"KAFKA_CONSUMER_KEY_WORKERS" envDefault:"0"`
+	ConsumerRetryInitialBackoff                  time.Duration            `env:"KAFKA_CONSUMER_RETRY_INITIAL_BACKOFF" envDefault:"100ms"`
+	ConsumerRetryMaxBackoff                      time.Duration            `env:"KAFKA_CONSUMER_RETRY_MAX_BACKOFF" envDefault:"5s"`
+	ConsumerReconnectMaxAttempts                 int                      `env:"KAFKA_CONSUMER_RECONNECT_MAX_ATTEMPTS" envDefault:"0"`
+	ConsumerReconnectInitialBackoff              time.Duration            `env:"KAFKA_CONSUMER_RECONNECT_INITIAL_BACKOFF" envDefault:"1s"`
+	ConsumerReconnectMaxBackoff                  time.Duration            `env:"KAFKA_CONSUMER_RECONNECT_MAX_BACKOFF" envDefault:"30s"`
+	ConsumerRetryTopicDelays                     []time.Duration          `env:"KAFKA_CONSUMER_RETRY_TOPIC_DELAYS" envSeparator:","`
+	DbName                                       string                   `env:"POSTGRES_DB" envDefault:"courier_location"`
+	DbPassword                                   string                   `env:"POSTGRES_PASSWORD" envDefault:"S3cret"`
+	DbUser                                       string                   `env:"POSTGRES_USER" envDefault:"citizix_user"`
+	CourierLatestPositionGrpcPort                string                   `env:"COURIER_GRPC_PORT" envDefault:":9667"`
+	CourierLatestPositionStorage                 string                   `env:"COURIER_LATEST_POSITION_STORAGE" envDefault:"redis"`
+	CourierLocationQueueSizeTasks                int                      `env:"COURIER_LOCATION_QUEUE_SIZE_TASKS" envDefault:"10000"`
+	CourierLocationWorkerPoolCount               int                      `env:"COURIER_LOCATION_WORKER_POOL_COUNT" envDefault:"10"`
+	CourierLocationWorkerTimeoutGracefulShutdown int                      `env:"COURIER_LOCATION_WORKER_TIMEOUT_GRACEFUL_SHUTDOWN" envDefault:"30"`
+	GpsFilterEnabled                             bool                     `env:"GPS_FILTER_ENABLED" envDefault:"false"`
+	GpsFilterRejectImplausible                   bool                     `env:"GPS_FILTER_REJECT_IMPLAUSIBLE" envDefault:"false"`
+	GpsFilterDefaultVehicleType                  string                   `env:"GPS_FILTER_DEFAULT_VEHICLE_TYPE" envDefault:"car"`
+	GpsFilterMaxSpeedFoot                        float64                  `env:"GPS_FILTER_MAX_SPEED_FOOT" envDefault:"15"`
+	GpsFilterMaxSpeedBicycle                     float64                  `env:"GPS_FILTER_MAX_SPEED_BICYCLE" envDefault:"45"`
+	GpsFilterMaxSpeedScooter                     float64                  `env:"GPS_FILTER_MAX_SPEED_SCOOTER" envDefault:"80"`
+	GpsFilterMaxSpeedCar                         float64                  `env:"GPS_FILTER_MAX_SPEED_CAR" envDefault:"200"`
+	GpsFilterSmoothing                           bool                     `env:"GPS_FILTER_SMOOTHING" envDefault:"false"`
+	GpsFilterProcessNoise                        float64                  `env:"GPS_FILTER_PROCESS_NOISE" envDefault:"3"`
+	GpsFilterAccuracy                            float64                  `env:"GPS_FILTER_ACCURACY" envDefault:"10"`
+	GpsFilterMaxRejections                       int                      `env:"GPS_FILTER_MAX_REJECTIONS" envDefault:"3"`
+	GpsFilterMaxGap                              int                      `env:"GPS_FILTER_MAX_GAP" envDefault:"300"`
+	CourierActivityMaxIdle                       int                      `env:"COURIER_ACTIVITY_MAX_IDLE" envDefault:"300"`
+	GeofenceRefreshInterval                      int                      `env:"GEOFENCE_REFRESH_INTERVAL" envDefault:"30"`
+	GeofenceExitMargin                           float64                  `env:"GEOFENCE_EXIT_MARGIN" envDefault:"20"`
+	GeofenceDropOffTTL                           int                      `env:"GEOFENCE_DROP_OFF_TTL" envDefault:"86400"`
+	KafkaSecurity                                pkgkafka.SecurityOptions `envPrefix:"KAFKA_"`
+	KafkaPublisher                               pkgkafka.ProducerOptions `envPrefix:"KAFKA_PUBLISHER_"`
+	Tracing                                      tracing.Options          `envPrefix:"TRACING_"`
 }
 
 func GetConfig() (config Config, err error) {
-	cfg := Config{}
+	cfg := Config{
+		// locations are sent often, so publisher batches and compresses them by default.
+		KafkaPublisher: pkgkafka.ProducerOptions{
+			Compression: pkgkafka.CompressionSnappy,
+			Linger:      10 * time.Millisecond,
+			BatchSize:   64 * 1024,
+		},
+	}
 	err = coreEnv.Parse(&cfg)
 
 	return cfg, err
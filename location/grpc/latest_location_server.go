@@ -17,9 +17,6 @@ type LatestLocationServer struct {
 
 func (ll *LatestLocationServer) GetCourierLatestPosition(ctx context.Context, req *pb.GetCourierLatestPositionRequest) (*pb.GetCourierLatestPositionResponse, error) {
 	latestPosition, err := ll.CourierRepository.GetLatestPositionCourierById(ctx, req.CourierId)
-	if err != nil {
-		return nil, fmt.Errorf("impossible to get courier geo position: %w", err)
-	}
 
 	isErrCourierNotFound := err != nil && errors.Is(err, domain.ErrCourierLocationNotFound)
 	if isErrCourierNotFound {
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/steteruk/go-delivery-service/location/domain"
+	pkghttp "github.com/steteruk/go-delivery-service/pkg/http"
+)
+
+const activityDateLayout = "2006-01-02"
+
+// GetActivityPayload is query params of activity request, by default it returns daily totals of the last week.
+type GetActivityPayload struct {
+	Period string `validate:"omitempty,oneof=daily weekly"`
+	From   string `validate:"omitempty,datetime=2006-01-02"`
+	To     string `validate:"omitempty,datetime=2006-01-02"`
+}
+
+// GetActivityResponse returns totals of courier activity.
+type GetActivityResponse struct {
+	CourierID string                         `json:"courier_id"`
+	Period    string                         `json:"period"`
+	Totals    []*domain.CourierActivityTotal `json:"totals"`
+}
+
+type ActivityHandler struct {
+	activityService *domain.CourierActivityService
+	httpHandler     pkghttp.HandlerInterface
+}
+
+func NewActivityHandler(activityService *domain.CourierActivityService, handler pkghttp.HandlerInterface) *ActivityHandler {
+	return &ActivityHandler{
+		activityService: activityService,
+		httpHandler:     handler,
+	}
+}
+
+// GetActivityHandler returns kilometres driven and active minutes of courier per day or per week.
+func (h *ActivityHandler) GetActivityHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	activityPayload := GetActivityPayload{
+		Period: query.Get("period"),
+		From:   query.Get("from"),
+		To:     query.Get("to"),
+	}
+
+	if err := h.httpHandler.ValidatePayload(&activityPayload); err != nil {
+		h.httpHandler.FailResponse(w, err)
+
+		return
+	}
+
+	if activityPayload.Period == "" {
+		activityPayload.Period = domain.ActivityPeriodDaily
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if activityPayload.To != "" {
+		to, _ = time.Parse(activityDateLayout, activityPayload.To)
+	}
+
+	from := to.AddDate(0, 0, -6)
+	if activityPayload.From != "" {
+		from, _ = time.Parse(activityDateLayout, activityPayload.From)
+	}
+
+	if from.After(to) {
+		h.httpHandler.FailResponse(w, fmt.Errorf("from %s is after to %s:%w", from.Format(activityDateLayout), to.Format(activityDateLayout), pkghttp.ErrValidatePayloadFailed))
+
+		return
+	}
+
+	courierID := mux.Vars(r)["courier_id"]
+	totals, err := h.activityService.GetCourierActivityTotals(r.Context(), courierID, activityPayload.Period, from, to)
+	if err != nil {
+		log.Printf("failed to get courier activity: %v", err)
+		h.httpHandler.FailResponse(w, err)
+
+		return
+	}
+
+	h.httpHandler.SuccessResponse(w, &GetActivityResponse{
+		CourierID: courierID,
+		Period:    activityPayload.Period,
+		Totals:    totals,
+	}, http.StatusOK)
+}
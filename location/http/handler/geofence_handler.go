@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/steteruk/go-delivery-service/location/domain"
+	pkghttp "github.com/steteruk/go-delivery-service/pkg/http"
+)
+
+// CreateGeofencePayload imagine payload for creating geofence, drop-off geofence should have order id and courier of order.
+type CreateGeofencePayload struct {
+	Name         string  `json:"name" validate:"required,lte=100"`
+	Type         string  `json:"type" validate:"required,oneof=restaurant warehouse drop_off"`
+	OrderID      string  `json:"order_id" validate:"required_if=Type drop_off,omitempty,uuid"`
+	CourierID    string  `json:"courier_id" validate:"required_if=Type drop_off,omitempty,uuid"`
+	Latitude     float64 `json:"latitude" validate:"required,latitude"`
+	Longitude    float64 `json:"longitude" validate:"required,longitude"`
+	RadiusMeters float64 `json:"radius_meters" validate:"required,gt=0,lte=10000"`
+}
+
+type GeofenceHandler struct {
+	geofenceService *domain.GeofenceService
+	httpHandler     pkghttp.HandlerInterface
+}
+
+func NewGeofenceHandler(geofenceService *domain.GeofenceService, handler pkghttp.HandlerInterface) *GeofenceHandler {
+	return &GeofenceHandler{
+		geofenceService: geofenceService,
+		httpHandler:     handler,
+	}
+}
+
+func (h *GeofenceHandler) CreateGeofenceHandler(w http.ResponseWriter, r *http.Request) {
+	var geofencePayload CreateGeofencePayload
+
+	if err := h.httpHandler.DecodePayloadFromJson(r, &geofencePayload); err != nil {
+		h.httpHandler.FailResponse(w, err)
+
+		return
+	}
+
+	if err := h.httpHandler.ValidatePayload(&geofencePayload); err != nil {
+		h.httpHandler.FailResponse(w, err)
+
+		return
+	}
+
+	geofence, err := h.geofenceService.CreateGeofence(r.Context(), &domain.Geofence{
+		Name:         geofencePayload.Name,
+		Type:         geofencePayload.Type,
+		OrderID:      geofencePayload.OrderID,
+		CourierID:    geofencePayload.CourierID,
+		Latitude:     geofencePayload.Latitude,
+		Longitude:    geofencePayload.Longitude,
+		RadiusMeters: geofencePayload.RadiusMeters,
+	})
+
+	if err != nil {
+		log.Printf("failed to save geofence: %v", err)
+		h.httpHandler.FailResponse(w, err)
+
+		return
+	}
+
+	h.httpHandler.SuccessResponse(w, geofence, http.StatusCreated)
+}
+
+func (h *GeofenceHandler) GetGeofencesHandler(w http.ResponseWriter, r *http.Request) {
+	geofences, err := h.geofenceService.GetGeofences(r.Context())
+	if err != nil {
+		log.Printf("failed to get geofences: %v", err)
+		h.httpHandler.FailResponse(w, err)
+
+		return
+	}
+
+	if geofences == nil {
+		geofences = []*domain.Geofence{}
+	}
+
+	h.httpHandler.SuccessResponse(w, geofences, http.StatusOK)
+}
+
+func (h *GeofenceHandler) DeleteGeofenceHandler(w http.ResponseWriter, r *http.Request) {
+	geofenceID := mux.Vars(r)["geofence_id"]
+	err := h.geofenceService.DeleteGeofence(r.Context(), geofenceID)
+
+	if errors.Is(err, domain.ErrGeofenceNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("failed to delete geofence: %v", err)
+		h.httpHandler.FailResponse(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
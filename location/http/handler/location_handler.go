@@ -17,6 +17,8 @@ type ResponseMessage struct {
 type LocationPayload struct {
 	Latitude  float64 `json:"latitude" validate:"required,latitude"`
 	Longitude float64 `json:"longitude" validate:"required,longitude"`
+	// VehicleType helps to filter gps noise, because every vehicle has own max speed.
+	VehicleType string `json:"vehicle_type" validate:"omitempty,oneof=foot bicycle scooter car"`
 }
 
 type LocationHandler struct {
@@ -50,10 +52,11 @@ func (h *LocationHandler) LatestLocationHandler(w http.ResponseWriter, r *http.R
 	vars := mux.Vars(r)
 	courierId := vars["courier_id"]
 	courierLocation := &domain.CourierLocation{
-		courierId,
-		locationPayload.Latitude,
-		locationPayload.Longitude,
-		time.Now(),
+		CourierID:   courierId,
+		Latitude:    locationPayload.Latitude,
+		Longitude:   locationPayload.Longitude,
+		CreatedAt:   time.Now(),
+		VehicleType: locationPayload.VehicleType,
 	}
 
 	h.courierLocationWorkerPool.AddTask(courierLocation)
@@ -5,44 +5,62 @@ import (
 	"fmt"
 	"github.com/steteruk/go-delivery-service/avro/v1"
 	"github.com/steteruk/go-delivery-service/location/domain"
-	"log"
 	"time"
 )
 
 type CourierLocationConsumer struct {
 	courierLocationRepository domain.CourierLocationRepositoryInterface
+	courierActivityRepository domain.CourierActivityRepositoryInterface
+	activityMaxIdle           time.Duration
+	geofenceTracker           domain.CourierGeofenceTrackerInterface
 }
 
 func NewCourierLocationConsumer(
 	courierLocationRepository domain.CourierLocationRepositoryInterface,
+	courierActivityRepository domain.CourierActivityRepositoryInterface,
+	activityMaxIdle time.Duration,
+	geofenceTracker domain.CourierGeofenceTrackerInterface,
 ) *CourierLocationConsumer {
 	return &CourierLocationConsumer{
 		courierLocationRepository: courierLocationRepository,
+		courierActivityRepository: courierActivityRepository,
+		activityMaxIdle:           activityMaxIdle,
+		geofenceTracker:           geofenceTracker,
 	}
 }
 
-// HandleJSONMessage Handle kafka message in json format
-func (courierLocationConsumer *CourierLocationConsumer) HandleJSONMessage(ctx context.Context, message []byte) error {
-	latestCourierLocationMessage := avro.NewLatestCourierLocationMessage()
-	var courierLocation domain.CourierLocation
-	if err := latestCourierLocationMessage.UnmarshalJSON(message); err != nil {
-		log.Printf("failed to unmarshal Kafka message into courier location struct: %v\n", err)
+// HandleMessage Handle kafka message with latest position of courier
+func (courierLocationConsumer *CourierLocationConsumer) HandleMessage(ctx context.Context, latestCourierLocationMessage avro.LatestCourierLocationMessage) error {
+	courierLocation := domain.CourierLocation{
+		CourierID:     latestCourierLocationMessage.Courier_id,
+		Latitude:      latestCourierLocationMessage.Latitude,
+		Longitude:     latestCourierLocationMessage.Longitude,
+		CreatedAt:     time.Unix(latestCourierLocationMessage.Created_at, 0),
+		VehicleType:   latestCourierLocationMessage.Vehicle_type,
+		IsImplausible: latestCourierLocationMessage.Is_implausible,
+	}
+
+	err := courierLocationConsumer.courierLocationRepository.SaveLatestCourierGeoPosition(ctx, &courierLocation)
 
+	if err != nil {
+		return fmt.Errorf("failed to save a courier location in the repository: %w", err)
+	}
+
+	// flagged position would add wrong distance and geofence events, so it is only saved as latest position.
+	if courierLocation.IsImplausible {
 		return nil
 	}
 
-	time := time.Unix(latestCourierLocationMessage.Created_at, 0)
-	courierLocation = domain.CourierLocation{
-		CourierID: latestCourierLocationMessage.Courier_id,
-		Latitude:  latestCourierLocationMessage.Latitude,
-		Longitude: latestCourierLocationMessage.Longitude,
-		CreatedAt: time,
+	err = courierLocationConsumer.courierActivityRepository.SaveCourierActivity(ctx, &courierLocation, courierLocationConsumer.activityMaxIdle)
+
+	if err != nil {
+		return fmt.Errorf("failed to save a courier activity in the repository: %w", err)
 	}
 
-	err := courierLocationConsumer.courierLocationRepository.SaveLatestCourierGeoPosition(ctx, &courierLocation)
+	err = courierLocationConsumer.geofenceTracker.TrackCourierLocation(ctx, &courierLocation)
 
 	if err != nil {
-		return fmt.Errorf("failed to save a courier location in the repository: %w", err)
+		return fmt.Errorf("failed to track courier geofences: %w", err)
 	}
 
 	return nil
@@ -13,28 +13,29 @@ const LatestPositionCourierTopic = "latest_position_courier.v1"
 
 // CourierLocationLatestPublisher Publisher for kafka
 type CourierLocationLatestPublisher struct {
-	publisher *pkgkafka.Publisher
+	publisher *pkgkafka.AvroPublisher[avro.LatestCourierLocationMessage]
 }
 
-func NewCourierLocationPublisher(publisher *pkgkafka.Publisher) *CourierLocationLatestPublisher {
-	return &CourierLocationLatestPublisher{publisher: publisher}
+// NewCourierLocationPublisher creates publisher, position is sent in binary avro without json, because topic has a lot of messages.
+func NewCourierLocationPublisher(publisher *pkgkafka.Publisher) (*CourierLocationLatestPublisher, error) {
+	avroPublisher, err := pkgkafka.NewAvroPublisher[avro.LatestCourierLocationMessage](publisher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create courier location publisher: %w", err)
+	}
+
+	return &CourierLocationLatestPublisher{publisher: avroPublisher}, nil
 }
 
-// PublishLatestCourierLocation sends latest courier position message in json format in Kafka.
+// PublishLatestCourierLocation sends latest courier position message in Kafka.
 func (courierPublisher *CourierLocationLatestPublisher) PublishLatestCourierLocation(ctx context.Context, courierLocation *domain.CourierLocation) error {
 	latestCourierLocation := avro.NewLatestCourierLocationMessage()
 	latestCourierLocation.Courier_id = courierLocation.CourierID
 	latestCourierLocation.Longitude = courierLocation.Longitude
 	latestCourierLocation.Latitude = courierLocation.Latitude
 	latestCourierLocation.Created_at = courierLocation.CreatedAt.Unix()
-	message, err := latestCourierLocation.MarshalJSON()
-
-	if err != nil {
-		return fmt.Errorf("failed to marshal courier location before sending Kafka event: %w", err)
-	}
-
-	schema := latestCourierLocation.Schema()
-	err = courierPublisher.publisher.PublishMessage(ctx, message, []byte(courierLocation.CourierID), schema)
+	latestCourierLocation.Vehicle_type = courierLocation.VehicleType
+	latestCourierLocation.Is_implausible = courierLocation.IsImplausible
+	err := courierPublisher.publisher.PublishMessage(ctx, latestCourierLocation, []byte(courierLocation.CourierID))
 
 	if err != nil {
 		return fmt.Errorf("failed to publish courier location: %w", err)
@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/steteruk/go-delivery-service/avro/v1"
+	"github.com/steteruk/go-delivery-service/location/domain"
+	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+)
+
+const CourierGeofenceEventsTopic = "courier_geofence_events.v1"
+
+// GeofenceEventPublisher Publisher for kafka
+type GeofenceEventPublisher struct {
+	publisher *pkgkafka.AvroPublisher[avro.CourierGeofenceEventMessage]
+}
+
+// NewGeofenceEventPublisher creates publisher, event is sent in binary avro without json.
+func NewGeofenceEventPublisher(publisher *pkgkafka.Publisher) (*GeofenceEventPublisher, error) {
+	avroPublisher, err := pkgkafka.NewAvroPublisher[avro.CourierGeofenceEventMessage](publisher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create geofence event publisher: %w", err)
+	}
+
+	return &GeofenceEventPublisher{publisher: avroPublisher}, nil
+}
+
+// PublishGeofenceEvent sends courier entered or exited geofence message in Kafka.
+func (geofencePublisher *GeofenceEventPublisher) PublishGeofenceEvent(ctx context.Context, geofenceEvent *domain.GeofenceEvent) error {
+	geofenceEventMessage := avro.NewCourierGeofenceEventMessage()
+	geofenceEventMessage.Event = geofenceEvent.Event
+	geofenceEventMessage.Courier_id = geofenceEvent.CourierLocation.CourierID
+	geofenceEventMessage.Geofence_id = geofenceEvent.Geofence.ID
+	geofenceEventMessage.Geofence_type = geofenceEvent.Geofence.Type
+	geofenceEventMessage.Latitude = geofenceEvent.CourierLocation.Latitude
+	geofenceEventMessage.Longitude = geofenceEvent.CourierLocation.Longitude
+	geofenceEventMessage.Created_at = geofenceEvent.CourierLocation.CreatedAt.UnixMilli()
+	geofenceEventMessage.Order_id = nil
+	if geofenceEvent.Geofence.OrderID != "" {
+		geofenceEventMessage.Order_id = avro.NewUnionStringNull()
+		geofenceEventMessage.Order_id.String = geofenceEvent.Geofence.OrderID
+	}
+
+	err := geofencePublisher.publisher.PublishMessage(ctx, geofenceEventMessage, []byte(geofenceEvent.CourierLocation.CourierID))
+
+	if err != nil {
+		return fmt.Errorf("failed to publish geofence event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS courier_daily_activity (
+                                                   courier_id UUID NOT NULL,
+                                                   day DATE NOT NULL,
+                                                   distance_meters double precision NOT NULL DEFAULT 0,
+                                                   active_seconds BIGINT NOT NULL DEFAULT 0,
+                                                   PRIMARY KEY (courier_id, day)
+    );
+CREATE TABLE IF NOT EXISTS courier_activity_position (
+                                                   courier_id UUID NOT NULL,
+                                                   latitude double precision NOT NULL,
+                                                   longitude double precision NOT NULL,
+                                                   created_at TIMESTAMPTZ NOT NULL,
+                                                   PRIMARY KEY (courier_id)
+    );
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS courier_geofences (
+                                                 courier_id UUID NOT NULL,
+                                                 geofence_id UUID NOT NULL REFERENCES geofences(id) ON DELETE CASCADE,
+                                                 entered_at TIMESTAMPTZ NOT NULL,
+                                                 PRIMARY KEY (courier_id, geofence_id)
+    );
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS geofences (
+                                         id UUID NOT NULL DEFAULT gen_random_uuid(),
+                                         name varchar(100) NOT NULL,
+                                         type varchar(20) NOT NULL,
+                                         order_id UUID NULL,
+                                         courier_id UUID NULL,
+                                         latitude double precision NOT NULL,
+                                         longitude double precision NOT NULL,
+                                         radius_meters double precision NOT NULL,
+                                         created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                         PRIMARY KEY (id)
+    );
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/steteruk/go-delivery-service/location/domain"
+	"log"
+	"time"
 )
 
 type CourierRepository struct {
@@ -38,15 +40,15 @@ func (r *CourierRepository) SaveLatestCourierGeoPosition(ctx context.Context, co
 }
 
 func (r *CourierRepository) GetLatestPositionCourierById(ctx context.Context, courierId string) (*domain.CourierLocation, error) {
-	sqlStatement := "SELECT latitude, longitude FROM courier_latest_cord WHERE courier_id = $1 ORDER BY created_at DESC LIMIT 1"
+	sqlStatement := "SELECT latitude, longitude, created_at FROM courier_latest_cord WHERE courier_id = $1 ORDER BY created_at DESC LIMIT 1"
 	row := r.client.QueryRowContext(
 		ctx,
 		sqlStatement,
 		courierId,
 	)
 
-	courierLocation := domain.CourierLocation{}
-	err := row.Scan(&courierLocation.Latitude, &courierLocation.Longitude)
+	courierLocation := domain.CourierLocation{CourierID: courierId}
+	err := row.Scan(&courierLocation.Latitude, &courierLocation.Longitude, &courierLocation.CreatedAt)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, domain.ErrCourierLocationNotFound
@@ -57,3 +59,92 @@ func (r *CourierRepository) GetLatestPositionCourierById(ctx context.Context, co
 
 	return &courierLocation, nil
 }
+
+// SaveCourierActivity adds way from latest position of courier into daily activity. It locks latest position of courier,
+// so positions of one courier are counted one by one, totals of days are increased by upsert.
+func (r *CourierRepository) SaveCourierActivity(ctx context.Context, courierLocation *domain.CourierLocation, maxIdle time.Duration) (err error) {
+	tx, err := r.client.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if errRollBack := tx.Rollback(); errRollBack != nil {
+			log.Printf("failed to rolback transaction: %v\n", errRollBack)
+		}
+	}()
+
+	query := "SELECT latitude, longitude, created_at FROM courier_activity_position WHERE courier_id = $1 FOR UPDATE"
+	row := tx.QueryRowContext(ctx, query, courierLocation.CourierID)
+
+	var latestLocation *domain.CourierLocation
+	location := domain.CourierLocation{CourierID: courierLocation.CourierID}
+	err = row.Scan(&location.Latitude, &location.Longitude, &location.CreatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get latest position of courier activity: %w", err)
+	}
+
+	if err == nil {
+		latestLocation = &location
+	}
+
+	// position which came later than newer one is skipped, latest position stays the same.
+	if latestLocation == nil || courierLocation.CreatedAt.After(latestLocation.CreatedAt) {
+		query = "INSERT INTO courier_activity_position (courier_id, latitude, longitude, created_at) VALUES ($1, $2, $3, $4) " +
+			"ON CONFLICT (courier_id) DO UPDATE SET latitude = EXCLUDED.latitude, longitude = EXCLUDED.longitude, created_at = EXCLUDED.created_at"
+		_, err = tx.ExecContext(
+			ctx,
+			query,
+			courierLocation.CourierID,
+			courierLocation.Latitude,
+			courierLocation.Longitude,
+			courierLocation.CreatedAt,
+		)
+
+		if err != nil {
+			return fmt.Errorf("latest position of courier activity was not saved: %w", err)
+		}
+	}
+
+	query = "INSERT INTO courier_daily_activity (courier_id, day, distance_meters, active_seconds) VALUES ($1, $2, $3, $4) " +
+		"ON CONFLICT (courier_id, day) DO UPDATE SET distance_meters = courier_daily_activity.distance_meters + EXCLUDED.distance_meters, " +
+		"active_seconds = courier_daily_activity.active_seconds + EXCLUDED.active_seconds"
+	for _, activity := range domain.CourierActivityDeltas(latestLocation, courierLocation, maxIdle) {
+		_, err = tx.ExecContext(ctx, query, activity.CourierID, activity.Day, activity.DistanceMeters, activity.ActiveSeconds)
+		if err != nil {
+			return fmt.Errorf("row courier activity was not saved: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit courier activity: %w", err)
+	}
+
+	return nil
+}
+
+// GetCourierDailyActivities returns daily activities of courier between from and to days ordered by day.
+func (r *CourierRepository) GetCourierDailyActivities(ctx context.Context, courierID string, from time.Time, to time.Time) ([]*domain.CourierDailyActivity, error) {
+	query := "SELECT day, distance_meters, active_seconds FROM courier_daily_activity WHERE courier_id = $1 AND day BETWEEN $2 AND $3 ORDER BY day"
+	rows, err := r.client.QueryContext(ctx, query, courierID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier activities: %w", err)
+	}
+	defer rows.Close()
+
+	var activities []*domain.CourierDailyActivity
+	for rows.Next() {
+		activity := domain.CourierDailyActivity{CourierID: courierID}
+		if err := rows.Scan(&activity.Day, &activity.DistanceMeters, &activity.ActiveSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan courier activity: %w", err)
+		}
+
+		activities = append(activities, &activity)
+	}
+
+	return activities, rows.Err()
+}
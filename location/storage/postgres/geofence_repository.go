@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/location/domain"
+)
+
+type GeofenceRepository struct {
+	client *sql.DB
+}
+
+func NewGeofenceRepository(client *sql.DB) *GeofenceRepository {
+	return &GeofenceRepository{
+		client: client,
+	}
+}
+
+func (r *GeofenceRepository) SaveGeofence(ctx context.Context, geofence *domain.Geofence) (*domain.Geofence, error) {
+	sqlStatement := "INSERT INTO geofences (name, type, order_id, courier_id, latitude, longitude, radius_meters) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id"
+	row := r.client.QueryRowContext(
+		ctx,
+		sqlStatement,
+		geofence.Name,
+		geofence.Type,
+		sql.NullString{String: geofence.OrderID, Valid: geofence.OrderID != ""},
+		sql.NullString{String: geofence.CourierID, Valid: geofence.CourierID != ""},
+		geofence.Latitude,
+		geofence.Longitude,
+		geofence.RadiusMeters,
+	)
+
+	newGeofence := *geofence
+	if err := row.Scan(&newGeofence.ID); err != nil {
+		return nil, fmt.Errorf("row geofence was not saved: %w", err)
+	}
+
+	return &newGeofence, nil
+}
+
+func (r *GeofenceRepository) GetGeofences(ctx context.Context) ([]*domain.Geofence, error) {
+	sqlStatement := "SELECT id, name, type, order_id, courier_id, latitude, longitude, radius_meters FROM geofences"
+	rows, err := r.client.QueryContext(ctx, sqlStatement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofences: %w", err)
+	}
+	defer rows.Close()
+
+	var geofences []*domain.Geofence
+	for rows.Next() {
+		geofence := domain.Geofence{}
+		var orderID, courierID sql.NullString
+		err := rows.Scan(
+			&geofence.ID,
+			&geofence.Name,
+			&geofence.Type,
+			&orderID,
+			&courierID,
+			&geofence.Latitude,
+			&geofence.Longitude,
+			&geofence.RadiusMeters,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan geofence: %w", err)
+		}
+		geofence.OrderID = orderID.String
+		geofence.CourierID = courierID.String
+
+		geofences = append(geofences, &geofence)
+	}
+
+	return geofences, rows.Err()
+}
+
+func (r *GeofenceRepository) DeleteGeofence(ctx context.Context, geofenceID string) error {
+	result, err := r.client.ExecContext(ctx, "DELETE FROM geofences WHERE id = $1", geofenceID)
+	if err != nil {
+		return fmt.Errorf("row geofence was not deleted: %w", err)
+	}
+
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowAffected == 0 {
+		return domain.ErrGeofenceNotFound
+	}
+
+	return nil
+}
+
+// DeleteGeofencesCreatedBefore deletes geofences of type, geofences of couriers are deleted by foreign key.
+func (r *GeofenceRepository) DeleteGeofencesCreatedBefore(ctx context.Context, geofenceType string, before time.Time) error {
+	_, err := r.client.ExecContext(ctx, "DELETE FROM geofences WHERE type = $1 AND created_at < $2", geofenceType, before)
+	if err != nil {
+		return fmt.Errorf("rows geofence were not deleted: %w", err)
+	}
+
+	return nil
+}
+
+// GetCourierGeofenceIDs returns ids of geofences where courier is now.
+func (r *GeofenceRepository) GetCourierGeofenceIDs(ctx context.Context, courierID string) ([]string, error) {
+	rows, err := r.client.QueryContext(ctx, "SELECT geofence_id FROM courier_geofences WHERE courier_id = $1", courierID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofences of courier: %w", err)
+	}
+	defer rows.Close()
+
+	var geofenceIDs []string
+	for rows.Next() {
+		var geofenceID string
+		if err := rows.Scan(&geofenceID); err != nil {
+			return nil, fmt.Errorf("failed to scan geofence of courier: %w", err)
+		}
+
+		geofenceIDs = append(geofenceIDs, geofenceID)
+	}
+
+	return geofenceIDs, rows.Err()
+}
+
+// SaveCourierGeofence saves that courier entered geofence, geofence which was deleted meanwhile is ignored.
+func (r *GeofenceRepository) SaveCourierGeofence(ctx context.Context, courierID string, geofenceID string, enteredAt time.Time) error {
+	sqlStatement := "INSERT INTO courier_geofences (courier_id, geofence_id, entered_at) " +
+		"SELECT $1, id, $3 FROM geofences WHERE id = $2 ON CONFLICT (courier_id, geofence_id) DO NOTHING"
+	if _, err := r.client.ExecContext(ctx, sqlStatement, courierID, geofenceID, enteredAt); err != nil {
+		return fmt.Errorf("row courier geofence was not saved: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCourierGeofence saves that courier exited geofence.
+func (r *GeofenceRepository) DeleteCourierGeofence(ctx context.Context, courierID string, geofenceID string) error {
+	sqlStatement := "DELETE FROM courier_geofences WHERE courier_id = $1 AND geofence_id = $2"
+	if _, err := r.client.ExecContext(ctx, sqlStatement, courierID, geofenceID); err != nil {
+		return fmt.Errorf("row courier geofence was not deleted: %w", err)
+	}
+
+	return nil
+}
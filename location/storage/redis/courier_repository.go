@@ -2,15 +2,22 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"time"
+
 	coreRedis "github.com/redis/go-redis/v9"
 	"github.com/steteruk/go-delivery-service/location/domain"
 )
 
 const courierLatestCordsKey = "courier_latest_cord"
+const courierLatestCordsCreatedAtKey = "courier_latest_cord_created_at"
 
 type CourierRepository struct {
-	client *coreRedis.Client
+	client   *coreRedis.Client
+	fallback domain.CourierRepositoryInterface
 }
 
 func NewCourierRepository(client *coreRedis.Client) *CourierRepository {
@@ -20,6 +27,14 @@ func NewCourierRepository(client *coreRedis.Client) *CourierRepository {
 	}
 }
 
+// NewCourierRepositoryWithFallback creates redis repository which reads latest position from fallback repository on cache miss.
+func NewCourierRepositoryWithFallback(client *coreRedis.Client, fallback domain.CourierRepositoryInterface) *CourierRepository {
+	return &CourierRepository{
+		client:   client,
+		fallback: fallback,
+	}
+}
+
 func (r *CourierRepository) SaveLatestCourierGeoPosition(ctx context.Context, courierLocation *domain.CourierLocation) error {
 	l := &coreRedis.GeoLocation{
 		Longitude: courierLocation.Longitude,
@@ -27,9 +42,67 @@ func (r *CourierRepository) SaveLatestCourierGeoPosition(ctx context.Context, co
 		Name:      courierLocation.CourierID,
 	}
 
-	if err := r.client.GeoAdd(ctx, courierLatestCordsKey, l).Err(); err != nil {
+	_, err := r.client.TxPipelined(ctx, func(pipe coreRedis.Pipeliner) error {
+		pipe.GeoAdd(ctx, courierLatestCordsKey, l)
+		pipe.HSet(ctx, courierLatestCordsCreatedAtKey, courierLocation.CourierID, courierLocation.CreatedAt.Unix())
+
+		return nil
+	})
+
+	if err != nil {
 		return fmt.Errorf("failed to add courier geo location into redis: %w", err)
 	}
 
 	return nil
 }
+
+// GetLatestPositionCourierById gets latest position from geo set and time of position from hash, on miss it asks fallback repository.
+func (r *CourierRepository) GetLatestPositionCourierById(ctx context.Context, courierID string) (*domain.CourierLocation, error) {
+	courierLocation, err := r.getLatestPositionCourierById(ctx, courierID)
+	if err == nil {
+		return courierLocation, nil
+	}
+
+	if r.fallback == nil {
+		return nil, err
+	}
+
+	if !errors.Is(err, domain.ErrCourierLocationNotFound) {
+		log.Printf("failed to get latest position from redis, use fallback: %v\n", err)
+	}
+
+	return r.fallback.GetLatestPositionCourierById(ctx, courierID)
+}
+
+func (r *CourierRepository) getLatestPositionCourierById(ctx context.Context, courierID string) (*domain.CourierLocation, error) {
+	positions, err := r.client.GeoPos(ctx, courierLatestCordsKey, courierID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier geo position from redis: %w", err)
+	}
+
+	if len(positions) == 0 || positions[0] == nil {
+		return nil, domain.ErrCourierLocationNotFound
+	}
+
+	courierLocation := domain.CourierLocation{
+		CourierID: courierID,
+		Latitude:  positions[0].Latitude,
+		Longitude: positions[0].Longitude,
+	}
+
+	createdAt, err := r.client.HGet(ctx, courierLatestCordsCreatedAtKey, courierID).Result()
+	if errors.Is(err, coreRedis.Nil) {
+		return &courierLocation, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier geo position time from redis: %w", err)
+	}
+
+	timestamp, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid courier geo position time in redis: %w", err)
+	}
+	courierLocation.CreatedAt = time.Unix(timestamp, 0)
+
+	return &courierLocation, nil
+}
@@ -6,18 +6,22 @@ import (
 	"fmt"
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
+	"github.com/steteruk/go-delivery-service/avro/v1"
 	"github.com/steteruk/go-delivery-service/order/domain"
 	"github.com/steteruk/go-delivery-service/order/env"
+	orderGrpc "github.com/steteruk/go-delivery-service/order/grpc"
 	"github.com/steteruk/go-delivery-service/order/http/handler"
 	"github.com/steteruk/go-delivery-service/order/kafka"
 	"github.com/steteruk/go-delivery-service/order/storage/postgres"
 	pkghttp "github.com/steteruk/go-delivery-service/pkg/http"
 	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/tracing"
 	"log"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -27,6 +31,13 @@ func main() {
 		return
 	}
 
+	shutdownTracing, err := tracing.NewTracerProvider(context.Background(), "order", config.Tracing)
+	if err != nil {
+		log.Printf("failed to create tracer provider: %v\n", err)
+		return
+	}
+	defer shutdownTracing(context.Background())
+
 	credsPostgres := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable", config.DBUser, config.DBPassword, config.DBName)
 	clientPostgres, err := sql.Open("postgres", credsPostgres)
 	if err != nil {
@@ -35,27 +46,84 @@ func main() {
 	defer clientPostgres.Close()
 
 	orderRepo := postgres.NewOrderRepository(clientPostgres)
-	publisher, err := pkgkafka.NewPublisher([]string{config.KafkaAddress}, []string{config.KafkaSchemaRegistryAddress}, kafka.OrderTopic)
+	publisher, err := pkgkafka.NewPublisher(pkgkafka.PublisherOptions{
+		Address:               []string{config.KafkaAddress},
+		SchemaRegistryAddress: []string{config.KafkaSchemaRegistryAddress},
+		Topic:                 kafka.OrderTopic,
+		Partitioner:           config.PublisherPartitioner,
+		Security:              config.KafkaSecurity,
+		Producer:              config.KafkaPublisher,
+	})
 	if err != nil {
 		log.Printf("failed to create publisher: %v\n", err)
 		return
 	}
+	publisher.SetSync(config.PublisherSync)
+	defer publisher.Close()
+	if config.SchemaCompatibilityCheck {
+		if err := publisher.CheckSchemaCompatibility(context.Background(), avro.NewOrderMessage().Schema(), config.SchemaCompatibilityLevel); err != nil {
+			log.Printf("failed to check schema compatibility: %v\n", err)
+			return
+		}
+	}
 	orderPublisher := kafka.NewOrderPublisher(publisher)
 
 	orderService := domain.NewOrderService(orderRepo, orderPublisher)
+	orderStatusService := domain.NewOrderStatusService(orderRepo, orderPublisher)
+
+	locationGrpcConn, err := orderGrpc.NewLocationConnection(config.LocationGrpcAddress)
+	if err != nil {
+		log.Panicf("error location gRPC client connection: %v\n", err)
+	}
+	defer locationGrpcConn.Close()
+
+	etaLocation, err := time.LoadLocation(config.EtaTimeZone)
+	if err != nil {
+		log.Panicf("failed to load eta time zone %s: %v\n", config.EtaTimeZone, err)
+	}
+
+	etaService := domain.NewETAService(
+		orderRepo,
+		orderRepo,
+		orderGrpc.NewCourierLocationClient(locationGrpcConn),
+		domain.SpeedModel{
+			Speeds: map[string]float64{
+				"foot":    config.EtaSpeedFoot,
+				"bicycle": config.EtaSpeedBicycle,
+				"scooter": config.EtaSpeedScooter,
+				"car":     config.EtaSpeedCar,
+			},
+			DefaultVehicleType: config.EtaDefaultVehicleType,
+			RushHours:          config.EtaRushHours,
+			RushHourFactor:     config.EtaRushHourFactor,
+			DetourFactor:       config.EtaDetourFactor,
+			Location:           etaLocation,
+		},
+		time.Duration(config.EtaMaxAge)*time.Second,
+	)
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 	var wg sync.WaitGroup
-	wg.Add(2)
-	go runHttpServer(ctx, config, &wg, orderService)
-	go runOrderConsumer(ctx, orderService, &wg, config)
+	consumerAdminHandler := pkghttp.NewConsumerAdminHandler(pkghttp.NewHandler())
+	wg.Add(5)
+	go runHttpServer(ctx, config, &wg, orderService, etaService)
+	go runAdminServer(ctx, config, &wg, consumerAdminHandler)
+	go runOrderConsumer(ctx, stop, orderService, &wg, config, consumerAdminHandler)
+	go runCourierLocationConsumer(ctx, stop, etaService, &wg, config, consumerAdminHandler)
+	go runCourierGeofenceEventConsumer(ctx, stop, orderStatusService, &wg, config, consumerAdminHandler)
 	wg.Wait()
 
 }
 
-func runHttpServer(ctx context.Context, config env.Config, wg *sync.WaitGroup, orderService domain.OrderService) {
-	orderHandler := handler.NewOrderHandler(orderService, pkghttp.NewHandler())
+func runHttpServer(
+	ctx context.Context,
+	config env.Config,
+	wg *sync.WaitGroup,
+	orderService domain.OrderService,
+	etaService domain.ETAService,
+) {
+	orderHandler := handler.NewOrderHandler(orderService, etaService, pkghttp.NewHandler())
 
 	defer wg.Done()
 	routes := map[string]pkghttp.Route{
@@ -67,32 +135,200 @@ func runHttpServer(ctx context.Context, config env.Config, wg *sync.WaitGroup, o
 			Handler: orderHandler.GetOrderHandler,
 			Method:  "GET",
 		},
+		"/orders/{order_id}/eta": {
+			Handler: orderHandler.GetOrderETAHandler,
+			Method:  "GET",
+		},
+		"/metrics": pkghttp.MetricsRoute(),
 	}
 
 	router := pkghttp.NewRoute(routes, mux.NewRouter())
 	pkghttp.ServerRun(ctx, router, config.PortServer)
 }
 
-func runOrderConsumer(ctx context.Context, orderService domain.OrderService, wg *sync.WaitGroup, config env.Config) {
+// runAdminServer serves admin routes of consumers on internal admin port, they are not exposed with public api.
+func runAdminServer(ctx context.Context, config env.Config, wg *sync.WaitGroup, consumerAdminHandler *pkghttp.ConsumerAdminHandler) {
+	defer wg.Done()
+	pkghttp.ServerRun(ctx, consumerAdminHandler.Router(), config.AdminPortServer)
+}
+
+func runOrderConsumer(
+	ctx context.Context,
+	stop context.CancelFunc,
+	orderService domain.OrderService,
+	wg *sync.WaitGroup,
+	config env.Config,
+	consumerAdminHandler *pkghttp.ConsumerAdminHandler,
+) {
 	defer wg.Done()
 	orderConsumer := kafka.NewOrderConsumerValidation(orderService)
-	consumer, err := pkgkafka.NewConsumer(
-		orderConsumer,
-		config.KafkaAddress,
-		config.Verbose,
-		config.Oldest,
-		config.Assignor,
-		kafka.OrderValidationsTopic,
+	consumer, err := pkgkafka.NewAvroConsumer(
+		pkgkafka.NewAvroHandler[avro.OrderValidationMessage](orderConsumer),
+		pkgkafka.ConsumerOptions{
+			Brokers:               config.KafkaAddress,
+			SchemaRegistryAddress: []string{config.KafkaSchemaRegistryAddress},
+			Topic:                 kafka.OrderValidationsTopic,
+			Group:                 kafka.OrderValidationsTopic,
+			Assignor:              config.Assignor,
+			Oldest:                config.Oldest,
+			Verbose:               config.Verbose,
+			Security:              config.KafkaSecurity,
+			// courier publishes validations in transactions, validation of aborted attempt must not be read.
+			ReadCommitted: true,
+		},
+	)
+
+	if err != nil {
+		log.Panicf("Failed to create kafka consumer group: %v\n", err)
+	}
+
+	if config.ConsumerDeadLetterEnabled {
+		deadLetterPublisher := newDeadLetterPublisher(config)
+		defer deadLetterPublisher.Close()
+		consumer.SetDeadLetterPublisher(deadLetterPublisher)
+	}
+	if err := consumer.SetRetryPolicy(newRetryPolicy(config)); err != nil {
+		log.Panicf("Failed to set retry policy of consumer: %v\n", err)
+	}
+	consumer.SetReconnectPolicy(newReconnectPolicy(config))
+	consumerAdminHandler.AddConsumer(consumer)
+	consumer.SetKeyWorkers(config.ConsumerKeyWorkers)
+
+	consumeMessages(ctx, stop, consumer)
+}
+
+func runCourierLocationConsumer(
+	ctx context.Context,
+	stop context.CancelFunc,
+	etaService domain.ETAService,
+	wg *sync.WaitGroup,
+	config env.Config,
+	consumerAdminHandler *pkghttp.ConsumerAdminHandler,
+) {
+	defer wg.Done()
+	courierLocationConsumer := kafka.NewCourierLocationConsumer(etaService)
+	consumer, err := pkgkafka.NewAvroConsumer(
+		pkgkafka.NewAvroHandler[avro.LatestCourierLocationMessage](courierLocationConsumer),
+		pkgkafka.ConsumerOptions{
+			Brokers:               config.KafkaAddress,
+			SchemaRegistryAddress: []string{config.KafkaSchemaRegistryAddress},
+			Topic:                 kafka.LatestPositionCourierTopic,
+			Group:                 kafka.CourierLocationConsumerGroup,
+			Assignor:              config.Assignor,
+			Oldest:                config.Oldest,
+			Verbose:               config.Verbose,
+			Security:              config.KafkaSecurity,
+		},
+	)
+
+	if err != nil {
+		log.Panicf("Failed to create kafka consumer group: %v\n", err)
+	}
+
+	if config.ConsumerDeadLetterEnabled {
+		deadLetterPublisher := newDeadLetterPublisher(config)
+		defer deadLetterPublisher.Close()
+		consumer.SetDeadLetterPublisher(deadLetterPublisher)
+	}
+	if err := consumer.SetRetryPolicy(newRetryPolicy(config)); err != nil {
+		log.Panicf("Failed to set retry policy of consumer: %v\n", err)
+	}
+	consumer.SetReconnectPolicy(newReconnectPolicy(config))
+	consumerAdminHandler.AddConsumer(consumer)
+	consumer.SetKeyWorkers(config.ConsumerKeyWorkers)
+
+	consumeMessages(ctx, stop, consumer)
+}
+
+func runCourierGeofenceEventConsumer(
+	ctx context.Context,
+	stop context.CancelFunc,
+	orderStatusService domain.OrderStatusService,
+	wg *sync.WaitGroup,
+	config env.Config,
+	consumerAdminHandler *pkghttp.ConsumerAdminHandler,
+) {
+	defer wg.Done()
+	courierGeofenceEventConsumer := kafka.NewCourierGeofenceEventConsumer(orderStatusService)
+	schemaRegistryClient, err := pkgkafka.NewCachedSchemaRegistryClientWithOptions(
 		[]string{config.KafkaSchemaRegistryAddress},
+		config.KafkaSecurity.SchemaRegistry,
+	)
+	if err != nil {
+		log.Panicf("Failed to create schema registry client: %v\n", err)
+	}
+
+	geofenceEventSerde, err := pkgkafka.NewAvroSerde[avro.CourierGeofenceEventMessage](schemaRegistryClient)
+	if err != nil {
+		log.Panicf("Failed to create serde of geofence events: %v\n", err)
+	}
+
+	consumer, err := pkgkafka.NewTypedConsumer[avro.CourierGeofenceEventMessage](
+		courierGeofenceEventConsumer,
+		pkgkafka.ConsumerOptions{
+			Brokers:               config.KafkaAddress,
+			SchemaRegistryAddress: []string{config.KafkaSchemaRegistryAddress},
+			Topic:                 kafka.CourierGeofenceEventsTopic,
+			Group:                 kafka.CourierGeofenceEventConsumerGroup,
+			Assignor:              config.Assignor,
+			Oldest:                config.Oldest,
+			Verbose:               config.Verbose,
+			Security:              config.KafkaSecurity,
+			SchemaRegistryClient:  schemaRegistryClient,
+			Serde:                 pkgkafka.NewMessageSerde[avro.CourierGeofenceEventMessage](geofenceEventSerde),
+		},
 	)
 
 	if err != nil {
 		log.Panicf("Failed to create kafka consumer group: %v\n", err)
 	}
 
-	err = consumer.ConsumeMessage(ctx)
+	if config.ConsumerDeadLetterEnabled {
+		deadLetterPublisher := newDeadLetterPublisher(config)
+		defer deadLetterPublisher.Close()
+		consumer.SetDeadLetterPublisher(deadLetterPublisher)
+	}
+	if err := consumer.SetRetryPolicy(newRetryPolicy(config)); err != nil {
+		log.Panicf("Failed to set retry policy of consumer: %v\n", err)
+	}
+	consumer.SetReconnectPolicy(newReconnectPolicy(config))
+	consumerAdminHandler.AddConsumer(consumer)
+	consumer.SetKeyWorkers(config.ConsumerKeyWorkers)
+
+	consumeMessages(ctx, stop, consumer)
+}
+
+// consumeMessages runs consumer till context is canceled, failed consumer stops other consumers and servers too,
+// so service is restarted instead of running without consumer.
+func consumeMessages(ctx context.Context, stop context.CancelFunc, consumer *pkgkafka.Consumer) {
+	if err := consumer.ConsumeMessage(ctx); err != nil {
+		log.Printf("Failed to consume message: %v\n", err)
+		stop()
+	}
+}
 
+func newDeadLetterPublisher(config env.Config) *pkgkafka.DeadLetterPublisher {
+	deadLetterPublisher, err := pkgkafka.NewDeadLetterPublisherWithSecurity([]string{config.KafkaAddress}, config.KafkaSecurity)
 	if err != nil {
-		log.Panicf("Failed to consume message: %v\n", err)
+		log.Panicf("Failed to create dead letter publisher: %v\n", err)
+	}
+
+	return deadLetterPublisher
+}
+
+func newRetryPolicy(config env.Config) pkgkafka.RetryPolicy {
+	return pkgkafka.RetryPolicy{
+		MaxAttempts:      config.ConsumerMaxAttempts,
+		InitialBackoff:   config.ConsumerRetryInitialBackoff,
+		MaxBackoff:       config.ConsumerRetryMaxBackoff,
+		RetryTopicDelays: config.ConsumerRetryTopicDelays,
+	}
+}
+
+func newReconnectPolicy(config env.Config) pkgkafka.RetryPolicy {
+	return pkgkafka.RetryPolicy{
+		MaxAttempts:    config.ConsumerReconnectMaxAttempts,
+		InitialBackoff: config.ConsumerReconnectInitialBackoff,
+		MaxBackoff:     config.ConsumerReconnectMaxBackoff,
 	}
 }
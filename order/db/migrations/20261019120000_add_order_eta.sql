@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS dropoff_latitude double precision NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS dropoff_longitude double precision NULL;
+
+CREATE TABLE IF NOT EXISTS order_etas (
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    courier_id UUID NOT NULL,
+    distance_meters double precision NOT NULL,
+    duration_seconds BIGINT NOT NULL,
+    estimated_arrival_at TIMESTAMPTZ NOT NULL,
+    calculated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (order_id)
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE order_etas;
+ALTER TABLE orders DROP COLUMN dropoff_latitude;
+ALTER TABLE orders DROP COLUMN dropoff_longitude;
+-- +goose StatementEnd
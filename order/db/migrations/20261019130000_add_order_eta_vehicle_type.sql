@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE order_etas ADD COLUMN IF NOT EXISTS vehicle_type VARCHAR(50) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_etas DROP COLUMN vehicle_type;
+-- +goose StatementEnd
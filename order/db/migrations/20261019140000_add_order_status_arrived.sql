@@ -0,0 +1,6 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'arrived' AFTER 'in_progress';
+
+-- +goose Down
+-- value of enum can not be dropped in postgres, orders with status arrived are kept.
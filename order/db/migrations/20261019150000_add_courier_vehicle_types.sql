@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS courier_vehicle_types (
+    courier_id UUID NOT NULL,
+    vehicle_type VARCHAR(50) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (courier_id)
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE courier_vehicle_types;
+-- +goose StatementEnd
@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/geo"
+)
+
+var ErrOrderETANotFound = errors.New("order eta was not found")
+var ErrOrderDropOffNotFound = errors.New("order does not have drop-off position")
+var ErrOrderCourierNotAssigned = errors.New("courier was not assigned to order")
+var ErrCourierPositionNotFound = errors.New("courier position was not found")
+var ErrCourierVehicleTypeNotFound = errors.New("vehicle type of courier was not found")
+
+// LocationPosition coords of courier or drop-off point.
+type LocationPosition struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// OrderETA shows when courier arrives to drop-off point of order.
+type OrderETA struct {
+	OrderID            string    `json:"order_id"`
+	CourierID          string    `json:"courier_id"`
+	DistanceMeters     float64   `json:"distance_meters"`
+	DurationSeconds    int64     `json:"duration_seconds"`
+	EstimatedArrivalAt time.Time `json:"estimated_arrival_at"`
+	CalculatedAt       time.Time `json:"calculated_at"`
+	// VehicleType is vehicle of courier when eta was calculated, empty means default vehicle type.
+	VehicleType string `json:"vehicle_type,omitempty"`
+	// IsStale is true when courier position is unavailable and saved eta is older than max age.
+	IsStale bool `json:"is_stale"`
+}
+
+// SpeedModel describes average speed of courier in km/h for vehicle types, in rush hours speed is multiplied by rush hour factor.
+// Courier never moves by straight line, so distance is multiplied by detour factor.
+// Rush hours are hours in Location, UTC is used when Location is nil.
+type SpeedModel struct {
+	Speeds             map[string]float64
+	DefaultVehicleType string
+	RushHours          []int
+	RushHourFactor     float64
+	DetourFactor       float64
+	Location           *time.Location
+}
+
+// CourierLocationClient gets latest position of courier from location service.
+type CourierLocationClient interface {
+	GetLatestPosition(ctx context.Context, courierID string) (*LocationPosition, error)
+}
+
+// OrderETARepository saves eta of orders and finds orders which courier delivers now.
+// Vehicle type of courier is saved from his latest positions, so eta which is calculated on request uses it too.
+type OrderETARepository interface {
+	SaveOrderETA(ctx context.Context, orderETA *OrderETA) error
+	GetOrderETA(ctx context.Context, orderID string) (*OrderETA, error)
+	GetActiveOrdersByCourierID(ctx context.Context, courierID string) ([]*Order, error)
+	SaveCourierVehicleType(ctx context.Context, courierID string, vehicleType string, positionAt time.Time) error
+	GetCourierVehicleType(ctx context.Context, courierID string) (string, error)
+}
+
+type ETAService interface {
+	GetOrderETA(ctx context.Context, orderID string) (*OrderETA, error)
+	RecalculateCourierOrdersETA(ctx context.Context, courierID string, vehicleType string, courierPosition *LocationPosition, positionAt time.Time) error
+}
+
+type ETAServiceManager struct {
+	orderRepo             OrderRepository
+	etaRepo               OrderETARepository
+	courierLocationClient CourierLocationClient
+	speedModel            SpeedModel
+	maxAge                time.Duration
+}
+
+// NewETAService creates eta service, saved eta is older than maxAge is calculated again by latest position of courier.
+func NewETAService(
+	orderRepo OrderRepository,
+	etaRepo OrderETARepository,
+	courierLocationClient CourierLocationClient,
+	speedModel SpeedModel,
+	maxAge time.Duration,
+) ETAService {
+	return &ETAServiceManager{
+		orderRepo:             orderRepo,
+		etaRepo:               etaRepo,
+		courierLocationClient: courierLocationClient,
+		speedModel:            speedModel,
+		maxAge:                maxAge,
+	}
+}
+
+// Speed returns speed in km/h for vehicle type at specific time.
+func (m *SpeedModel) Speed(vehicleType string, at time.Time) float64 {
+	speed, ok := m.Speeds[vehicleType]
+	if !ok {
+		speed = m.Speeds[m.DefaultVehicleType]
+	}
+
+	location := m.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	hour := at.In(location).Hour()
+	for _, rushHour := range m.RushHours {
+		if hour == rushHour {
+			return speed * m.RushHourFactor
+		}
+	}
+
+	return speed
+}
+
+// EstimateDuration returns time which courier needs to drive from one point to another by vehicle type.
+func (m *SpeedModel) EstimateDuration(vehicleType string, from LocationPosition, to LocationPosition, at time.Time) (float64, time.Duration) {
+	distance := geo.DistanceMeters(
+		geo.Point{Latitude: from.Latitude, Longitude: from.Longitude},
+		geo.Point{Latitude: to.Latitude, Longitude: to.Longitude},
+	) * m.DetourFactor
+
+	speed := m.Speed(vehicleType, at)
+	if speed <= 0 {
+		return distance, 0
+	}
+
+	return distance, time.Duration(distance / (speed / 3.6) * float64(time.Second))
+}
+
+// GetOrderETA returns saved eta or calculates eta by latest position of courier when saved eta is too old.
+// When location service is unavailable saved eta is returned as stale.
+func (s *ETAServiceManager) GetOrderETA(ctx context.Context, orderID string) (*OrderETA, error) {
+	orderETA, err := s.etaRepo.GetOrderETA(ctx, orderID)
+	if err != nil && !errors.Is(err, ErrOrderETANotFound) {
+		return nil, fmt.Errorf("failed to get order eta: %w", err)
+	}
+
+	if orderETA != nil && time.Since(orderETA.CalculatedAt) < s.maxAge {
+		return orderETA, nil
+	}
+
+	order, err := s.orderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order.CourierID == "" {
+		return nil, ErrOrderCourierNotAssigned
+	}
+
+	courierPosition, err := s.courierLocationClient.GetLatestPosition(ctx, order.CourierID)
+	if err != nil && orderETA != nil {
+		log.Printf("failed to get courier position, stale eta of order %s is returned: %v\n", orderID, err)
+		orderETA.IsStale = true
+
+		return orderETA, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier position: %w", err)
+	}
+
+	// location service returns only position, so vehicle type is taken from latest positions of courier.
+	vehicleType, err := s.etaRepo.GetCourierVehicleType(ctx, order.CourierID)
+	if err != nil && !errors.Is(err, ErrCourierVehicleTypeNotFound) {
+		return nil, fmt.Errorf("failed to get vehicle type of courier: %w", err)
+	}
+
+	return s.calculateOrderETA(ctx, order, vehicleType, courierPosition, time.Now())
+}
+
+// RecalculateCourierOrdersETA calculates eta for all orders which courier delivers now, when we get new position of courier.
+func (s *ETAServiceManager) RecalculateCourierOrdersETA(ctx context.Context, courierID string, vehicleType string, courierPosition *LocationPosition, positionAt time.Time) error {
+	if vehicleType != "" {
+		if err := s.etaRepo.SaveCourierVehicleType(ctx, courierID, vehicleType, positionAt); err != nil {
+			return fmt.Errorf("failed to save vehicle type of courier: %w", err)
+		}
+	}
+
+	orders, err := s.etaRepo.GetActiveOrdersByCourierID(ctx, courierID)
+	if err != nil {
+		return fmt.Errorf("failed to get active orders of courier: %w", err)
+	}
+
+	for _, order := range orders {
+		_, err := s.calculateOrderETA(ctx, order, vehicleType, courierPosition, positionAt)
+		if errors.Is(err, ErrOrderDropOffNotFound) {
+			log.Printf("skip eta of order %s: %v\n", order.ID, err)
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ETAServiceManager) calculateOrderETA(ctx context.Context, order *Order, vehicleType string, courierPosition *LocationPosition, positionAt time.Time) (*OrderETA, error) {
+	if order.DropOff == nil {
+		return nil, ErrOrderDropOffNotFound
+	}
+
+	distance, duration := s.speedModel.EstimateDuration(vehicleType, *courierPosition, *order.DropOff, positionAt)
+	orderETA := &OrderETA{
+		OrderID:            order.ID,
+		CourierID:          order.CourierID,
+		DistanceMeters:     distance,
+		DurationSeconds:    int64(duration.Seconds()),
+		EstimatedArrivalAt: positionAt.Add(duration),
+		CalculatedAt:       positionAt,
+		VehicleType:        vehicleType,
+	}
+
+	if err := s.etaRepo.SaveOrderETA(ctx, orderETA); err != nil {
+		return nil, fmt.Errorf("failed to save order eta: %w", err)
+	}
+
+	return orderETA, nil
+}
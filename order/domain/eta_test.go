@@ -0,0 +1,89 @@
+package domain_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/order/domain"
+)
+
+func newSpeedModel(location *time.Location) *domain.SpeedModel {
+	return &domain.SpeedModel{
+		Speeds:             map[string]float64{"bicycle": 15, "car": 30},
+		DefaultVehicleType: "bicycle",
+		RushHours:          []int{8, 18},
+		RushHourFactor:     0.5,
+		DetourFactor:       1.3,
+		Location:           location,
+	}
+}
+
+func TestSpeedModelSlowsDownInRushHour(t *testing.T) {
+	speedModel := newSpeedModel(nil)
+
+	if speed := speedModel.Speed("car", time.Date(2026, 10, 19, 18, 30, 0, 0, time.UTC)); speed != 15 {
+		t.Fatalf("expected 15 km/h in rush hour, got %v", speed)
+	}
+
+	if speed := speedModel.Speed("car", time.Date(2026, 10, 19, 19, 0, 0, 0, time.UTC)); speed != 30 {
+		t.Fatalf("expected 30 km/h after rush hour, got %v", speed)
+	}
+}
+
+func TestSpeedModelUsesRushHoursOfLocation(t *testing.T) {
+	kyiv := time.FixedZone("EEST", 3*60*60)
+	speedModel := newSpeedModel(kyiv)
+
+	// 15:30 UTC is 18:30 in Kyiv.
+	if speed := speedModel.Speed("car", time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)); speed != 15 {
+		t.Fatalf("expected 15 km/h in rush hour of location, got %v", speed)
+	}
+
+	// 18:30 UTC is 21:30 in Kyiv.
+	if speed := speedModel.Speed("car", time.Date(2026, 10, 19, 18, 30, 0, 0, time.UTC)); speed != 30 {
+		t.Fatalf("expected 30 km/h out of rush hour of location, got %v", speed)
+	}
+}
+
+func TestSpeedModelUsesDefaultVehicleType(t *testing.T) {
+	speedModel := newSpeedModel(nil)
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	if speed := speedModel.Speed("", at); speed != 15 {
+		t.Fatalf("expected speed of default vehicle type for empty type, got %v", speed)
+	}
+
+	if speed := speedModel.Speed("scooter", at); speed != 15 {
+		t.Fatalf("expected speed of default vehicle type for unknown type, got %v", speed)
+	}
+}
+
+func TestSpeedModelEstimatesDurationWithDetour(t *testing.T) {
+	speedModel := newSpeedModel(nil)
+	from := domain.LocationPosition{Latitude: 50.45, Longitude: 30.52}
+	to := domain.LocationPosition{Latitude: 50.46, Longitude: 30.52}
+
+	distance, duration := speedModel.EstimateDuration("car", from, to, time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+
+	// 0.01 degree of latitude is about 1112 m, with detour it is about 1446 m.
+	if math.Abs(distance-1445.6) > 1 {
+		t.Fatalf("expected about 1445.6 m, got %v", distance)
+	}
+
+	expected := time.Duration(distance / (30 / 3.6) * float64(time.Second))
+	if duration != expected {
+		t.Fatalf("expected %v, got %v", expected, duration)
+	}
+}
+
+func TestSpeedModelReturnsZeroDurationWithoutSpeed(t *testing.T) {
+	speedModel := &domain.SpeedModel{DetourFactor: 1}
+	from := domain.LocationPosition{Latitude: 50.45, Longitude: 30.52}
+	to := domain.LocationPosition{Latitude: 50.46, Longitude: 30.52}
+
+	distance, duration := speedModel.EstimateDuration("car", from, to, time.Now())
+	if distance == 0 || duration != 0 {
+		t.Fatalf("expected distance and zero duration, got %v and %v", distance, duration)
+	}
+}
@@ -27,6 +27,8 @@ type Order struct {
 	CustomerPhoneNumber string    `json:"customer_phone_number"`
 	Status              string    `json:"status"`
 	CreatedAt           time.Time `json:"created_at"`
+	// DropOff is position where courier delivers order, it needs for eta.
+	DropOff *LocationPosition `json:"drop_off,omitempty"`
 }
 
 // OrderValidation imagine entity for order validation for saving in db
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+const OrderStatusInProgress = "in_progress"
+const OrderStatusArrived = "arrived"
+
+const (
+	GeofenceEventCourierEntered = "courier_entered"
+	GeofenceEventCourierExited  = "courier_exited"
+)
+
+const (
+	GeofenceTypeRestaurant = "restaurant"
+	GeofenceTypeWarehouse  = "warehouse"
+	GeofenceTypeDropOff    = "drop_off"
+)
+
+// CourierGeofenceEvent is event of location service that courier entered or exited geofence, drop-off geofence has order id.
+type CourierGeofenceEvent struct {
+	Event        string
+	CourierID    string
+	GeofenceID   string
+	GeofenceType string
+	OrderID      string
+}
+
+// OrderStatusRepository finds orders of courier and saves new status of order.
+type OrderStatusRepository interface {
+	GetOrderByID(ctx context.Context, orderID string) (*Order, error)
+	GetActiveOrdersByCourierID(ctx context.Context, courierID string) ([]*Order, error)
+	UpdateOrder(ctx context.Context, order *Order) error
+}
+
+type OrderStatusService interface {
+	HandleCourierGeofenceEvent(ctx context.Context, event *CourierGeofenceEvent) error
+}
+
+// OrderStatusServiceManager changes status of orders by geofence events of courier.
+// Courier picked up orders when he exited restaurant or warehouse, courier arrived when he entered drop-off geofence of order.
+type OrderStatusServiceManager struct {
+	orderRepo      OrderStatusRepository
+	orderPublisher OrderPublisher
+}
+
+// NewOrderStatusService creates service which changes status of orders by geofence events.
+func NewOrderStatusService(orderRepo OrderStatusRepository, orderPublisher OrderPublisher) OrderStatusService {
+	return &OrderStatusServiceManager{
+		orderRepo:      orderRepo,
+		orderPublisher: orderPublisher,
+	}
+}
+
+// HandleCourierGeofenceEvent changes status of orders, other events are skipped.
+// Order which already has new status is not updated again, so event which is handled again does not publish duplicate.
+func (s *OrderStatusServiceManager) HandleCourierGeofenceEvent(ctx context.Context, event *CourierGeofenceEvent) error {
+	switch {
+	case event.Event == GeofenceEventCourierExited &&
+		(event.GeofenceType == GeofenceTypeRestaurant || event.GeofenceType == GeofenceTypeWarehouse):
+		return s.pickUpCourierOrders(ctx, event.CourierID)
+	case event.Event == GeofenceEventCourierEntered && event.GeofenceType == GeofenceTypeDropOff && event.OrderID != "":
+		return s.arriveOrder(ctx, event.CourierID, event.OrderID)
+	}
+
+	return nil
+}
+
+func (s *OrderStatusServiceManager) pickUpCourierOrders(ctx context.Context, courierID string) error {
+	orders, err := s.orderRepo.GetActiveOrdersByCourierID(ctx, courierID)
+	if err != nil {
+		return fmt.Errorf("failed to get active orders of courier: %w", err)
+	}
+
+	for _, order := range orders {
+		if order.Status != OrderStatusAccepted {
+			continue
+		}
+
+		if err := s.changeOrderStatus(ctx, order, OrderStatusInProgress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *OrderStatusServiceManager) arriveOrder(ctx context.Context, courierID string, orderID string) error {
+	order, err := s.orderRepo.GetOrderByID(ctx, orderID)
+	if errors.Is(err, ErrOrderNotFound) {
+		log.Printf("order %s of drop-off geofence was not found\n", orderID)
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	// other courier can pass near address of customer.
+	if order.CourierID != courierID {
+		return nil
+	}
+
+	if order.Status != OrderStatusAccepted && order.Status != OrderStatusInProgress {
+		return nil
+	}
+
+	return s.changeOrderStatus(ctx, order, OrderStatusArrived)
+}
+
+func (s *OrderStatusServiceManager) changeOrderStatus(ctx context.Context, order *Order, status string) error {
+	order.Status = status
+	if err := s.orderRepo.UpdateOrder(ctx, order); err != nil {
+		return fmt.Errorf("failed to update status of order in database: %w", err)
+	}
+
+	if err := s.orderPublisher.PublishOrder(ctx, order, EventOrderUpdated); err != nil {
+		return fmt.Errorf("failed to publish a order in the kafka: %w", err)
+	}
+
+	return nil
+}
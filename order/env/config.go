@@ -1,24 +1,59 @@
 package env
 
 import (
+	"time"
+
 	coreEnv "github.com/caarlos0/env/v9"
+	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/tracing"
 )
 
 type Config struct {
-	DBName                     string `env:"POSTGRES_DB" envDefault:"orders"`
-	DBPassword                 string `env:"POSTGRES_PASSWORD" envDefault:"S3cret"`
-	DBUser                     string `env:"POSTGRES_USER" envDefault:"citizix_user"`
-	PortServer                 string `env:"PORT_SERVER" envDefault:":8872"`
-	CourierGrpcPort            string `env:"COURIER_GRPC_PORT" envDefault:":9671"`
-	KafkaAddress               string `env:"KAFKA_BROKERS" envDefault:"localhost:9092"`
-	KafkaSchemaRegistryAddress string `env:"KAFKA_SCHEMA_REGISTRY_ADDRESS" envDefault:"http://localhost:8085"`
-	Assignor                   string `env:"KAFKA_CONSUMER_ASSIGNOR" envDefault:"range"`
-	Oldest                     bool   `env:"KAFKA_CONSUMER_OLDEST" envDefault:"true"`
-	Verbose                    bool   `env:"KAFKA_CONSUMER_VERBOSE" envDefault:"false"`
+	DBName                          string                   `env:"POSTGRES_DB" envDefault:"orders"`
+	DBPassword                      string                   `env:"POSTGRES_PASSWORD" envDefault:"S3cret"`
+	DBUser                          string                   `env:"POSTGRES_USER" envDefault:"citizix_user"`
+	PortServer                      string                   `env:"PORT_SERVER" envDefault:":8872"`
+	AdminPortServer                 string                   `env:"ADMIN_PORT_SERVER" envDefault:"127.0.0.1:8873"`
+	CourierGrpcPort                 string                   `env:"COURIER_GRPC_PORT" envDefault:":9671"`
+	KafkaAddress                    string                   `env:"KAFKA_BROKERS" envDefault:"localhost:9092"`
+	KafkaSchemaRegistryAddress      string                   `env:"KAFKA_SCHEMA_REGISTRY_ADDRESS" envDefault:"http://localhost:8085"`
+	Assignor                        string                   `env:"KAFKA_CONSUMER_ASSIGNOR" envDefault:"range"`
+	Oldest                          bool                     `env:"KAFKA_CONSUMER_OLDEST" envDefault:"true"`
+	Verbose                         bool                     `env:"KAFKA_CONSUMER_VERBOSE" envDefault:"false"`
+	PublisherSync                   bool                     `env:"KAFKA_PUBLISHER_SYNC" envDefault:"true"`
+	PublisherPartitioner            string                   `env:"KAFKA_PUBLISHER_PARTITIONER" envDefault:"murmur2"`
+	SchemaCompatibilityCheck        bool                     `env:"KAFKA_SCHEMA_COMPATIBILITY_CHECK" envDefault:"true"`
+	SchemaCompatibilityLevel        string                   `env:"KAFKA_SCHEMA_COMPATIBILITY_LEVEL"`
+	ConsumerDeadLetterEnabled       bool                     `env:"KAFKA_CONSUMER_DEAD_LETTER_ENABLED" envDefault:"true"`
+	ConsumerMaxAttempts             int                      `env:"KAFKA_CONSUMER_MAX_ATTEMPTS" envDefault:"3"`
+	ConsumerKeyWorkers              int                      `env:"KAFKA_CONSUMER_KEY_WORKERS" envDefault:"0"`
+	ConsumerRetryInitialBackoff     time.Duration            `env:"KAFKA_CONSUMER_RETRY_INITIAL_BACKOFF" envDefault:"100ms"`
+	ConsumerRetryMaxBackoff         time.Duration            `env:"KAFKA_CONSUMER_RETRY_MAX_BACKOFF" envDefault:"5s"`
+	ConsumerReconnectMaxAttempts    int                      `env:"KAFKA_CONSUMER_RECONNECT_MAX_ATTEMPTS" envDefault:"0"`
+	ConsumerReconnectInitialBackoff time.Duration            `env:"KAFKA_CONSUMER_RECONNECT_INITIAL_BACKOFF" envDefault:"1s"`
+	ConsumerReconnectMaxBackoff     time.Duration            `env:"KAFKA_CONSUMER_RECONNECT_MAX_BACKOFF" envDefault:"30s"`
+	ConsumerRetryTopicDelays        []time.Duration          `env:"KAFKA_CONSUMER_RETRY_TOPIC_DELAYS" envSeparator:","`
+	LocationGrpcAddress             string                   `env:"LOCATION_GRPC_ADDRESS" envDefault:":9667"`
+	EtaDefaultVehicleType           string                   `env:"ETA_DEFAULT_VEHICLE_TYPE" envDefault:"bicycle"`
+	EtaSpeedFoot                    float64                  `env:"ETA_SPEED_FOOT" envDefault:"5"`
+	EtaSpeedBicycle                 float64                  `env:"ETA_SPEED_BICYCLE" envDefault:"15"`
+	EtaSpeedScooter                 float64                  `env:"ETA_SPEED_SCOOTER" envDefault:"25"`
+	EtaSpeedCar                     float64                  `env:"ETA_SPEED_CAR" envDefault:"30"`
+	EtaRushHours                    []int                    `env:"ETA_RUSH_HOURS" envDefault:"8,9,17,18,19" envSeparator:","`
+	EtaRushHourFactor               float64                  `env:"ETA_RUSH_HOUR_FACTOR" envDefault:"0.7"`
+	EtaDetourFactor                 float64                  `env:"ETA_DETOUR_FACTOR" envDefault:"1.3"`
+	EtaMaxAge                       int                      `env:"ETA_MAX_AGE" envDefault:"60"`
+	EtaTimeZone                     string                   `env:"ETA_TIME_ZONE" envDefault:"UTC"`
+	KafkaSecurity                   pkgkafka.SecurityOptions `envPrefix:"KAFKA_"`
+	KafkaPublisher                  pkgkafka.ProducerOptions `envPrefix:"KAFKA_PUBLISHER_"`
+	Tracing                         tracing.Options          `envPrefix:"TRACING_"`
 }
 
 func GetConfig() (config Config, err error) {
-	cfg := Config{}
+	cfg := Config{
+		// orders must not be duplicated on retries of producer, so publisher is idempotent by default.
+		KafkaPublisher: pkgkafka.ProducerOptions{Idempotent: true},
+	}
 	err = coreEnv.Parse(&cfg)
 
 	return cfg, err
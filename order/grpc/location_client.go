@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/steteruk/go-delivery-service/order/domain"
+	pb "github.com/steteruk/go-delivery-service/proto/generate/location/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type CourierLocationClient struct {
+	courierClientGrpc pb.CourierClient
+}
+
+func NewCourierLocationClient(locationConnection *grpc.ClientConn) *CourierLocationClient {
+	return &CourierLocationClient{
+		courierClientGrpc: pb.NewCourierClient(locationConnection),
+	}
+}
+
+// GetLatestPosition gets latest position of courier from location service.
+func (cl *CourierLocationClient) GetLatestPosition(ctx context.Context, courierID string) (*domain.LocationPosition, error) {
+	courierLatestPositionResponse, err := cl.courierClientGrpc.GetCourierLatestPosition(ctx, &pb.GetCourierLatestPositionRequest{CourierId: courierID})
+	code, ok := status.FromError(err)
+	if ok && code.Code() == codes.NotFound {
+		log.Printf("Not Found: %v\n", err)
+		return nil, domain.ErrCourierPositionNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LocationPosition{
+		Latitude:  courierLatestPositionResponse.Latitude,
+		Longitude: courierLatestPositionResponse.Longitude,
+	}, nil
+}
+
+func NewLocationConnection(locationGrpcAddress string) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+
+	return grpc.Dial(locationGrpcAddress, opts...)
+}
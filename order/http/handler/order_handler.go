@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -11,18 +13,27 @@ import (
 
 type OrderHandler struct {
 	orderService domain.OrderService
+	etaService   domain.ETAService
 	httpHandler  pkghttp.HandlerInterface
 }
 
-func NewOrderHandler(orderService domain.OrderService, handler pkghttp.HandlerInterface) *OrderHandler {
+func NewOrderHandler(orderService domain.OrderService, etaService domain.ETAService, handler pkghttp.HandlerInterface) *OrderHandler {
 	return &OrderHandler{
 		orderService: orderService,
+		etaService:   etaService,
 		httpHandler:  handler,
 	}
 }
 
+// DropOffPayload position where courier delivers order.
+type DropOffPayload struct {
+	Latitude  float64 `json:"latitude" validate:"required,latitude"`
+	Longitude float64 `json:"longitude" validate:"required,longitude"`
+}
+
 type CreateOrderPayload struct {
-	CustomerPhoneNumber string `json:"customer_phone_number" validate:"required,e164"`
+	CustomerPhoneNumber string          `json:"customer_phone_number" validate:"required,e164"`
+	DropOff             *DropOffPayload `json:"drop_off" validate:"omitempty"`
 }
 
 type CreateOrderResponse struct {
@@ -55,6 +66,12 @@ func (h *OrderHandler) CreateOrderHandler(w http.ResponseWriter, r *http.Request
 
 	ctx := r.Context()
 	order := h.orderService.NewOrder(orderPayload.CustomerPhoneNumber)
+	if orderPayload.DropOff != nil {
+		order.DropOff = &domain.LocationPosition{
+			Latitude:  orderPayload.DropOff.Latitude,
+			Longitude: orderPayload.DropOff.Longitude,
+		}
+	}
 	order, err := h.orderService.CreateOrder(
 		ctx,
 		order,
@@ -91,3 +108,36 @@ func (h *OrderHandler) GetOrderHandler(w http.ResponseWriter, r *http.Request) {
 	orderRes := &GetOrderResponse{Status: order.Status}
 	h.httpHandler.SuccessResponse(w, orderRes, http.StatusOK)
 }
+
+// GetOrderETAHandler returns when courier arrives to drop-off point of order.
+func (h *OrderHandler) GetOrderETAHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderPayload := &GetOrderPayload{OrderID: vars["order_id"]}
+	if err := h.httpHandler.ValidatePayload(orderPayload); err != nil {
+		h.httpHandler.FailResponse(w, err)
+
+		return
+	}
+
+	orderETA, err := h.etaService.GetOrderETA(r.Context(), orderPayload.OrderID)
+	isNotFound := errors.Is(err, domain.ErrOrderNotFound) ||
+		errors.Is(err, domain.ErrOrderCourierNotAssigned) ||
+		errors.Is(err, domain.ErrOrderDropOffNotFound) ||
+		errors.Is(err, domain.ErrCourierPositionNotFound)
+
+	if isNotFound {
+		log.Printf("eta is unknown: %v", err)
+		h.httpHandler.FailResponse(w, fmt.Errorf("%w: %w", pkghttp.ErrNotFound, err))
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("failed to get order eta: %v", err)
+		h.httpHandler.FailResponse(w, err)
+
+		return
+	}
+
+	h.httpHandler.SuccessResponse(w, orderETA, http.StatusOK)
+}
@@ -0,0 +1,46 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/steteruk/go-delivery-service/avro/v1"
+	"github.com/steteruk/go-delivery-service/order/domain"
+)
+
+const CourierGeofenceEventsTopic = "courier_geofence_events.v1"
+
+// CourierGeofenceEventConsumerGroup order service has own consumer group, because other services can consume the same topic.
+const CourierGeofenceEventConsumerGroup = "order-status.courier_geofence_events.v1"
+
+// CourierGeofenceEventConsumer consumes geofence events of couriers and changes status of their orders.
+type CourierGeofenceEventConsumer struct {
+	orderStatusService domain.OrderStatusService
+}
+
+// NewCourierGeofenceEventConsumer creates courier geofence event consumer.
+func NewCourierGeofenceEventConsumer(orderStatusService domain.OrderStatusService) *CourierGeofenceEventConsumer {
+	return &CourierGeofenceEventConsumer{
+		orderStatusService: orderStatusService,
+	}
+}
+
+// HandleMessage Handle kafka message with geofence event of courier
+func (courierGeofenceEventConsumer *CourierGeofenceEventConsumer) HandleMessage(ctx context.Context, courierGeofenceEventMessage avro.CourierGeofenceEventMessage) error {
+	event := &domain.CourierGeofenceEvent{
+		Event:        courierGeofenceEventMessage.Event,
+		CourierID:    courierGeofenceEventMessage.Courier_id,
+		GeofenceID:   courierGeofenceEventMessage.Geofence_id,
+		GeofenceType: courierGeofenceEventMessage.Geofence_type,
+	}
+
+	if courierGeofenceEventMessage.Order_id != nil && courierGeofenceEventMessage.Order_id.UnionType == avro.UnionStringNullTypeEnumString {
+		event.OrderID = courierGeofenceEventMessage.Order_id.String
+	}
+
+	if err := courierGeofenceEventConsumer.orderStatusService.HandleCourierGeofenceEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to change status of orders by geofence event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/avro/v1"
+	"github.com/steteruk/go-delivery-service/order/domain"
+)
+
+const LatestPositionCourierTopic = "latest_position_courier.v1"
+
+// CourierLocationConsumerGroup order service has own consumer group, because location service consumes the same topic.
+const CourierLocationConsumerGroup = "order-eta.latest_position_courier.v1"
+
+// CourierLocationConsumer consumes latest positions of couriers and recalculates eta of their orders.
+type CourierLocationConsumer struct {
+	etaService domain.ETAService
+}
+
+// NewCourierLocationConsumer creates courier location consumer.
+func NewCourierLocationConsumer(etaService domain.ETAService) *CourierLocationConsumer {
+	return &CourierLocationConsumer{
+		etaService: etaService,
+	}
+}
+
+// HandleMessage Handle kafka message with latest position of courier
+func (courierLocationConsumer *CourierLocationConsumer) HandleMessage(ctx context.Context, latestCourierLocationMessage avro.LatestCourierLocationMessage) error {
+	// gps filter flagged position, eta is recalculated by next position.
+	if latestCourierLocationMessage.Is_implausible {
+		return nil
+	}
+
+	err := courierLocationConsumer.etaService.RecalculateCourierOrdersETA(
+		ctx,
+		latestCourierLocationMessage.Courier_id,
+		latestCourierLocationMessage.Vehicle_type,
+		&domain.LocationPosition{
+			Latitude:  latestCourierLocationMessage.Latitude,
+			Longitude: latestCourierLocationMessage.Longitude,
+		},
+		time.Unix(latestCourierLocationMessage.Created_at, 0),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to recalculate eta of courier orders: %w", err)
+	}
+
+	return nil
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/steteruk/go-delivery-service/avro/v1"
 	"github.com/steteruk/go-delivery-service/order/domain"
-	"log"
 )
 
 const OrderValidationsTopic = "order_validations.v1"
@@ -33,18 +32,13 @@ func NewOrderConsumerValidation(orderService domain.OrderService) *OrderConsumer
 	return orderConsumer
 }
 
-// HandleJSONMessage Handle kafka message in json format
-func (orderConsumerValidation *OrderConsumerValidation) HandleJSONMessage(ctx context.Context, message []byte) error {
-	orderValidationMessage := avro.NewOrderValidationMessage()
-	if err := orderValidationMessage.UnmarshalJSON(message); err != nil {
-		log.Printf("failed to unmarshal Kafka message into order validation struct: %v\n", err)
-
-		return nil
+// HandleMessage Handle kafka message with validation of order, message is resolved from schema of producer to schema of consumer.
+func (orderConsumerValidation *OrderConsumerValidation) HandleMessage(ctx context.Context, orderValidationMessage avro.OrderValidationMessage) error {
+	orderValidationPayload := domain.OrderValidationPayload{}
+	if orderValidationMessage.Payload.Courier_id != nil {
+		orderValidationPayload.CourierID = orderValidationMessage.Payload.Courier_id.String
 	}
 
-	orderValidationPayload := domain.OrderValidationPayload{
-		CourierID: orderValidationMessage.Payload.Courier_id.String,
-	}
 	err := orderConsumerValidation.orderService.ValidateOrderForService(
 		ctx,
 		orderValidationMessage.Service_name,
@@ -20,19 +20,28 @@ func NewOrderRepository(client *sql.DB) *OrderRepository {
 }
 
 func (r *OrderRepository) SaveNewOrder(ctx context.Context, order *domain.Order) (*domain.Order, error) {
-	sqlStatement := "INSERT INTO orders (customer_phone_number, status, created_at) VALUES ($1, $2, $3) RETURNING id, courier_id, customer_phone_number, status, created_at"
+	sqlStatement := "INSERT INTO orders (customer_phone_number, status, created_at, dropoff_latitude, dropoff_longitude) VALUES ($1, $2, $3, $4, $5) " +
+		"RETURNING id, courier_id, customer_phone_number, status, created_at, dropoff_latitude, dropoff_longitude"
+	var dropOffLatitude, dropOffLongitude sql.NullFloat64
+	if order.DropOff != nil {
+		dropOffLatitude = sql.NullFloat64{Float64: order.DropOff.Latitude, Valid: true}
+		dropOffLongitude = sql.NullFloat64{Float64: order.DropOff.Longitude, Valid: true}
+	}
 	row := r.client.QueryRowContext(
 		ctx,
 		sqlStatement,
 		order.CustomerPhoneNumber,
 		order.Status,
 		order.CreatedAt,
+		dropOffLatitude,
+		dropOffLongitude,
 	)
 
 	newOrder := domain.Order{}
 	var courierID sql.NullString
-	err := row.Scan(&newOrder.ID, &courierID, &newOrder.CustomerPhoneNumber, &newOrder.Status, &newOrder.CreatedAt)
+	err := row.Scan(&newOrder.ID, &courierID, &newOrder.CustomerPhoneNumber, &newOrder.Status, &newOrder.CreatedAt, &dropOffLatitude, &dropOffLongitude)
 	order.CourierID = courierID.String
+	newOrder.DropOff = dropOffPosition(dropOffLatitude, dropOffLongitude)
 
 	if err != nil {
 		fmt.Println(err)
@@ -43,7 +52,7 @@ func (r *OrderRepository) SaveNewOrder(ctx context.Context, order *domain.Order)
 }
 
 func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID string) (*domain.Order, error) {
-	sqlStatement := "SELECT * FROM orders WHERE id = $1"
+	sqlStatement := "SELECT id, courier_id, customer_phone_number, status, created_at, dropoff_latitude, dropoff_longitude FROM orders WHERE id = $1"
 	row := r.client.QueryRowContext(
 		ctx,
 		sqlStatement,
@@ -52,8 +61,10 @@ func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID string) (*do
 
 	order := domain.Order{}
 	var courierID sql.NullString
-	err := row.Scan(&order.ID, &courierID, &order.CustomerPhoneNumber, &order.Status, &order.CreatedAt)
+	var dropOffLatitude, dropOffLongitude sql.NullFloat64
+	err := row.Scan(&order.ID, &courierID, &order.CustomerPhoneNumber, &order.Status, &order.CreatedAt, &dropOffLatitude, &dropOffLongitude)
 	order.CourierID = courierID.String
+	order.DropOff = dropOffPosition(dropOffLatitude, dropOffLongitude)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, domain.ErrOrderNotFound
@@ -172,3 +183,113 @@ func (repo *OrderRepository) UpdateOrder(ctx context.Context, order *domain.Orde
 
 	return err
 }
+
+// GetActiveOrdersByCourierID returns orders which courier delivers now.
+func (repo *OrderRepository) GetActiveOrdersByCourierID(ctx context.Context, courierID string) ([]*domain.Order, error) {
+	query := "SELECT id, courier_id, customer_phone_number, status, created_at, dropoff_latitude, dropoff_longitude FROM orders " +
+		"WHERE courier_id = $1 AND status IN ('accepted', 'in_progress')"
+	rows, err := repo.client.QueryContext(ctx, query, courierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*domain.Order
+	for rows.Next() {
+		order := domain.Order{}
+		var dropOffLatitude, dropOffLongitude sql.NullFloat64
+		err := rows.Scan(&order.ID, &order.CourierID, &order.CustomerPhoneNumber, &order.Status, &order.CreatedAt, &dropOffLatitude, &dropOffLongitude)
+		if err != nil {
+			return nil, err
+		}
+		order.DropOff = dropOffPosition(dropOffLatitude, dropOffLongitude)
+
+		orders = append(orders, &order)
+	}
+
+	return orders, rows.Err()
+}
+
+// SaveOrderETA creates or updates eta of order.
+func (repo *OrderRepository) SaveOrderETA(ctx context.Context, orderETA *domain.OrderETA) error {
+	query := "INSERT INTO order_etas (order_id, courier_id, distance_meters, duration_seconds, estimated_arrival_at, calculated_at, vehicle_type) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (order_id) DO UPDATE SET courier_id = EXCLUDED.courier_id, " +
+		"distance_meters = EXCLUDED.distance_meters, duration_seconds = EXCLUDED.duration_seconds, " +
+		"estimated_arrival_at = EXCLUDED.estimated_arrival_at, calculated_at = EXCLUDED.calculated_at, " +
+		"vehicle_type = EXCLUDED.vehicle_type " +
+		"WHERE order_etas.calculated_at <= EXCLUDED.calculated_at"
+	_, err := repo.client.ExecContext(
+		ctx,
+		query,
+		orderETA.OrderID,
+		orderETA.CourierID,
+		orderETA.DistanceMeters,
+		orderETA.DurationSeconds,
+		orderETA.EstimatedArrivalAt,
+		orderETA.CalculatedAt,
+		orderETA.VehicleType,
+	)
+
+	return err
+}
+
+// GetOrderETA gets latest eta of order.
+func (repo *OrderRepository) GetOrderETA(ctx context.Context, orderID string) (*domain.OrderETA, error) {
+	query := "SELECT order_id, courier_id, distance_meters, duration_seconds, estimated_arrival_at, calculated_at, vehicle_type FROM order_etas WHERE order_id = $1"
+	row := repo.client.QueryRowContext(ctx, query, orderID)
+
+	var orderETA domain.OrderETA
+	err := row.Scan(
+		&orderETA.OrderID,
+		&orderETA.CourierID,
+		&orderETA.DistanceMeters,
+		&orderETA.DurationSeconds,
+		&orderETA.EstimatedArrivalAt,
+		&orderETA.CalculatedAt,
+		&orderETA.VehicleType,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrOrderETANotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &orderETA, nil
+}
+
+func dropOffPosition(latitude sql.NullFloat64, longitude sql.NullFloat64) *domain.LocationPosition {
+	if !latitude.Valid || !longitude.Valid {
+		return nil
+	}
+
+	return &domain.LocationPosition{Latitude: latitude.Float64, Longitude: longitude.Float64}
+}
+
+// SaveCourierVehicleType saves vehicle type of courier, vehicle type of older position does not overwrite newer one.
+func (repo *OrderRepository) SaveCourierVehicleType(ctx context.Context, courierID string, vehicleType string, positionAt time.Time) error {
+	query := "INSERT INTO courier_vehicle_types (courier_id, vehicle_type, updated_at) VALUES ($1, $2, $3) " +
+		"ON CONFLICT (courier_id) DO UPDATE SET vehicle_type = EXCLUDED.vehicle_type, updated_at = EXCLUDED.updated_at " +
+		"WHERE courier_vehicle_types.updated_at <= EXCLUDED.updated_at"
+	_, err := repo.client.ExecContext(ctx, query, courierID, vehicleType, positionAt)
+
+	return err
+}
+
+// GetCourierVehicleType returns vehicle type of latest position of courier.
+func (repo *OrderRepository) GetCourierVehicleType(ctx context.Context, courierID string) (string, error) {
+	query := "SELECT vehicle_type FROM courier_vehicle_types WHERE courier_id = $1"
+	row := repo.client.QueryRowContext(ctx, query, courierID)
+
+	var vehicleType string
+	err := row.Scan(&vehicleType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", domain.ErrCourierVehicleTypeNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return vehicleType, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	coreEnv "github.com/caarlos0/env/v9"
+	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+)
+
+// config has the same env variables as services, so tool connects to the same cluster.
+type config struct {
+	KafkaAddress               string                   `env:"KAFKA_BROKERS" envDefault:"localhost:9092"`
+	KafkaSchemaRegistryAddress string                   `env:"KAFKA_SCHEMA_REGISTRY_ADDRESS" envDefault:"http://localhost:8085"`
+	KafkaSecurity              pkgkafka.SecurityOptions `envPrefix:"KAFKA_"`
+}
+
+// kafka-admin shows difference between topics spec and cluster, with -apply it creates topics,
+// adds partitions, changes config of topics and registers value schemas of topics.
+func main() {
+	specPath := flag.String("spec", "topics.json", "path to json spec of topics")
+	apply := flag.Bool("apply", false, "apply changes, without it tool only shows them")
+	skipSchemas := flag.Bool("skip-schemas", false, "don't register value schemas of topics")
+	flag.Parse()
+
+	cfg := config{}
+	if err := coreEnv.Parse(&cfg); err != nil {
+		log.Printf("failed to parse variable env: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg, *specPath, *apply, *skipSchemas); err != nil {
+		log.Printf("kafka admin failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg config, specPath string, apply bool, skipSchemas bool) error {
+	spec, err := pkgkafka.LoadTopicsSpec(specPath)
+	if err != nil {
+		return err
+	}
+
+	admin, err := pkgkafka.NewClusterAdmin([]string{cfg.KafkaAddress}, cfg.KafkaSecurity)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	topicChanges, err := pkgkafka.DiffTopics(admin, spec)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range topicChanges {
+		log.Printf("topic change: %s\n", change)
+	}
+
+	var schemaChanges []pkgkafka.SchemaChange
+	var registryClient *pkgkafka.SchemaRegistryClient
+	if !skipSchemas {
+		registryClient, err = pkgkafka.NewSchemaRegistryClientWithOptions(
+			[]string{cfg.KafkaSchemaRegistryAddress},
+			1,
+			cfg.KafkaSecurity.SchemaRegistry,
+		)
+		if err != nil {
+			return err
+		}
+
+		schemaChanges, err = pkgkafka.DiffTopicSchemas(ctx, registryClient, spec)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range schemaChanges {
+			log.Printf("schema change: %s\n", change)
+		}
+	}
+
+	if len(topicChanges) == 0 && len(schemaChanges) == 0 {
+		log.Println("cluster is up to date with spec")
+
+		return nil
+	}
+
+	if !apply {
+		log.Println("run with -apply to apply changes")
+
+		return nil
+	}
+
+	if err := pkgkafka.ApplyTopicChanges(admin, topicChanges); err != nil {
+		return err
+	}
+	log.Printf("applied %d topic changes\n", len(topicChanges))
+
+	if registryClient != nil {
+		if err := pkgkafka.ApplySchemaChanges(ctx, registryClient, schemaChanges); err != nil {
+			return err
+		}
+		log.Printf("applied %d schema changes\n", len(schemaChanges))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	coreEnv "github.com/caarlos0/env/v9"
+	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+)
+
+// config has the same env variables as services, so tool connects to the same cluster.
+type config struct {
+	KafkaAddress               string                   `env:"KAFKA_BROKERS" envDefault:"localhost:9092"`
+	KafkaSchemaRegistryAddress string                   `env:"KAFKA_SCHEMA_REGISTRY_ADDRESS" envDefault:"http://localhost:8085"`
+	KafkaSecurity              pkgkafka.SecurityOptions `envPrefix:"KAFKA_"`
+}
+
+// kafka-tail prints messages of topic as json lines with value decoded by schema registry,
+// with -replay-to it sends printed messages in another topic.
+func main() {
+	var opts options
+	var since, until string
+	flag.StringVar(&opts.topic, "topic", "", "topic which is read")
+	flag.IntVar(&opts.partition, "partition", -1, "partition which is read, -1 reads all partitions")
+	flag.StringVar(&opts.from, "from", "newest", "offset where reading starts: oldest, newest or number")
+	flag.StringVar(&since, "since", "", "RFC3339 time where reading starts, it is used instead of -from")
+	flag.StringVar(&until, "until", "", "RFC3339 time where reading stops")
+	flag.IntVar(&opts.limit, "limit", 0, "max number of messages, 0 means no limit")
+	flag.StringVar(&opts.key, "key", "", "print only messages with key")
+	flag.BoolVar(&opts.follow, "follow", false, "wait for new messages, otherwise reading stops at the end of partitions")
+	flag.StringVar(&opts.replayTo, "replay-to", "", "topic where printed messages are sent again")
+	flag.Parse()
+
+	if opts.topic == "" {
+		log.Println("topic is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	if opts.since, err = parseTime(since); err != nil {
+		log.Printf("failed to parse since: %v\n", err)
+		os.Exit(2)
+	}
+	if opts.until, err = parseTime(until); err != nil {
+		log.Printf("failed to parse until: %v\n", err)
+		os.Exit(2)
+	}
+
+	cfg := config{}
+	if err := coreEnv.Parse(&cfg); err != nil {
+		log.Printf("failed to parse variable env: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg, opts); err != nil {
+		log.Printf("kafka tail failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
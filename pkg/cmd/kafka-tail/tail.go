@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	pkgkafka "github.com/steteruk/go-delivery-service/pkg/kafka"
+)
+
+// Headers which are added to replayed message, they show where message was read.
+const (
+	replayHeaderTopic     = "replay.topic"
+	replayHeaderPartition = "replay.partition"
+	replayHeaderOffset    = "replay.offset"
+)
+
+type options struct {
+	topic     string
+	partition int
+	from      string
+	since     time.Time
+	until     time.Time
+	limit     int
+	key       string
+	follow    bool
+	replayTo  string
+}
+
+// record is printed message, value is decoded avro or raw value when it can not be decoded.
+type record struct {
+	Topic     string            `json:"topic"`
+	Partition int32             `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	Key       string            `json:"key"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Value     json.RawMessage   `json:"value,omitempty"`
+	RawValue  string            `json:"raw_value,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// endIdleTimeout is how long we wait next message before we check that end of partition was reached, the last offsets
+// of transactional topic are commit markers and aborted messages which consumer never gets.
+const endIdleTimeout = 500 * time.Millisecond
+
+// partitionRange is offset where reading of partition starts and end offset, end is -1 in follow mode.
+type partitionRange struct {
+	partition int32
+	start     int64
+	end       int64
+}
+
+func run(ctx context.Context, cfg config, opts options) error {
+	client, err := pkgkafka.NewClient([]string{cfg.KafkaAddress}, cfg.KafkaSecurity)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	registry, err := pkgkafka.NewCachedSchemaRegistryClientWithOptions([]string{cfg.KafkaSchemaRegistryAddress}, cfg.KafkaSecurity.SchemaRegistry)
+	if err != nil {
+		return err
+	}
+
+	var producer sarama.SyncProducer
+	if opts.replayTo != "" {
+		producer, err = sarama.NewSyncProducerFromClient(client)
+		if err != nil {
+			return fmt.Errorf("failed to create replay producer: %w", err)
+		}
+		defer producer.Close()
+	}
+
+	ranges, err := partitionRanges(client, opts)
+	if err != nil {
+		return err
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	messages := make(chan *sarama.ConsumerMessage)
+	var wg sync.WaitGroup
+	for _, r := range ranges {
+		partitionConsumer, err := consumer.ConsumePartition(opts.topic, r.partition, r.start)
+		if err != nil {
+			return fmt.Errorf("failed to consume partition %d: %w", r.partition, err)
+		}
+		defer partitionConsumer.Close()
+
+		wg.Add(1)
+		go func(end int64) {
+			defer wg.Done()
+			readPartition(ctx, partitionConsumer, end, opts.until, messages)
+		}(r.end)
+	}
+
+	go func() {
+		wg.Wait()
+		close(messages)
+	}()
+
+	encoder := json.NewEncoder(os.Stdout)
+	printed := 0
+	for message := range messages {
+		if opts.key != "" && string(message.Key) != opts.key {
+			continue
+		}
+
+		if err := encoder.Encode(decodeRecord(ctx, registry, message)); err != nil {
+			return fmt.Errorf("failed to print message: %w", err)
+		}
+
+		if producer != nil {
+			if err := replay(producer, opts.replayTo, message); err != nil {
+				return err
+			}
+		}
+
+		printed++
+		if opts.limit > 0 && printed >= opts.limit {
+			cancel()
+			break
+		}
+	}
+
+	if producer != nil {
+		log.Printf("replayed %d messages in %s\n", printed, opts.replayTo)
+	}
+
+	if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return nil
+}
+
+// partitionRanges finds offsets of partitions, partitions without messages in range are skipped when we don't follow topic.
+func partitionRanges(client sarama.Client, opts options) ([]partitionRange, error) {
+	partitions := []int32{int32(opts.partition)}
+	if opts.partition < 0 {
+		var err error
+		partitions, err = client.Partitions(opts.topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get partitions of topic %s: %w", opts.topic, err)
+		}
+	}
+
+	ranges := make([]partitionRange, 0, len(partitions))
+	for _, partition := range partitions {
+		newest, err := client.GetOffset(opts.topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get newest offset of partition %d: %w", partition, err)
+		}
+
+		start, err := startOffset(client, opts, partition, newest)
+		if err != nil {
+			return nil, err
+		}
+
+		end := int64(-1)
+		if !opts.follow {
+			if start >= newest {
+				continue
+			}
+			end = newest
+		}
+
+		ranges = append(ranges, partitionRange{partition: partition, start: start, end: end})
+	}
+
+	return ranges, nil
+}
+
+func startOffset(client sarama.Client, opts options, partition int32, newest int64) (int64, error) {
+	if !opts.since.IsZero() {
+		offset, err := client.GetOffset(opts.topic, partition, opts.since.UnixMilli())
+		if err != nil {
+			return 0, fmt.Errorf("failed to get offset of partition %d by time: %w", partition, err)
+		}
+		// there are no messages after time.
+		if offset < 0 {
+			return newest, nil
+		}
+
+		return offset, nil
+	}
+
+	switch opts.from {
+	case "oldest":
+		offset, err := client.GetOffset(opts.topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get oldest offset of partition %d: %w", partition, err)
+		}
+
+		return offset, nil
+	case "newest":
+		return newest, nil
+	default:
+		offset, err := strconv.ParseInt(opts.from, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("offset must be oldest, newest or number: %w", err)
+		}
+
+		return offset, nil
+	}
+}
+
+// readPartition sends messages of partition till end offset or until time, end -1 means no end.
+// Partition is finished when offset of message reaches end or when there are no messages and high water mark reached end.
+func readPartition(
+	ctx context.Context,
+	partitionConsumer sarama.PartitionConsumer,
+	end int64,
+	until time.Time,
+	messages chan<- *sarama.ConsumerMessage,
+) {
+	idle := time.NewTimer(endIdleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-idle.C:
+			if end >= 0 && partitionConsumer.HighWaterMarkOffset() >= end {
+				return
+			}
+			idle.Reset(endIdleTimeout)
+		case message, ok := <-partitionConsumer.Messages():
+			if !ok {
+				return
+			}
+
+			if !until.IsZero() && message.Timestamp.After(until) {
+				return
+			}
+
+			select {
+			case messages <- message:
+			case <-ctx.Done():
+				return
+			}
+
+			if end >= 0 && message.Offset+1 >= end {
+				return
+			}
+
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(endIdleTimeout)
+		}
+	}
+}
+
+func decodeRecord(ctx context.Context, registry pkgkafka.SchemaRegistryClientInterface, message *sarama.ConsumerMessage) record {
+	r := record{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Timestamp: message.Timestamp,
+		Key:       string(message.Key),
+	}
+
+	if len(message.Headers) > 0 {
+		r.Headers = make(map[string]string, len(message.Headers))
+		for _, header := range message.Headers {
+			if header != nil {
+				r.Headers[string(header.Key)] = string(header.Value)
+			}
+		}
+	}
+
+	value, err := pkgkafka.DecodeAvroValue(ctx, registry, message.Value)
+	if err != nil {
+		r.Error = err.Error()
+		r.RawValue = string(message.Value)
+
+		return r
+	}
+	r.Value = value
+
+	return r
+}
+
+// replay sends original key, value and headers of message in topic.
+func replay(producer sarama.SyncProducer, topic string, message *sarama.ConsumerMessage) error {
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+3)
+	for _, header := range message.Headers {
+		if header != nil {
+			headers = append(headers, *header)
+		}
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(replayHeaderTopic), Value: []byte(message.Topic)},
+		sarama.RecordHeader{Key: []byte(replayHeaderPartition), Value: []byte(strconv.Itoa(int(message.Partition)))},
+		sarama.RecordHeader{Key: []byte(replayHeaderOffset), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+	)
+
+	replayed := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+	if message.Key != nil {
+		replayed.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	if _, _, err := producer.SendMessage(replayed); err != nil {
+		return fmt.Errorf("failed to replay message partition = %d, offset = %d: %w", message.Partition, message.Offset, err)
+	}
+
+	return nil
+}
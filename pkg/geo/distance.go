@@ -0,0 +1,29 @@
+package geo
+
+import "math"
+
+// EarthRadiusMeters mean radius of the Earth.
+const EarthRadiusMeters = 6371008.8
+
+// Point coords on the Earth in degrees.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// DistanceMeters returns great-circle distance between two points using haversine formula.
+func DistanceMeters(from, to Point) float64 {
+	fromLatitude := toRadians(from.Latitude)
+	toLatitude := toRadians(to.Latitude)
+	deltaLatitude := toRadians(to.Latitude - from.Latitude)
+	deltaLongitude := toRadians(to.Longitude - from.Longitude)
+
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(fromLatitude)*math.Cos(toLatitude)*math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+
+	return 2 * EarthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
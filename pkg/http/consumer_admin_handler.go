@@ -0,0 +1,261 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	nethttp "net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+)
+
+// ConsumerAdminInterface is consumer which can be managed by admin http handler, kafka.Consumer implements it.
+type ConsumerAdminInterface interface {
+	Group() string
+	State() (*kafka.ConsumerState, error)
+	Pause(topic string, partitions []int32) error
+	Resume(topic string, partitions []int32) error
+	ResetOffsets(topic string, partitions []int32, timestamp time.Time) (map[int32]int64, error)
+}
+
+// PartitionsPayload selects partitions of topic, empty partitions means all partitions of topic.
+type PartitionsPayload struct {
+	Topic      string  `json:"topic" validate:"required"`
+	Partitions []int32 `json:"partitions"`
+}
+
+// ResetOffsetsPayload selects partitions which are read again from the first message after timestamp.
+type ResetOffsetsPayload struct {
+	PartitionsPayload
+	Timestamp time.Time `json:"timestamp" validate:"required"`
+}
+
+// ResetOffsetsResponse returns new offsets of partitions.
+type ResetOffsetsResponse struct {
+	Topic   string          `json:"topic"`
+	Offsets map[int32]int64 `json:"offsets"`
+}
+
+// ConsumerAdminHandler shows state of consumers and pauses, resumes partitions or resets their offsets.
+type ConsumerAdminHandler struct {
+	mu        sync.RWMutex
+	consumers map[string]ConsumerAdminInterface
+	handler   HandlerInterface
+}
+
+// NewConsumerAdminHandler creates admin handler of consumers, consumers are found by name of consumer group.
+func NewConsumerAdminHandler(handler HandlerInterface, consumers ...ConsumerAdminInterface) *ConsumerAdminHandler {
+	h := &ConsumerAdminHandler{consumers: make(map[string]ConsumerAdminInterface, len(consumers)), handler: handler}
+	for _, consumer := range consumers {
+		h.AddConsumer(consumer)
+	}
+
+	return h
+}
+
+// AddConsumer adds consumer to admin, consumers can be added when server is already running.
+func (h *ConsumerAdminHandler) AddConsumer(consumer ConsumerAdminInterface) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.consumers[consumer.Group()] = consumer
+}
+
+// Router returns router with admin routes. Routes change consumption and have no authentication, so service must
+// serve them on own admin port which is reachable only from internal network, not on public port of service.
+func (h *ConsumerAdminHandler) Router() *mux.Router {
+	return NewRoute(h.Routes(), mux.NewRouter())
+}
+
+// Routes returns admin routes of consumers.
+func (h *ConsumerAdminHandler) Routes() map[string]Route {
+	return map[string]Route{
+		"/admin/consumers": {
+			Handler: h.GetConsumersHandler,
+			Method:  "GET",
+		},
+		"/admin/consumers/{group}": {
+			Handler: h.GetConsumerHandler,
+			Method:  "GET",
+		},
+		"/admin/consumers/{group}/pause": {
+			Handler: h.PauseHandler,
+			Method:  "POST",
+		},
+		"/admin/consumers/{group}/resume": {
+			Handler: h.ResumeHandler,
+			Method:  "POST",
+		},
+		"/admin/consumers/{group}/offsets/reset": {
+			Handler: h.ResetOffsetsHandler,
+			Method:  "POST",
+		},
+	}
+}
+
+// GetConsumersHandler returns state of all consumers.
+func (h *ConsumerAdminHandler) GetConsumersHandler(w nethttp.ResponseWriter, r *nethttp.Request) {
+	h.mu.RLock()
+	consumers := make([]ConsumerAdminInterface, 0, len(h.consumers))
+	for _, consumer := range h.consumers {
+		consumers = append(consumers, consumer)
+	}
+	h.mu.RUnlock()
+
+	states := make([]*kafka.ConsumerState, 0, len(consumers))
+	for _, consumer := range consumers {
+		state, err := consumer.State()
+		if err != nil {
+			h.handler.FailResponse(w, err)
+
+			return
+		}
+		states = append(states, state)
+	}
+
+	h.handler.SuccessResponse(w, states, nethttp.StatusOK)
+}
+
+// GetConsumerHandler returns group, member, offsets and lag of partitions of consumer.
+func (h *ConsumerAdminHandler) GetConsumerHandler(w nethttp.ResponseWriter, r *nethttp.Request) {
+	consumer, ok := h.consumer(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := consumer.State()
+	if err != nil {
+		h.handler.FailResponse(w, err)
+
+		return
+	}
+
+	h.handler.SuccessResponse(w, state, nethttp.StatusOK)
+}
+
+// PauseHandler pauses partitions of topic.
+func (h *ConsumerAdminHandler) PauseHandler(w nethttp.ResponseWriter, r *nethttp.Request) {
+	h.changePartitions(w, r, func(consumer ConsumerAdminInterface, payload PartitionsPayload) error {
+		return consumer.Pause(payload.Topic, payload.Partitions)
+	})
+}
+
+// ResumeHandler resumes paused partitions of topic.
+func (h *ConsumerAdminHandler) ResumeHandler(w nethttp.ResponseWriter, r *nethttp.Request) {
+	h.changePartitions(w, r, func(consumer ConsumerAdminInterface, payload PartitionsPayload) error {
+		return consumer.Resume(payload.Topic, payload.Partitions)
+	})
+}
+
+// ResetOffsetsHandler resets offsets of partitions to timestamp, so consumer reads messages again.
+func (h *ConsumerAdminHandler) ResetOffsetsHandler(w nethttp.ResponseWriter, r *nethttp.Request) {
+	consumer, ok := h.consumer(w, r)
+	if !ok {
+		return
+	}
+
+	var payload ResetOffsetsPayload
+	if !h.decodePayload(w, r, &payload) {
+		return
+	}
+
+	offsets, err := consumer.ResetOffsets(payload.Topic, payload.Partitions, payload.Timestamp)
+	if err != nil {
+		h.failAdminResponse(w, err)
+
+		return
+	}
+
+	h.handler.SuccessResponse(w, ResetOffsetsResponse{Topic: payload.Topic, Offsets: offsets}, nethttp.StatusOK)
+}
+
+func (h *ConsumerAdminHandler) changePartitions(
+	w nethttp.ResponseWriter,
+	r *nethttp.Request,
+	change func(consumer ConsumerAdminInterface, payload PartitionsPayload) error,
+) {
+	consumer, ok := h.consumer(w, r)
+	if !ok {
+		return
+	}
+
+	var payload PartitionsPayload
+	if !h.decodePayload(w, r, &payload) {
+		return
+	}
+
+	if err := change(consumer, payload); err != nil {
+		h.failAdminResponse(w, err)
+
+		return
+	}
+
+	state, err := consumer.State()
+	if err != nil {
+		h.handler.FailResponse(w, err)
+
+		return
+	}
+
+	h.handler.SuccessResponse(w, state, nethttp.StatusOK)
+}
+
+func (h *ConsumerAdminHandler) consumer(w nethttp.ResponseWriter, r *nethttp.Request) (ConsumerAdminInterface, bool) {
+	group := mux.Vars(r)["group"]
+	h.mu.RLock()
+	consumer, ok := h.consumers[group]
+	h.mu.RUnlock()
+	if !ok {
+		writeResponseMessage(w, fmt.Sprintf("consumer group %s not found", group), nethttp.StatusNotFound)
+
+		return nil, false
+	}
+
+	return consumer, true
+}
+
+func (h *ConsumerAdminHandler) decodePayload(w nethttp.ResponseWriter, r *nethttp.Request, payload any) bool {
+	if err := h.handler.DecodePayloadFromJson(r, payload); err != nil {
+		h.handler.FailResponse(w, err)
+
+		return false
+	}
+
+	if err := h.handler.ValidatePayload(payload); err != nil {
+		h.handler.FailResponse(w, err)
+
+		return false
+	}
+
+	return true
+}
+
+// failAdminResponse returns bad request when consumer does not read topic and conflict when consumer
+// does not claim partition.
+func (h *ConsumerAdminHandler) failAdminResponse(w nethttp.ResponseWriter, err error) {
+	if errors.Is(err, kafka.ErrUnknownTopic) {
+		writeResponseMessage(w, err.Error(), nethttp.StatusBadRequest)
+
+		return
+	}
+
+	if errors.Is(err, kafka.ErrPartitionNotClaimed) {
+		writeResponseMessage(w, err.Error(), nethttp.StatusConflict)
+
+		return
+	}
+
+	h.handler.FailResponse(w, err)
+}
+
+func writeResponseMessage(w nethttp.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(&ResponseMessage{Status: "Error", Message: message}); err != nil {
+		log.Printf("failed to encode json response: %v\n", err)
+	}
+}
@@ -0,0 +1,154 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pkghttp "github.com/steteruk/go-delivery-service/pkg/http"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+const testEventSchema = `{"type": "object", "properties": {"id": {"type": "string"}}}`
+
+type testEvent struct {
+	ID string `json:"id"`
+}
+
+// receivingHandler sends id of every handled event in channel.
+type receivingHandler struct {
+	ids chan string
+}
+
+func (h receivingHandler) HandleMessage(_ context.Context, event testEvent) error {
+	h.ids <- event.ID
+
+	return nil
+}
+
+func newTestConsumer(t *testing.T, handler receivingHandler) (*kafka.Consumer, *kafkatest.Broker) {
+	t.Helper()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	serde := kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema)
+
+	publisher := kafka.NewPublisherWithProducer(broker.NewAsyncProducer(), registry, "in")
+	publisher.SetSync(true)
+	defer publisher.Close()
+	if err := kafka.NewSerdePublisher[testEvent](publisher, serde).PublishMessage(context.Background(), testEvent{ID: "order-1"}, []byte("order-1")); err != nil {
+		t.Fatalf("failed to publish event: %v", err)
+	}
+
+	consumer, err := kafka.NewSerdeConsumer(kafka.NewSerdeHandler[testEvent](serde, handler), kafka.ConsumerOptions{
+		Topic:                "in",
+		ConsumerGroup:        broker.NewConsumerGroup("in"),
+		SchemaRegistryClient: registry,
+	})
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	consumer.SetOffsetsClient(broker, "in")
+
+	return consumer, broker
+}
+
+func postAdmin(t *testing.T, admin *pkghttp.ConsumerAdminHandler, url string, payload string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := httptest.NewRequest(nethttp.MethodPost, url, strings.NewReader(payload))
+	recorder := httptest.NewRecorder()
+	admin.Router().ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestPauseHandlerPausesPartitionOfConsumer(t *testing.T) {
+	consumer, _ := newTestConsumer(t, receivingHandler{ids: make(chan string, 1)})
+	admin := pkghttp.NewConsumerAdminHandler(pkghttp.NewHandler(), consumer)
+
+	response := postAdmin(t, admin, "/admin/consumers/in/pause", `{"topic": "in", "partitions": [0]}`)
+	if response.Code != nethttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", response.Code, response.Body)
+	}
+
+	state, err := consumer.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(state.Partitions) != 1 || !state.Partitions[0].Paused {
+		t.Fatalf("expected paused partition 0, got %+v", state.Partitions)
+	}
+
+	response = postAdmin(t, admin, "/admin/consumers/in/resume", `{"topic": "in"}`)
+	if response.Code != nethttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", response.Code, response.Body)
+	}
+
+	if state, err = consumer.State(); err != nil || state.Partitions[0].Paused {
+		t.Fatalf("expected resumed partition 0, got %+v: %v", state, err)
+	}
+}
+
+func TestResetOffsetsHandlerReplaysPartition(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	handler := receivingHandler{ids: make(chan string, 2)}
+	consumer, broker := newTestConsumer(t, handler)
+	admin := pkghttp.NewConsumerAdminHandler(pkghttp.NewHandler(), consumer)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- consumer.ConsumeMessage(ctx)
+	}()
+
+	<-handler.ids
+	if err := broker.WaitCommittedOffset(ctx, "in", "in", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	response := postAdmin(t, admin, "/admin/consumers/in/offsets/reset", `{"topic": "in", "timestamp": "`+timestamp+`"}`)
+	if response.Code != nethttp.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", response.Code, response.Body)
+	}
+
+	var reset pkghttp.ResetOffsetsResponse
+	if err := json.NewDecoder(response.Body).Decode(&reset); err != nil {
+		t.Fatal(err)
+	}
+
+	if offset, ok := reset.Offsets[0]; !ok || offset != 0 {
+		t.Fatalf("expected reset of partition 0 to offset 0, got %v", reset.Offsets)
+	}
+
+	select {
+	case id := <-handler.ids:
+		if id != "order-1" {
+			t.Fatalf("expected replay of order-1, got %s", id)
+		}
+	case <-ctx.Done():
+		t.Fatal("partition was not replayed after reset")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestResetOffsetsHandlerRejectsNotClaimedPartition(t *testing.T) {
+	consumer, _ := newTestConsumer(t, receivingHandler{ids: make(chan string, 1)})
+	admin := pkghttp.NewConsumerAdminHandler(pkghttp.NewHandler(), consumer)
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	response := postAdmin(t, admin, "/admin/consumers/in/offsets/reset", `{"topic": "in", "partitions": [0], "timestamp": "`+timestamp+`"}`)
+	if response.Code != nethttp.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", response.Code, response.Body)
+	}
+}
@@ -0,0 +1,26 @@
+package http
+
+import (
+	nethttp "net/http"
+
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// CorrelationIDHeader is http header with correlation id of request, it is returned in response too.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// CorrelationMiddleware puts W3C trace context and correlation id of request in context of handler,
+// so kafka messages which handler sends have the same trace and correlation id. Request without correlation id gets new one.
+func CorrelationMiddleware(next nethttp.Handler) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		ctx := propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		correlationID := r.Header.Get(CorrelationIDHeader)
+		if correlationID == "" {
+			correlationID = kafka.NewCorrelationID(ctx)
+		}
+
+		w.Header().Set(CorrelationIDHeader, correlationID)
+		next.ServeHTTP(w, r.WithContext(kafka.WithCorrelationID(ctx, correlationID)))
+	})
+}
@@ -17,6 +17,9 @@ var ErrDecodeFailed = errors.New("failed to decode payload")
 // ErrValidatePayloadFailed throws this error when we have invalid payload.
 var ErrValidatePayloadFailed = errors.New("failed to validated payload")
 
+// ErrNotFound is wrapped by handler when requested resource does not exist.
+var ErrNotFound = errors.New("not found")
+
 // ResponseMessage returns when we have bad request, or we have problem on server.
 type ResponseMessage struct {
 	Status  string `json:"status"`
@@ -109,6 +112,17 @@ func (h *Handler) FailResponse(w nethttp.ResponseWriter, errFailResponse error)
 
 		w.WriteHeader(nethttp.StatusBadRequest)
 
+	case errors.Is(errFailResponse, ErrNotFound):
+		w.WriteHeader(nethttp.StatusNotFound)
+		err := json.NewEncoder(w).Encode(&ResponseMessage{
+			Status:  "Error",
+			Message: errFailResponse.Error(),
+		})
+
+		if err != nil {
+			log.Printf("failed to encode json response: %v\n", err)
+		}
+
 	default:
 		log.Printf("Server error: %v\n", errFailResponse)
 		w.WriteHeader(nethttp.StatusInternalServerError)
@@ -0,0 +1,13 @@
+package http
+
+import (
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsRoute returns route of prometheus metrics, for example metrics of kafka consumers and publishers.
+func MetricsRoute() Route {
+	return Route{
+		Handler: promhttp.Handler().ServeHTTP,
+		Method:  "GET",
+	}
+}
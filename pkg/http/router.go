@@ -12,8 +12,9 @@ type Route struct {
 	Method  string
 }
 
-// NewRoute creates for handling different path routes.
+// NewRoute creates for handling different path routes, handlers get correlation id of request in context.
 func NewRoute(routes map[string]Route, router *mux.Router) *mux.Router {
+	router.Use(CorrelationMiddleware)
 	for url, route := range routes {
 		router.HandleFunc(url, route.Handler).Methods(route.Method)
 	}
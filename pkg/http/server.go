@@ -10,11 +10,11 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// ServerRun serves router on port till ctx is done, every server has own router, so service can run several servers.
 func ServerRun(ctx context.Context, locationRouter *mux.Router, port string) {
-	http.Handle("/", locationRouter)
 	srv := &http.Server{
 		Addr:    port,
-		Handler: nil,
+		Handler: locationRouter,
 	}
 	go func() {
 		if err := srv.ListenAndServe(); err != nil {
@@ -0,0 +1,151 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/actgardner/gogen-avro/v10/compiler"
+	"github.com/actgardner/gogen-avro/v10/vm"
+	"github.com/actgardner/gogen-avro/v10/vm/types"
+	"github.com/linkedin/goavro"
+)
+
+// ErrIncompatibleAvroSchema returns when schema of producer can not be resolved to schema of consumer.
+var ErrIncompatibleAvroSchema = errors.New("writer schema is incompatible with reader schema")
+
+// AvroMessageHandler Handler message in binary avro format, writerCodec is codec of schema which producer used.
+type AvroMessageHandler interface {
+	HandleAvroMessage(ctx context.Context, writerCodec *goavro.Codec, message []byte) error
+}
+
+// AvroRecord is struct generated by gogen-avro.
+type AvroRecord interface {
+	Serialize(w io.Writer) error
+	Schema() string
+}
+
+// Handler handles messages of type T, for example generated avro struct or protobuf message.
+type Handler[T any] interface {
+	HandleMessage(ctx context.Context, message T) error
+}
+
+// AvroHandler deserializes binary avro directly in generated struct T and calls typed handler,
+// PT is pointer of generated struct, gogen-avro vm fills struct through it.
+// Message is resolved from writer schema of producer to reader schema of T by avro rules: fields which reader
+// does not know are skipped and missed fields get default value, so producer and consumer can be updated separately.
+type AvroHandler[T AvroRecord, PT interface {
+	*T
+	types.Field
+}] struct {
+	handler Handler[T]
+	// programs keeps compiled program for every writer schema.
+	programs sync.Map
+}
+
+// NewAvroHandler creates handler for Consumer, usually only T is specified: NewAvroHandler[avro.OrderMessage](handler).
+func NewAvroHandler[T AvroRecord, PT interface {
+	*T
+	types.Field
+}](handler Handler[T]) *AvroHandler[T, PT] {
+	return &AvroHandler[T, PT]{handler: handler}
+}
+
+// HandleAvroMessage deserializes message and calls handler, message which we can not deserialize is invalid.
+func (h *AvroHandler[T, PT]) HandleAvroMessage(ctx context.Context, writerCodec *goavro.Codec, message []byte) error {
+	program, err := h.getProgram(writerCodec)
+	if err != nil {
+		return err
+	}
+
+	var record T
+	if err := vm.Eval(bytes.NewReader(message), program, PT(&record)); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidAvroMessage, err)
+	}
+
+	return h.handler.HandleMessage(ctx, record)
+}
+
+// getProgram compiles program only once for writer schema, generated Deserialize functions compile it for every message.
+// Without writer codec message is read by schema of T.
+func (h *AvroHandler[T, PT]) getProgram(writerCodec *goavro.Codec) (*vm.Program, error) {
+	var record T
+
+	return avroProgram(&h.programs, writerCodec, record.Schema())
+}
+
+// avroProgram returns program from programs of writer schema or compiles it.
+func avroProgram(programs *sync.Map, writerCodec *goavro.Codec, readerSchema string) (*vm.Program, error) {
+	writerSchema := readerSchema
+	if writerCodec != nil {
+		writerSchema = writerCodec.Schema()
+	}
+
+	if program, ok := programs.Load(writerSchema); ok {
+		return program.(*vm.Program), nil
+	}
+
+	program, err := compiler.CompileSchemaBytes([]byte(writerSchema), []byte(readerSchema))
+	if err != nil {
+		// message with such schema never can be read, so it is invalid for consumer.
+		return nil, fmt.Errorf("%w: %w: %w", ErrInvalidAvroMessage, ErrIncompatibleAvroSchema, err)
+	}
+
+	programs.Store(writerSchema, program)
+
+	return program, nil
+}
+
+// AvroPublisher sends generated avro struct T in kafka, struct is serialized in binary avro without json.
+type AvroPublisher[T AvroRecord] struct {
+	publisher *Publisher
+	codec     *goavro.Codec
+	buffers   sync.Pool
+}
+
+// NewAvroPublisher creates typed publisher, schema of T is registered in subject of publisher topic.
+func NewAvroPublisher[T AvroRecord](publisher *Publisher) (*AvroPublisher[T], error) {
+	var record T
+	codec, err := goavro.NewCodec(record.Schema())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create avro codec: %w", err)
+	}
+
+	return &AvroPublisher[T]{
+		publisher: publisher,
+		codec:     codec,
+		buffers: sync.Pool{New: func() any {
+			return new(bytes.Buffer)
+		}},
+	}, nil
+}
+
+// PublishMessage sends message in kafka, in sync mode it waits acknowledgement of broker.
+func (p *AvroPublisher[T]) PublishMessage(ctx context.Context, message T, key []byte) error {
+	return p.PublishMessageWithCallback(ctx, message, key, nil)
+}
+
+// PublishMessageWithCallback sends message in kafka and calls callback when broker acknowledges message or sending fails.
+func (p *AvroPublisher[T]) PublishMessageWithCallback(ctx context.Context, message T, key []byte, callback DeliveryCallback) error {
+	schemaId, err := p.publisher.GetSchemaId(ctx, p.publisher.topic, p.codec)
+	if err != nil {
+		return err
+	}
+
+	buffer := p.buffers.Get().(*bytes.Buffer)
+	buffer.Reset()
+	defer p.buffers.Put(buffer)
+
+	if err := message.Serialize(buffer); err != nil {
+		return fmt.Errorf("failed to serialize avro message: %w", err)
+	}
+
+	// producer encodes message later in other goroutine, so it gets own copy of buffer.
+	content := make([]byte, buffer.Len())
+	copy(content, buffer.Bytes())
+
+	return p.publisher.publishAvro(ctx, schemaId, content, key, callback)
+}
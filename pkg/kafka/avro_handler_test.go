@@ -0,0 +1,299 @@
+package kafka_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/linkedin/goavro"
+	"github.com/steteruk/go-delivery-service/avro/v1"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+const benchmarkTopic = "latest_position_courier.v1"
+
+type courierLocationHandler struct {
+	handled int
+}
+
+func (h *courierLocationHandler) HandleMessage(_ context.Context, message avro.LatestCourierLocationMessage) error {
+	if message.Courier_id != "" {
+		h.handled++
+	}
+
+	return nil
+}
+
+// courierLocationValue returns latest courier location in Confluent wire format with codec of writer schema.
+func courierLocationValue(b *testing.B, registry *kafkatest.SchemaRegistry) (*goavro.Codec, []byte) {
+	b.Helper()
+
+	message := avro.NewLatestCourierLocationMessage()
+	message.Courier_id = "4bd2a5b6-8f5c-4d0e-9c7a-2b1a6e0d9f11"
+	message.Latitude = 50.4501
+	message.Longitude = 30.5234
+	message.Created_at = time.Now().UnixMilli()
+	message.Vehicle_type = "bicycle"
+
+	codec, err := goavro.NewCodec(message.Schema())
+	if err != nil {
+		b.Fatalf("failed to create codec: %v", err)
+	}
+
+	schemaID, err := registry.CreateSubject(context.Background(), benchmarkTopic+"-value", codec)
+	if err != nil {
+		b.Fatalf("failed to register schema: %v", err)
+	}
+
+	var content bytes.Buffer
+	if err := message.Serialize(&content); err != nil {
+		b.Fatalf("failed to serialize message: %v", err)
+	}
+
+	value := make([]byte, 5, 5+content.Len())
+	binary.BigEndian.PutUint32(value[1:5], uint32(schemaID))
+
+	return codec, append(value, content.Bytes()...)
+}
+
+// BenchmarkAvroHandler decodes binary avro directly in generated struct.
+func BenchmarkAvroHandler(b *testing.B) {
+	ctx := context.Background()
+	registry := kafkatest.NewSchemaRegistry()
+	codec, value := courierLocationValue(b, registry)
+	content := value[5:]
+	handler := kafka.NewAvroHandler[avro.LatestCourierLocationMessage](&courierLocationHandler{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := handler.HandleAvroMessage(ctx, codec, content); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkProcessAvroMsg decodes binary avro in textual json and unmarshals json in generated struct as json handlers do.
+func BenchmarkProcessAvroMsg(b *testing.B) {
+	ctx := context.Background()
+	registry := kafkatest.NewSchemaRegistry()
+	_, value := courierLocationValue(b, registry)
+	consumer, err := kafka.NewConsumer(nil, fakeConsumerOptions(kafkatest.NewBroker(1), registry, benchmarkTopic))
+	if err != nil {
+		b.Fatalf("failed to create consumer: %v", err)
+	}
+	message := &sarama.ConsumerMessage{Topic: benchmarkTopic, Value: value}
+	handler := &courierLocationHandler{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		textual, err := consumer.ProcessAvroMsg(ctx, message)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var record avro.LatestCourierLocationMessage
+		if err := json.Unmarshal(textual, &record); err != nil {
+			b.Fatal(err)
+		}
+
+		if err := handler.HandleMessage(ctx, record); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// lastCourierLocationHandler keeps last handled message.
+type lastCourierLocationHandler struct {
+	message avro.LatestCourierLocationMessage
+}
+
+func (h *lastCourierLocationHandler) HandleMessage(_ context.Context, message avro.LatestCourierLocationMessage) error {
+	h.message = message
+
+	return nil
+}
+
+// writerCodec returns codec of schema which producer used and binary avro of record by this schema.
+func writerCodec(t *testing.T, schema string, record map[string]any) (*goavro.Codec, []byte) {
+	t.Helper()
+
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		t.Fatalf("failed to create codec: %v", err)
+	}
+
+	content, err := codec.BinaryFromNative(nil, record)
+	if err != nil {
+		t.Fatalf("failed to encode record: %v", err)
+	}
+
+	return codec, content
+}
+
+func TestAvroHandlerSkipsFieldOfWriterWhichReaderDoesNotKnow(t *testing.T) {
+	codec, content := writerCodec(t, `{
+		"type": "record",
+		"name": "LatestCourierLocationMessage",
+		"fields": [
+			{"name": "courier_id", "type": "string"},
+			{"name": "latitude", "type": "double"},
+			{"name": "speed_kmh", "type": "double"},
+			{"name": "longitude", "type": "double"},
+			{"name": "created_at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "vehicle_type", "type": "string", "default": ""},
+			{"name": "is_implausible", "type": "boolean", "default": false}
+		]
+	}`, map[string]any{
+		"courier_id":     "courier-1",
+		"latitude":       50.45,
+		"speed_kmh":      14.5,
+		"longitude":      30.52,
+		"created_at":     int64(1_700_000_000_000),
+		"vehicle_type":   "scooter",
+		"is_implausible": true,
+	})
+
+	handler := &lastCourierLocationHandler{}
+	if err := kafka.NewAvroHandler[avro.LatestCourierLocationMessage](handler).HandleAvroMessage(context.Background(), codec, content); err != nil {
+		t.Fatal(err)
+	}
+
+	message := handler.message
+	if message.Courier_id != "courier-1" || message.Latitude != 50.45 || message.Longitude != 30.52 ||
+		message.Created_at != 1_700_000_000_000 || message.Vehicle_type != "scooter" || !message.Is_implausible {
+		t.Fatalf("unexpected message %+v", message)
+	}
+}
+
+func TestAvroHandlerSetsDefaultOfFieldWhichWriterDoesNotHave(t *testing.T) {
+	codec, content := writerCodec(t, `{
+		"type": "record",
+		"name": "LatestCourierLocationMessage",
+		"fields": [
+			{"name": "courier_id", "type": "string"},
+			{"name": "latitude", "type": "double"},
+			{"name": "longitude", "type": "double"},
+			{"name": "created_at", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+		]
+	}`, map[string]any{
+		"courier_id": "courier-1",
+		"latitude":   50.45,
+		"longitude":  30.52,
+		"created_at": int64(1_700_000_000_000),
+	})
+
+	handler := &lastCourierLocationHandler{}
+	avroHandler := kafka.NewAvroHandler[avro.LatestCourierLocationMessage](handler)
+	// the second message uses compiled program of writer schema.
+	for i := 0; i < 2; i++ {
+		if err := avroHandler.HandleAvroMessage(context.Background(), codec, content); err != nil {
+			t.Fatal(err)
+		}
+
+		message := handler.message
+		if message.Courier_id != "courier-1" || message.Longitude != 30.52 || message.Vehicle_type != "" || message.Is_implausible {
+			t.Fatalf("expected message with default vehicle type and plausible position, got %+v", message)
+		}
+	}
+}
+
+func TestAvroHandlerRejectsIncompatibleWriterSchema(t *testing.T) {
+	codec, content := writerCodec(t, `{
+		"type": "record",
+		"name": "LatestCourierLocationMessage",
+		"fields": [{"name": "courier_id", "type": "long"}]
+	}`, map[string]any{"courier_id": int64(1)})
+
+	err := kafka.NewAvroHandler[avro.LatestCourierLocationMessage](&lastCourierLocationHandler{}).HandleAvroMessage(context.Background(), codec, content)
+	if !errors.Is(err, kafka.ErrIncompatibleAvroSchema) || !errors.Is(err, kafka.ErrInvalidAvroMessage) {
+		t.Fatalf("expected error of incompatible schema, got %v", err)
+	}
+}
+
+// courierLocationReceiver sends every handled message in channel.
+type courierLocationReceiver struct {
+	messages chan avro.LatestCourierLocationMessage
+}
+
+func (h courierLocationReceiver) HandleMessage(_ context.Context, message avro.LatestCourierLocationMessage) error {
+	h.messages <- message
+
+	return nil
+}
+
+func TestAvroPublisherMessageIsHandledByAvroHandler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	publisher := kafka.NewPublisherWithProducer(broker.NewAsyncProducer(), registry, benchmarkTopic)
+	publisher.SetSync(true)
+	defer publisher.Close()
+
+	avroPublisher, err := kafka.NewAvroPublisher[avro.LatestCourierLocationMessage](publisher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := avro.NewLatestCourierLocationMessage()
+	sent.Courier_id = "4bd2a5b6-8f5c-4d0e-9c7a-2b1a6e0d9f11"
+	sent.Latitude = 50.4501
+	sent.Longitude = 30.5234
+	sent.Created_at = 1_700_000_000_000
+	sent.Vehicle_type = "bicycle"
+	if err := avroPublisher.PublishMessage(ctx, sent, []byte(sent.Courier_id)); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := courierLocationReceiver{messages: make(chan avro.LatestCourierLocationMessage, 1)}
+	consumer, err := kafka.NewAvroConsumer(
+		kafka.NewAvroHandler[avro.LatestCourierLocationMessage](handler),
+		fakeConsumerOptions(broker, registry, benchmarkTopic),
+	)
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	done := runConsumer(ctx, t, consumer)
+
+	select {
+	case received := <-handler.messages:
+		if received != sent {
+			t.Fatalf("expected message %+v, got %+v", sent, received)
+		}
+	case <-ctx.Done():
+		t.Fatal("message was not handled")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestAvroHandlerReturnsErrorOfInvalidMessage(t *testing.T) {
+	codec, content := writerCodec(t, avro.NewLatestCourierLocationMessage().Schema(), map[string]any{
+		"courier_id":     "courier-1",
+		"latitude":       50.45,
+		"longitude":      30.52,
+		"created_at":     int64(1_700_000_000_000),
+		"vehicle_type":   "scooter",
+		"is_implausible": false,
+	})
+
+	handler := &courierLocationHandler{}
+	err := kafka.NewAvroHandler[avro.LatestCourierLocationMessage](handler).HandleAvroMessage(context.Background(), codec, content[:len(content)-4])
+	if !errors.Is(err, kafka.ErrInvalidAvroMessage) {
+		t.Fatalf("expected error of invalid message, got %v", err)
+	}
+
+	if handler.handled != 0 {
+		t.Fatal("expected invalid message skipped by handler")
+	}
+}
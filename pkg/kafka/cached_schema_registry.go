@@ -1,101 +1,325 @@
 package kafka
 
 import (
+	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/linkedin/goavro"
 )
 
+const (
+	// defaultLatestSchemaTTL is how long latest version of subject is cached, new version is visible after it.
+	defaultLatestSchemaTTL = time.Minute
+	// defaultMissingSchemaTTL is how long unknown schema id is cached, so broken messages don't call registry every time.
+	defaultMissingSchemaTTL = 30 * time.Second
+)
+
+type subjectVersionKey struct {
+	subject string
+	version int
+}
+
+// schemaIdKey is schema in subject, the same schema has own id and version in every subject.
+type schemaIdKey struct {
+	subject    string
+	schemaType string
+	schema     string
+}
+
+func newSchemaIdKey(subject string, schema Schema) schemaIdKey {
+	schemaType := schema.SchemaType
+	if schemaType == "" {
+		schemaType = SchemaTypeAvro
+	}
+
+	return schemaIdKey{subject: subject, schemaType: schemaType, schema: schema.Schema}
+}
+
+// expiringCodec is codec of latest version of subject which is cached till expiresAt.
+type expiringCodec struct {
+	codec     *goavro.Codec
+	expiresAt time.Time
+}
+
+// expiringError is error of registry for unknown schema id which is cached till expiresAt.
+type expiringError struct {
+	err       error
+	expiresAt time.Time
+}
+
 // CachedSchemaRegistryClient is a schema registry client that will cache some data to improve performance.
+// Schemas by id and by version never change, so they are cached forever, latest versions of subjects
+// and unknown schema ids are cached for some time.
 type CachedSchemaRegistryClient struct {
 	SchemaRegistryClient *SchemaRegistryClient
 	schemaCache          map[int]*goavro.Codec
+	missingSchemaCache   map[int]expiringError
+	rawSchemaCache       map[int]*Schema
 	schemaCacheLock      sync.RWMutex
-	schemaIdCache        map[string]int
+	schemaIdCache        map[schemaIdKey]int
 	schemaIdCacheLock    sync.RWMutex
+	versionCache         map[subjectVersionKey]*goavro.Codec
+	latestCache          map[string]expiringCodec
+	versionCacheLock     sync.RWMutex
+	latestSchemaTTL      time.Duration
+	missingSchemaTTL     time.Duration
 }
 
 // NewCachedSchemaRegistryClient creates SchemaRegistryClient with cache.
 func NewCachedSchemaRegistryClient(connect []string) *CachedSchemaRegistryClient {
-	SchemaRegistryClient := NewSchemaRegistryClient(connect)
-	return &CachedSchemaRegistryClient{SchemaRegistryClient: SchemaRegistryClient, schemaCache: make(map[int]*goavro.Codec), schemaIdCache: make(map[string]int)}
+	return newCachedSchemaRegistryClient(NewSchemaRegistryClient(connect))
 }
 
 func NewCachedSchemaRegistryClientWithRetries(connect []string, retries int) *CachedSchemaRegistryClient {
-	SchemaRegistryClient := NewSchemaRegistryClientWithRetries(connect, retries)
-	return &CachedSchemaRegistryClient{SchemaRegistryClient: SchemaRegistryClient, schemaCache: make(map[int]*goavro.Codec), schemaIdCache: make(map[string]int)}
+	return newCachedSchemaRegistryClient(NewSchemaRegistryClientWithRetries(connect, retries))
+}
+
+// NewCachedSchemaRegistryClientWithOptions creates SchemaRegistryClient with cache, TLS and authentication of options.
+func NewCachedSchemaRegistryClientWithOptions(connect []string, options SchemaRegistryOptions) (*CachedSchemaRegistryClient, error) {
+	SchemaRegistryClient, err := NewSchemaRegistryClientWithOptions(connect, len(connect), options)
+	if err != nil {
+		return nil, err
+	}
+	return newCachedSchemaRegistryClient(SchemaRegistryClient), nil
+}
+
+func newCachedSchemaRegistryClient(SchemaRegistryClient *SchemaRegistryClient) *CachedSchemaRegistryClient {
+	return &CachedSchemaRegistryClient{
+		SchemaRegistryClient: SchemaRegistryClient,
+		schemaCache:          make(map[int]*goavro.Codec),
+		missingSchemaCache:   make(map[int]expiringError),
+		rawSchemaCache:       make(map[int]*Schema),
+		schemaIdCache:        make(map[schemaIdKey]int),
+		versionCache:         make(map[subjectVersionKey]*goavro.Codec),
+		latestCache:          make(map[string]expiringCodec),
+		latestSchemaTTL:      defaultLatestSchemaTTL,
+		missingSchemaTTL:     defaultMissingSchemaTTL,
+	}
 }
 
-// GetSchema will return and cache the codec with the given id.
-func (client *CachedSchemaRegistryClient) GetSchema(id int) (*goavro.Codec, error) {
+// SetLatestSchemaTTL changes how long latest version of subject is cached, zero disables cache of latest versions.
+func (client *CachedSchemaRegistryClient) SetLatestSchemaTTL(ttl time.Duration) {
+	client.versionCacheLock.Lock()
+	client.latestSchemaTTL = ttl
+	client.versionCacheLock.Unlock()
+}
+
+// SetMissingSchemaTTL changes how long unknown schema id is cached, zero disables negative cache.
+func (client *CachedSchemaRegistryClient) SetMissingSchemaTTL(ttl time.Duration) {
+	client.schemaCacheLock.Lock()
+	client.missingSchemaTTL = ttl
+	client.schemaCacheLock.Unlock()
+}
+
+// GetSchema will return and cache the codec with the given id, unknown id is cached for missing schema ttl.
+func (client *CachedSchemaRegistryClient) GetSchema(ctx context.Context, id int) (*goavro.Codec, error) {
 	client.schemaCacheLock.RLock()
 	cachedResult := client.schemaCache[id]
+	missing, isMissing := client.missingSchemaCache[id]
+	ttl := client.missingSchemaTTL
 	client.schemaCacheLock.RUnlock()
+	isMissing = isMissing && ttl > 0 && time.Now().Before(missing.expiresAt)
+	observeSchemaCache("schema", nil != cachedResult || isMissing)
 	if nil != cachedResult {
 		return cachedResult, nil
 	}
-	codec, err := client.SchemaRegistryClient.GetSchema(id)
+	if isMissing {
+		return nil, missing.err
+	}
+	codec, err := client.SchemaRegistryClient.GetSchema(ctx, id)
+	var registryErr *Error
+	if errors.As(err, &registryErr) && registryErr.ErrorCode == schemaNotFoundErrorCode {
+		if ttl > 0 {
+			client.schemaCacheLock.Lock()
+			client.missingSchemaCache[id] = expiringError{err: err, expiresAt: time.Now().Add(ttl)}
+			client.schemaCacheLock.Unlock()
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 	client.schemaCacheLock.Lock()
 	client.schemaCache[id] = codec
+	delete(client.missingSchemaCache, id)
 	client.schemaCacheLock.Unlock()
 	return codec, nil
 }
 
 // GetSubjects returns a list of subjects.
-func (client *CachedSchemaRegistryClient) GetSubjects() ([]string, error) {
-	return client.SchemaRegistryClient.GetSubjects()
+func (client *CachedSchemaRegistryClient) GetSubjects(ctx context.Context) ([]string, error) {
+	return client.SchemaRegistryClient.GetSubjects(ctx)
 }
 
 // GetVersions returns a list of all versions of a subject.
-func (client *CachedSchemaRegistryClient) GetVersions(subject string) ([]int, error) {
-	return client.SchemaRegistryClient.GetVersions(subject)
+func (client *CachedSchemaRegistryClient) GetVersions(ctx context.Context, subject string) ([]int, error) {
+	return client.SchemaRegistryClient.GetVersions(ctx, subject)
 }
 
-// GetSchemaByVersion returns the codec for a specific version of a subject.
-func (client *CachedSchemaRegistryClient) GetSchemaByVersion(subject string, version int) (*goavro.Codec, error) {
-	return client.SchemaRegistryClient.GetSchemaByVersion(subject, version)
+// GetSchemaByVersion returns and caches the codec for a specific version of a subject.
+func (client *CachedSchemaRegistryClient) GetSchemaByVersion(ctx context.Context, subject string, version int) (*goavro.Codec, error) {
+	key := subjectVersionKey{subject: subject, version: version}
+	client.versionCacheLock.RLock()
+	cachedResult := client.versionCache[key]
+	client.versionCacheLock.RUnlock()
+	observeSchemaCache("version", nil != cachedResult)
+	if nil != cachedResult {
+		return cachedResult, nil
+	}
+	codec, err := client.SchemaRegistryClient.GetSchemaByVersion(ctx, subject, version)
+	if err != nil {
+		return nil, err
+	}
+	client.versionCacheLock.Lock()
+	client.versionCache[key] = codec
+	client.versionCacheLock.Unlock()
+	return codec, nil
 }
 
-// GetLatestSchema returns the highest version schema for a subject.
-func (client *CachedSchemaRegistryClient) GetLatestSchema(subject string) (*goavro.Codec, error) {
-	return client.SchemaRegistryClient.GetLatestSchema(subject)
+// GetLatestSchema returns the highest version schema for a subject, it is cached for latest schema ttl.
+func (client *CachedSchemaRegistryClient) GetLatestSchema(ctx context.Context, subject string) (*goavro.Codec, error) {
+	client.versionCacheLock.RLock()
+	cachedResult, found := client.latestCache[subject]
+	ttl := client.latestSchemaTTL
+	client.versionCacheLock.RUnlock()
+	found = found && ttl > 0 && time.Now().Before(cachedResult.expiresAt)
+	observeSchemaCache("latest", found)
+	if found {
+		return cachedResult.codec, nil
+	}
+	codec, err := client.SchemaRegistryClient.GetLatestSchema(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		client.versionCacheLock.Lock()
+		client.latestCache[subject] = expiringCodec{codec: codec, expiresAt: time.Now().Add(ttl)}
+		client.versionCacheLock.Unlock()
+	}
+	return codec, nil
 }
 
-// CreateSubject will return and cache the id with the given codec.
-func (client *CachedSchemaRegistryClient) CreateSubject(subject string, codec *goavro.Codec) (int, error) {
-	schemaJson := codec.Schema()
+// CreateSubject will return and cache the id with the given codec in subject.
+func (client *CachedSchemaRegistryClient) CreateSubject(ctx context.Context, subject string, codec *goavro.Codec) (int, error) {
+	key := newSchemaIdKey(subject, Schema{Schema: codec.Schema()})
 	client.schemaIdCacheLock.RLock()
-	cachedResult, found := client.schemaIdCache[schemaJson]
+	cachedResult, found := client.schemaIdCache[key]
 	client.schemaIdCacheLock.RUnlock()
+	observeSchemaCache("schema_id", found)
 	if found {
 		return cachedResult, nil
 	}
-	id, err := client.SchemaRegistryClient.CreateSubject(subject, codec)
+	id, err := client.SchemaRegistryClient.CreateSubject(ctx, subject, codec)
 
 	if err != nil {
 		return 0, err
 	}
 
 	client.schemaIdCacheLock.Lock()
-	client.schemaIdCache[schemaJson] = id
+	client.schemaIdCache[key] = id
 	client.schemaIdCacheLock.Unlock()
+	// schema can be new latest version of subject.
+	client.invalidateSubject(subject, false)
 	return id, nil
 }
 
 // IsSchemaRegistered checks if a specific codec is already registered to a subject.
-func (client *CachedSchemaRegistryClient) IsSchemaRegistered(subject string, codec *goavro.Codec) (int, error) {
-	return client.SchemaRegistryClient.IsSchemaRegistered(subject, codec)
+func (client *CachedSchemaRegistryClient) IsSchemaRegistered(ctx context.Context, subject string, codec *goavro.Codec) (int, error) {
+	return client.SchemaRegistryClient.IsSchemaRegistered(ctx, subject, codec)
 }
 
 // DeleteSubject deletes the subject, should only be used in development.
-func (client *CachedSchemaRegistryClient) DeleteSubject(subject string) error {
-	return client.SchemaRegistryClient.DeleteSubject(subject)
+func (client *CachedSchemaRegistryClient) DeleteSubject(ctx context.Context, subject string) error {
+	defer client.invalidateSubject(subject, true)
+	return client.SchemaRegistryClient.DeleteSubject(ctx, subject)
 }
 
 // DeleteVersion deletes the specific version of a subject, should only be used in development.
-func (client *CachedSchemaRegistryClient) DeleteVersion(subject string, version int) error {
-	return client.SchemaRegistryClient.DeleteVersion(subject, version)
+func (client *CachedSchemaRegistryClient) DeleteVersion(ctx context.Context, subject string, version int) error {
+	defer client.invalidateSubject(subject, true)
+	return client.SchemaRegistryClient.DeleteVersion(ctx, subject, version)
+}
+
+// TestCompatibility checks schema against latest version of subject.
+func (client *CachedSchemaRegistryClient) TestCompatibility(ctx context.Context, subject string, codec *goavro.Codec) (*CompatibilityResult, error) {
+	return client.SchemaRegistryClient.TestCompatibility(ctx, subject, codec)
+}
+
+// GetCompatibilityLevel returns compatibility level of subject.
+func (client *CachedSchemaRegistryClient) GetCompatibilityLevel(ctx context.Context, subject string) (string, error) {
+	return client.SchemaRegistryClient.GetCompatibilityLevel(ctx, subject)
+}
+
+// SetCompatibilityLevel changes compatibility level of subject.
+func (client *CachedSchemaRegistryClient) SetCompatibilityLevel(ctx context.Context, subject string, level string) error {
+	return client.SchemaRegistryClient.SetCompatibilityLevel(ctx, subject, level)
+}
+
+// RegisterSchema will return and cache the id of schema of any type in subject.
+func (client *CachedSchemaRegistryClient) RegisterSchema(ctx context.Context, subject string, schema Schema) (int, error) {
+	key := newSchemaIdKey(subject, schema)
+	client.schemaIdCacheLock.RLock()
+	cachedResult, found := client.schemaIdCache[key]
+	client.schemaIdCacheLock.RUnlock()
+	observeSchemaCache("schema_id", found)
+	if found {
+		return cachedResult, nil
+	}
+	id, err := client.SchemaRegistryClient.RegisterSchema(ctx, subject, schema)
+	if err != nil {
+		return 0, err
+	}
+
+	client.schemaIdCacheLock.Lock()
+	client.schemaIdCache[key] = id
+	client.schemaIdCacheLock.Unlock()
+	client.invalidateSubject(subject, false)
+	return id, nil
+}
+
+// GetRawSchema will return and cache schema of any type with the given id.
+func (client *CachedSchemaRegistryClient) GetRawSchema(ctx context.Context, id int) (*Schema, error) {
+	client.schemaCacheLock.RLock()
+	cachedResult := client.rawSchemaCache[id]
+	client.schemaCacheLock.RUnlock()
+	observeSchemaCache("raw_schema", nil != cachedResult)
+	if nil != cachedResult {
+		return cachedResult, nil
+	}
+	schema, err := client.SchemaRegistryClient.GetRawSchema(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	client.schemaCacheLock.Lock()
+	client.rawSchemaCache[id] = schema
+	client.schemaCacheLock.Unlock()
+	return schema, nil
+}
+
+// invalidateSubject removes latest version of subject from cache, with versions it removes all cached versions
+// and ids of registered schemas of subject too.
+func (client *CachedSchemaRegistryClient) invalidateSubject(subject string, withVersions bool) {
+	client.versionCacheLock.Lock()
+	defer client.versionCacheLock.Unlock()
+
+	delete(client.latestCache, subject)
+	if !withVersions {
+		return
+	}
+
+	for key := range client.versionCache {
+		if key.subject == subject {
+			delete(client.versionCache, key)
+		}
+	}
+
+	client.schemaIdCacheLock.Lock()
+	defer client.schemaIdCacheLock.Unlock()
+
+	for key := range client.schemaIdCache {
+		if key.subject == subject {
+			delete(client.schemaIdCache, key)
+		}
+	}
 }
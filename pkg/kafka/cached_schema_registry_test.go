@@ -0,0 +1,42 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/linkedin/goavro"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+func TestCachedSchemaRegistryClientRegistersSchemaInEverySubject(t *testing.T) {
+	ctx := context.Background()
+	registry := kafkatest.NewSchemaRegistry()
+	server := kafkatest.NewSchemaRegistryServer(registry)
+	defer server.Close()
+
+	client := kafka.NewCachedSchemaRegistryClient([]string{server.URL})
+	codec, err := goavro.NewCodec(topicSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CreateSubject(ctx, "orders.v1-value", codec); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CreateSubject(ctx, "orders.v2-value", codec); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.RegisterSchema(ctx, "orders.v3-value", kafka.Schema{Schema: codec.Schema()}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, subject := range []string{"orders.v1-value", "orders.v2-value", "orders.v3-value"} {
+		versions, err := registry.GetVersions(ctx, subject)
+		if err != nil || len(versions) != 1 {
+			t.Fatalf("expected schema registered in subject %s, got versions %v: %v", subject, versions, err)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package kafka
 
 import (
 	"context"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/linkedin/goavro"
@@ -12,10 +11,20 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/IBM/sarama"
 )
 
+// ErrInvalidMessage returns when message is not in Confluent wire format or serde can not deserialize it,
+// consumer sends such message in dead letter topic without retries.
+var ErrInvalidMessage = errors.New("message is not valid")
+
+// ErrInvalidAvroMessage returns when binary avro can not be read, it is ErrInvalidMessage too.
+var ErrInvalidAvroMessage = fmt.Errorf("%w: invalid avro", ErrInvalidMessage)
+
+var errMessageChannelClosed = errors.New("message channel was closed")
+
 // JSONMessageHandler Handler jso message that sending in kafka.
 type JSONMessageHandler interface {
 	HandleJSONMessage(ctx context.Context, message []byte) error
@@ -23,25 +32,121 @@ type JSONMessageHandler interface {
 
 // Consumer represents a Sarama consumer group consumer.
 type Consumer struct {
-	keepRunning          bool
-	topic                string
-	jsonMessageHandler   JSONMessageHandler
-	ready                chan bool
-	consumerGroup        sarama.ConsumerGroup
-	schemaRegistryClient *CachedSchemaRegistryClient
-}
-
-// NewConsumer Create new Consumer with specify consumer group.
-func NewConsumer(
-	jsonMessageHandler JSONMessageHandler,
-	brokers string,
-	verbose bool,
-	oldest bool,
-	assignor string,
-	topic string,
-	kafkaSchemaRegistryAddress []string,
-) (*Consumer, error) {
-	if verbose {
+	topic                  string
+	jsonMessageHandler     JSONMessageHandler
+	avroMessageHandler     AvroMessageHandler
+	valueHandler           ValueHandler
+	consumerGroup          sarama.ConsumerGroup
+	schemaRegistryClient   SchemaRegistryClientInterface
+	deadLetterPublisher    DeadLetterPublisherInterface
+	retryPolicy            RetryPolicy
+	reconnectPolicy        RetryPolicy
+	keyWorkers             int
+	group                  string
+	client                 sarama.Client
+	offsetsClient          OffsetsClientInterface
+	transactionalProducers TransactionalProducerFactory
+	lagRefreshInterval     time.Duration
+	verbose                bool
+
+	mu            sync.Mutex
+	session       sarama.ConsumerGroupSession
+	cancelSession context.CancelFunc
+	paused        map[string]map[int32]bool
+	pausedAll     bool
+	claimErr      error
+	offsetResets  map[string]map[int32]int64
+}
+
+// ConsumerOptions describes topic and consumer group of Consumer and how it connects to kafka and schema registry.
+type ConsumerOptions struct {
+	// Brokers is comma separated addresses of kafka brokers.
+	Brokers               string
+	SchemaRegistryAddress []string
+	Topic                 string
+	// Group is name of consumer group, empty means topic. Own group is needed when some services consume the same topic.
+	Group string
+	// Assignor is sticky, roundrobin or range.
+	Assignor string
+	// Oldest reads partition from the oldest offset when consumer group has no committed offset.
+	Oldest bool
+	// Verbose enables logs of sarama and log of every claimed message.
+	Verbose bool
+	// Serde deserializes messages for NewTypedConsumer, for example NewMessageSerde[*pb.Location](protobufSerde).
+	Serde MessageSerde
+	// ReadCommitted skips messages of aborted transactions and waits till transaction is committed,
+	// it is needed when topic is written by transactional producer, for example exactly-once consumer.
+	ReadCommitted bool
+	// Security enables TLS and authentication of kafka and schema registry.
+	Security SecurityOptions
+	// ConsumerGroup and SchemaRegistryClient are used instead of connecting to brokers and schema registry
+	// when they are set, for example fakes of kafkatest.
+	ConsumerGroup        sarama.ConsumerGroup
+	SchemaRegistryClient SchemaRegistryClientInterface
+}
+
+// group returns name of consumer group.
+func (options ConsumerOptions) group() string {
+	if options.Group == "" {
+		return options.Topic
+	}
+
+	return options.Group
+}
+
+// NewConsumer Create new Consumer which gives value of avro message in json to handler.
+func NewConsumer(jsonMessageHandler JSONMessageHandler, options ConsumerOptions) (*Consumer, error) {
+	consumer, err := newConsumer(options)
+	if err != nil {
+		return nil, err
+	}
+	consumer.jsonMessageHandler = jsonMessageHandler
+
+	return consumer, nil
+}
+
+// NewAvroConsumer Create new Consumer which gives binary avro message to handler without converting it in json.
+func NewAvroConsumer(avroMessageHandler AvroMessageHandler, options ConsumerOptions) (*Consumer, error) {
+	consumer, err := newConsumer(options)
+	if err != nil {
+		return nil, err
+	}
+	consumer.avroMessageHandler = avroMessageHandler
+
+	return consumer, nil
+}
+
+// NewSerdeConsumer Create new Consumer of messages which value handler decodes by serde of topic, for example protobuf.
+func NewSerdeConsumer(valueHandler ValueHandler, options ConsumerOptions) (*Consumer, error) {
+	consumer, err := newConsumer(options)
+	if err != nil {
+		return nil, err
+	}
+	consumer.valueHandler = valueHandler
+
+	return consumer, nil
+}
+
+// NewTypedConsumer Create new Consumer which deserializes messages by Serde of options and gives them to typed handler,
+// so format of topic is chosen by options and not by handler.
+func NewTypedConsumer[T any](handler Handler[T], options ConsumerOptions) (*Consumer, error) {
+	if options.Serde == nil {
+		return nil, ErrSerdeNotSet
+	}
+
+	return NewSerdeConsumer(&messageSerdeHandler[T]{serde: options.Serde, handler: handler}, options)
+}
+
+func newConsumer(options ConsumerOptions) (*Consumer, error) {
+	if options.ConsumerGroup != nil {
+		consumer := newConsumerFromConsumerGroup(options.ConsumerGroup, options.Topic, options.SchemaRegistryClient)
+		consumer.group = options.group()
+		consumer.verbose = options.Verbose
+
+		return consumer, nil
+	}
+
+	if options.Verbose {
 		sarama.Logger = log.New(os.Stdout, "[sarama] ", log.LstdFlags)
 	}
 
@@ -51,7 +156,7 @@ func NewConsumer(
 	 */
 	config := sarama.NewConfig()
 
-	switch assignor {
+	switch options.Assignor {
 	case "sticky":
 		config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
 	case "roundrobin":
@@ -59,125 +164,277 @@ func NewConsumer(
 	case "range":
 		config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
 	default:
-		err := fmt.Errorf("unrecognized consumer group partition assignor: %s", assignor)
+		err := fmt.Errorf("unrecognized consumer group partition assignor: %s", options.Assignor)
+
+		return nil, err
+	}
 
+	config.Consumer.Return.Errors = true
+	if options.ReadCommitted {
+		config.Consumer.IsolationLevel = sarama.ReadCommitted
+	}
+	if err := options.Security.apply(config); err != nil {
 		return nil, err
 	}
 
-	if oldest {
+	if options.Oldest {
 		config.Consumer.Offsets.Initial = sarama.OffsetOldest
 	}
 
-	consumerGroup, err := sarama.NewConsumerGroup(strings.Split(brokers, ","), topic, config)
+	schemaRegistryClient := options.SchemaRegistryClient
+	if schemaRegistryClient == nil {
+		var err error
+		schemaRegistryClient, err = NewCachedSchemaRegistryClientWithOptions(options.SchemaRegistryAddress, options.Security.SchemaRegistry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := sarama.NewClient(strings.Split(options.Brokers, ","), config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create courier location consumer: %w", err)
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
 	}
-	schemaRegistryClient := NewCachedSchemaRegistryClient(kafkaSchemaRegistryAddress)
 
+	consumerGroup, err := sarama.NewConsumerGroupFromClient(options.group(), client)
+	if err != nil {
+		_ = client.Close()
+
+		return nil, fmt.Errorf("failed to create consumer group %s: %w", options.group(), err)
+	}
+
+	consumer := newConsumerFromConsumerGroup(consumerGroup, options.Topic, schemaRegistryClient)
+	consumer.client = client
+	consumer.verbose = options.Verbose
+	consumer.SetOffsetsClient(NewOffsetsClient(client), options.group())
+
+	return consumer, nil
+}
+
+func newConsumerFromConsumerGroup(consumerGroup sarama.ConsumerGroup, topic string, schemaRegistryClient SchemaRegistryClientInterface) *Consumer {
 	return &Consumer{
 		consumerGroup:        consumerGroup,
-		keepRunning:          true,
-		ready:                make(chan bool),
 		topic:                topic,
-		jsonMessageHandler:   jsonMessageHandler,
 		schemaRegistryClient: schemaRegistryClient,
-	}, nil
+		retryPolicy:          RetryPolicy{MaxAttempts: 1},
+		reconnectPolicy:      RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 30 * time.Second},
+		group:                topic,
+		lagRefreshInterval:   defaultLagRefreshInterval,
+		paused:               make(map[string]map[int32]bool),
+		offsetResets:         make(map[string]map[int32]int64),
+	}
+}
+
+// SetDeadLetterPublisher enables dead letter topic, message goes there when retries are over or when we can not decode it.
+func (consumer *Consumer) SetDeadLetterPublisher(deadLetterPublisher DeadLetterPublisherInterface) {
+	consumer.deadLetterPublisher = deadLetterPublisher
+}
+
+// SetRetryPolicy sets how consumer retries failed messages. Delayed retry topics need dead letter publisher,
+// because the same producer sends messages in retry topics, so SetDeadLetterPublisher must be called before.
+func (consumer *Consumer) SetRetryPolicy(retryPolicy RetryPolicy) error {
+	if retryPolicy.MaxAttempts < 1 {
+		retryPolicy.MaxAttempts = 1
+	}
+
+	if err := retryPolicy.validateRetryTopicDelays(); err != nil {
+		return err
+	}
+
+	if len(retryPolicy.RetryTopicDelays) > 0 && consumer.deadLetterPublisher == nil {
+		return ErrRetryTopicsWithoutDeadLetter
+	}
+
+	consumer.retryPolicy = retryPolicy
+
+	return nil
+}
+
+// SetReconnectPolicy sets backoff between attempts to consume again when session fails, for example broker is lost.
+// Zero MaxAttempts means that consumer reconnects till ctx is cancelled.
+func (consumer *Consumer) SetReconnectPolicy(reconnectPolicy RetryPolicy) {
+	consumer.reconnectPolicy = reconnectPolicy
+}
+
+// SetKeyWorkers enables concurrent handling of partition by workers, messages with the same key go to the same worker
+// and keep their order. Offset is committed only up to the lowest message which is not handled yet.
+// Zero workers means that partition is handled message by message.
+func (consumer *Consumer) SetKeyWorkers(keyWorkers int) {
+	consumer.keyWorkers = keyWorkers
+}
+
+// topics returns topics and their retry topics which consumer reads.
+func (consumer *Consumer) topics() []string {
+	topics := strings.Split(consumer.topic, ",")
+	if consumer.deadLetterPublisher == nil {
+		return topics
+	}
+
+	for _, topic := range strings.Split(consumer.topic, ",") {
+		topics = append(topics, consumer.retryPolicy.RetryTopics(topic)...)
+	}
+
+	return topics
 }
 
-// ConsumeMessage Consume message in format json from kafka for specific consumer group.
+// ConsumeMessage consumes messages till ctx is cancelled, caller handles signals and cancels ctx for shutdown.
+// When session fails, for example broker is not available, consumer connects again after backoff of reconnect policy.
+// Marked offsets are committed before consumer group is closed.
 func (consumer *Consumer) ConsumeMessage(ctx context.Context) error {
-	consumptionIsPaused := false
 	ctx, cancel := context.WithCancel(ctx)
-	waitGroup := &sync.WaitGroup{}
-	waitGroup.Add(1)
-
-	go func() {
-		defer waitGroup.Done()
-		for {
-			// `Consume` should be called inside an infinite loop, when a
-			// server-side rebalance happens, the consumer session will need to be
-			// recreated to get the new claims
-			if err := consumer.consumerGroup.Consume(ctx, strings.Split(consumer.topic, ","), consumer); err != nil {
-				log.Panicf("Error from consumer: %v", err)
-			}
+	defer cancel()
 
-			// check if context was cancelled, signaling that the consumer should stop
-			if ctx.Err() != nil {
-				log.Panicf("Error from consumer: %v", ctx.Err())
-				return
-			}
+	go consumer.logErrors()
+	go consumer.toggleConsumptionOnSignal(ctx)
+	go consumer.refreshLag(ctx)
+
+	err := consumer.consume(ctx)
+	if closeErr := consumer.consumerGroup.Close(); closeErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to close consumer group: %w", closeErr))
+	}
 
-			consumer.ready = make(chan bool)
+	if consumer.client != nil {
+		if closeErr := consumer.client.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to close kafka client: %w", closeErr))
 		}
-	}()
+	}
 
-	<-consumer.ready // Await till the consumer has been set up
-	log.Println("Sarama consumer up and running!...")
+	return err
+}
 
-	sigusr1 := make(chan os.Signal, 1)
-	signal.Notify(sigusr1, syscall.SIGUSR1)
+func (consumer *Consumer) consume(ctx context.Context) error {
+	attempt := 0
+	for {
+		// `Consume` should be called inside an infinite loop, when a
+		// server-side rebalance happens, the consumer session will need to be
+		// recreated to get the new claims
+		err := consumer.consumeSession(ctx)
 
-	sigterm := make(chan os.Signal, 1)
-	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
+		// check if context was cancelled, signaling that the consumer should stop
+		if ctx.Err() != nil {
+			log.Println("terminating: context cancelled")
 
-	for consumer.keepRunning {
-		select {
-		case <-ctx.Done():
+			return nil
+		}
+
+		if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			return fmt.Errorf("failed to consume: %w", err)
+		}
+
+		if err == nil {
+			attempt = 0
+
+			continue
+		}
+
+		attempt++
+		if consumer.reconnectPolicy.MaxAttempts > 0 && attempt > consumer.reconnectPolicy.MaxAttempts {
+			return fmt.Errorf("failed to consume after %d reconnects: %w", consumer.reconnectPolicy.MaxAttempts, err)
+		}
+
+		backoff := consumer.reconnectPolicy.Backoff(attempt)
+		log.Printf("failed to consume, reconnect in %v: %v\n", backoff, err)
+		if err := sleep(ctx, backoff); err != nil {
 			log.Println("terminating: context cancelled")
 
-			consumer.keepRunning = false
-		case <-sigterm:
-			log.Println("terminating: via signal")
+			return nil
+		}
+	}
+}
+
+// toggleConsumptionOnSignal pauses all partitions on SIGUSR1 and resumes them on the next SIGUSR1.
+func (consumer *Consumer) toggleConsumptionOnSignal(ctx context.Context) {
+	sigusr1 := make(chan os.Signal, 1)
+	signal.Notify(sigusr1, syscall.SIGUSR1)
+	defer signal.Stop(sigusr1)
 
-			consumer.keepRunning = false
+	for {
+		select {
 		case <-sigusr1:
-			toggleConsumptionFlow(consumer.consumerGroup, &consumptionIsPaused)
+			consumer.mu.Lock()
+			pausedAll := consumer.pausedAll
+			consumer.mu.Unlock()
+
+			if pausedAll {
+				consumer.ResumeAll()
+			} else {
+				consumer.PauseAll()
+			}
+		case <-ctx.Done():
+			return
 		}
 	}
-	cancel()
-	waitGroup.Wait()
+}
 
-	if err := consumer.consumerGroup.Close(); err != nil {
-		return fmt.Errorf("Error closing client: %w", err)
+// logErrors logs errors of sessions, for example when ConsumeClaim returns error, till consumer group is closed.
+func (consumer *Consumer) logErrors() {
+	for err := range consumer.consumerGroup.Errors() {
+		log.Printf("Error from consumer: %v\n", err)
 	}
-
-	return nil
 }
 
 // Setup is run at the beginning of a new session, before ConsumeClaim.
-func (consumer *Consumer) Setup(sarama.ConsumerGroupSession) error {
-	// Mark the consumer as ready
-	close(consumer.ready)
+func (consumer *Consumer) Setup(session sarama.ConsumerGroupSession) error {
+	log.Printf("Sarama consumer up and running!... claims = %v\n", session.Claims())
+	consumer.startSession(session)
+
 	return nil
 }
 
 // Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited.
-func (consumer *Consumer) Cleanup(sarama.ConsumerGroupSession) error {
+// It commits marked offsets, so after shutdown or rebalance next consumer does not read handled messages again.
+func (consumer *Consumer) Cleanup(session sarama.ConsumerGroupSession) error {
+	session.Commit()
+	consumer.endSession()
+	consumer.deleteLag(session.Claims())
+
 	return nil
 }
 
 // ConsumeClaim must start a consumer loop of ConsumerGroupClaim's Messages().
+// Error of claim ends session, so consumer reconnects after backoff of reconnect policy.
 func (consumer *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	err := consumer.consumeClaim(session, claim)
+	if err != nil && !errors.Is(err, errMessageChannelClosed) {
+		consumer.mu.Lock()
+		consumer.claimErr = err
+		consumer.mu.Unlock()
+	}
+
+	return err
+}
+
+func (consumer *Consumer) consumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	// partition of new session is not paused by consumer group, so pause it again.
+	if consumer.isPaused(claim.Topic(), claim.Partition()) {
+		consumer.consumerGroup.Pause(map[string][]int32{claim.Topic(): {claim.Partition()}})
+	}
+
+	if consumer.transactionalProducers != nil {
+		return consumer.consumeClaimInTransactions(session, claim)
+	}
+
+	if consumer.keyWorkers > 0 {
+		return consumer.consumeClaimByKeys(session, claim)
+	}
+
 	for {
 		select {
 		case message, ok := <-claim.Messages():
 			if !ok {
-				return errors.New("message channel was closed")
+				return errMessageChannelClosed
 			}
 
-			log.Printf("Message claimed: value = %s, timestamp = %v, topic = %s", string(message.Value), message.Timestamp, message.Topic)
-			messageAvro, err := consumer.ProcessAvroMsg(message)
-			if err != nil {
-				return errors.New("message is not valid")
-			}
-
-			err = consumer.jsonMessageHandler.HandleJSONMessage(session.Context(), messageAvro)
+			consumer.logClaimedMessage(message)
+			if err := consumer.handleMessage(session.Context(), nil, message); err != nil {
+				if session.Context().Err() != nil {
+					return nil
+				}
 
-			if err != nil {
 				return err
 			}
 
 			session.MarkMessage(message, "")
+			consumer.observeLag(claim, message.Offset+1)
 
 		case <-session.Context().Done():
 			return nil
@@ -185,38 +442,201 @@ func (consumer *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 	}
 }
 
-func toggleConsumptionFlow(client sarama.ConsumerGroup, isPaused *bool) {
-	if *isPaused {
-		client.ResumeAll()
-		log.Println("Resuming consumption")
-	} else {
-		client.PauseAll()
-		log.Println("Pausing consumption")
+// handleMessage decodes message and calls handler, without dead letter topic it returns error when attempts are over,
+// otherwise it sends message in next retry topic or dead letter topic, so partition keeps moving.
+// With transaction every attempt and sending in retry or dead letter topic commit offset of message in own transaction.
+func (consumer *Consumer) handleMessage(ctx context.Context, txn *transaction, message *sarama.ConsumerMessage) (err error) {
+	ctx, span := consumer.startConsumeSpan(ctx, message)
+	defer func() {
+		endConsumeSpan(span, err)
+	}()
+
+	if err := consumer.waitRetryDelay(ctx, message); err != nil {
+		return err
+	}
+
+	consumedMessages.WithLabelValues(consumer.group, message.Topic).Inc()
+	handle, err := consumer.messageHandle(ctx, message)
+	if errors.Is(err, ErrInvalidMessage) {
+		decodeFailures.WithLabelValues(consumer.group, message.Topic).Inc()
+
+		return consumer.sendDeadLetter(ctx, txn, message, err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err = consumer.attempt(ctx, txn, message, handle)
+		consumer.observeHandler(message.Topic, start, err)
+		if err == nil {
+			return nil
+		}
+
+		if errors.Is(err, ErrInvalidMessage) {
+			decodeFailures.WithLabelValues(consumer.group, message.Topic).Inc()
+
+			return consumer.sendDeadLetter(ctx, txn, message, err)
+		}
+
+		if attempt >= consumer.retryPolicy.MaxAttempts {
+			break
+		}
+
+		backoff := consumer.retryPolicy.Backoff(attempt)
+		log.Printf("failed to handle message, attempt %d of %d, retry in %v: %v\n", attempt, consumer.retryPolicy.MaxAttempts, backoff, err)
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+	}
+
+	retryTopic, ok := consumer.retryPolicy.nextRetryTopic(message.Topic)
+	if consumer.deadLetterPublisher != nil && ok {
+		log.Printf("send message topic = %s, partition = %d, offset = %d in retry topic %s: %v\n", message.Topic, message.Partition, message.Offset, retryTopic, err)
+
+		if txn != nil {
+			return txn.republish(ctx, message, retryTopic, err)
+		}
+
+		return consumer.deadLetterPublisher.PublishRetry(message, retryTopic, err)
+	}
+
+	return consumer.sendDeadLetter(ctx, txn, message, err)
+}
+
+// attempt calls handler, in transaction messages of failed attempt are aborted.
+func (consumer *Consumer) attempt(
+	ctx context.Context,
+	txn *transaction,
+	message *sarama.ConsumerMessage,
+	handle func(ctx context.Context) error,
+) error {
+	if txn != nil {
+		return txn.run(ctx, message, handle)
 	}
 
-	*isPaused = !*isPaused
+	return handle(ctx)
+}
+
+// messageHandle decodes message for handler of consumer and returns function which calls handler.
+func (consumer *Consumer) messageHandle(ctx context.Context, message *sarama.ConsumerMessage) (func(ctx context.Context) error, error) {
+	if consumer.valueHandler != nil {
+		return consumer.valueHandler.DecodeValue(ctx, message.Topic, message.Value)
+	}
+
+	if consumer.avroMessageHandler != nil {
+		writerCodec, content, err := consumer.decodeAvroMsg(ctx, message)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(ctx context.Context) error {
+			return consumer.avroMessageHandler.HandleAvroMessage(ctx, writerCodec, content)
+		}, nil
+	}
+
+	messageAvro, err := consumer.ProcessAvroMsg(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		return consumer.jsonMessageHandler.HandleJSONMessage(ctx, messageAvro)
+	}, nil
+}
+
+// logClaimedMessage logs position of message in verbose mode, value is not logged, because it can have personal data.
+func (consumer *Consumer) logClaimedMessage(message *sarama.ConsumerMessage) {
+	if !consumer.verbose {
+		return
+	}
+
+	log.Printf(
+		"Message claimed: topic = %s, partition = %d, offset = %d, timestamp = %v, correlation_id = %s\n",
+		message.Topic,
+		message.Partition,
+		message.Offset,
+		message.Timestamp,
+		headerValue(message, CorrelationIDHeader),
+	)
+}
+
+// waitRetryDelay waits till delay of retry topic is over, it blocks only partition of retry topic.
+func (consumer *Consumer) waitRetryDelay(ctx context.Context, message *sarama.ConsumerMessage) error {
+	_, stage := consumer.retryPolicy.retryStage(message.Topic)
+	if stage < 0 {
+		return nil
+	}
+
+	delay := time.Until(message.Timestamp.Add(consumer.retryPolicy.RetryTopicDelays[stage]))
+
+	return sleep(ctx, delay)
+}
+
+func (consumer *Consumer) sendDeadLetter(ctx context.Context, txn *transaction, message *sarama.ConsumerMessage, cause error) error {
+	if consumer.deadLetterPublisher == nil {
+		return cause
+	}
+
+	log.Printf("send message topic = %s, partition = %d, offset = %d in dead letter topic: %v\n", message.Topic, message.Partition, message.Offset, cause)
+
+	if txn != nil {
+		return txn.republish(ctx, message, originalTopic(message)+DeadLetterTopicSuffix, cause)
+	}
+
+	return consumer.deadLetterPublisher.PublishDeadLetter(message, cause)
 }
 
 // GetSchema get schema  from schema-registry service
-func (consumer *Consumer) GetSchema(id int) (*goavro.Codec, error) {
-	codec, err := consumer.schemaRegistryClient.GetSchema(id)
+func (consumer *Consumer) GetSchema(ctx context.Context, id int) (*goavro.Codec, error) {
+	codec, err := consumer.schemaRegistryClient.GetSchema(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return codec, nil
 }
 
+// decodeAvroMsg checks wire format of value and returns codec of writer schema and binary avro content.
+func (consumer *Consumer) decodeAvroMsg(ctx context.Context, m *sarama.ConsumerMessage) (*goavro.Codec, []byte, error) {
+	return decodeAvroValue(ctx, consumer.schemaRegistryClient, m.Value)
+}
+
 // ProcessAvroMsg decode value and prepare for unmarshal
-func (consumer *Consumer) ProcessAvroMsg(m *sarama.ConsumerMessage) ([]byte, error) {
-	schemaId := binary.BigEndian.Uint32(m.Value[1:5])
-	codec, err := consumer.GetSchema(int(schemaId))
+func (consumer *Consumer) ProcessAvroMsg(ctx context.Context, m *sarama.ConsumerMessage) ([]byte, error) {
+	return DecodeAvroValue(ctx, consumer.schemaRegistryClient, m.Value)
+}
+
+// decodeAvroValue checks Confluent wire format of value and returns codec of writer schema and binary avro content.
+func decodeAvroValue(ctx context.Context, client SchemaRegistryClientInterface, value []byte) (*goavro.Codec, []byte, error) {
+	schemaId, content, err := decodeWireFormat(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	codec, err := client.GetSchema(ctx, schemaId)
+	if isSchemaNotFound(err) {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidAvroMessage, err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return codec, content, nil
+}
+
+// DecodeAvroValue decodes value in Confluent wire format by writer schema from registry and returns textual avro,
+// tools use it to show messages of topic.
+func DecodeAvroValue(ctx context.Context, client SchemaRegistryClientInterface, value []byte) ([]byte, error) {
+	codec, content, err := decodeAvroValue(ctx, client, value)
 	if err != nil {
 		return nil, err
 	}
 	// Convert binary Avro data back to native Go form
-	native, _, err := codec.NativeFromBinary(m.Value[5:])
+	native, _, err := codec.NativeFromBinary(content)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAvroMessage, err)
 	}
 
 	// Convert native Go form to textual Avro data
@@ -225,6 +645,5 @@ func (consumer *Consumer) ProcessAvroMsg(m *sarama.ConsumerMessage) ([]byte, err
 	if err != nil {
 		return nil, err
 	}
-	msg := textual
-	return msg, nil
+	return textual, nil
 }
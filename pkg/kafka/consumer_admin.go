@@ -0,0 +1,409 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrOffsetsClientNotSet returns when consumer can not read offsets of partitions, see SetOffsetsClient.
+var ErrOffsetsClientNotSet = errors.New("offsets client is not set")
+
+// ErrUnknownTopic returns when admin request has topic which consumer does not read.
+var ErrUnknownTopic = errors.New("consumer does not read topic")
+
+// ErrPartitionNotClaimed returns when offset of partition is reset by consumer which does not claim partition,
+// request must be sent to consumer which claims it, see State.
+var ErrPartitionNotClaimed = errors.New("consumer does not claim partition")
+
+// OffsetsClientInterface reads offsets of partitions and committed offsets of consumer group.
+type OffsetsClientInterface interface {
+	Partitions(topic string) ([]int32, error)
+	// GetOffset returns offset of the first message with timestamp in milliseconds greater or equal time,
+	// time can be sarama.OffsetNewest or sarama.OffsetOldest.
+	GetOffset(topic string, partition int32, time int64) (int64, error)
+	// FetchOffset returns committed offset of group, -1 when group did not commit offset.
+	FetchOffset(group string, topic string, partition int32) (int64, error)
+}
+
+// PartitionState describes partition of topic which consumer group reads.
+type PartitionState struct {
+	Topic           string `json:"topic"`
+	Partition       int32  `json:"partition"`
+	CommittedOffset int64  `json:"committed_offset"`
+	HighWaterMark   int64  `json:"high_water_mark"`
+	Lag             int64  `json:"lag"`
+	Claimed         bool   `json:"claimed"`
+	Paused          bool   `json:"paused"`
+}
+
+// ConsumerState describes consumer group and partitions, claimed partitions are read by this consumer.
+type ConsumerState struct {
+	Group        string           `json:"group"`
+	MemberID     string           `json:"member_id"`
+	GenerationID int32            `json:"generation_id"`
+	Partitions   []PartitionState `json:"partitions"`
+}
+
+type offsetsClient struct {
+	client sarama.Client
+}
+
+// NewOffsetsClient creates offsets client which reads offsets by brokers of client.
+func NewOffsetsClient(client sarama.Client) OffsetsClientInterface {
+	return &offsetsClient{client: client}
+}
+
+func (c *offsetsClient) Partitions(topic string) ([]int32, error) {
+	return c.client.Partitions(topic)
+}
+
+func (c *offsetsClient) GetOffset(topic string, partition int32, time int64) (int64, error) {
+	return c.client.GetOffset(topic, partition, time)
+}
+
+func (c *offsetsClient) FetchOffset(group string, topic string, partition int32) (int64, error) {
+	coordinator, err := c.client.Coordinator(group)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get coordinator of group %s: %w", group, err)
+	}
+
+	request := &sarama.OffsetFetchRequest{Version: 1, ConsumerGroup: group}
+	request.AddPartition(topic, partition)
+	response, err := coordinator.FetchOffset(request)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch offset of group %s: %w", group, err)
+	}
+
+	block := response.GetBlock(topic, partition)
+	if block == nil {
+		return -1, nil
+	}
+
+	if !errors.Is(block.Err, sarama.ErrNoError) {
+		return 0, fmt.Errorf("failed to fetch offset of group %s: %w", group, block.Err)
+	}
+
+	return block.Offset, nil
+}
+
+// SetOffsetsClient sets client which reads offsets for State and ResetOffsets, group is name of consumer group.
+// Consumers of NewConsumer have client already.
+func (consumer *Consumer) SetOffsetsClient(offsetsClient OffsetsClientInterface, group string) {
+	consumer.offsetsClient = offsetsClient
+	consumer.group = group
+}
+
+// Group returns name of consumer group.
+func (consumer *Consumer) Group() string {
+	return consumer.group
+}
+
+// State returns offsets and lag of all partitions of topics which consumer reads.
+func (consumer *Consumer) State() (*ConsumerState, error) {
+	if consumer.offsetsClient == nil {
+		return nil, ErrOffsetsClientNotSet
+	}
+
+	consumer.mu.Lock()
+	state := &ConsumerState{Group: consumer.group}
+	claims := map[string][]int32{}
+	if consumer.session != nil {
+		state.MemberID = consumer.session.MemberID()
+		state.GenerationID = consumer.session.GenerationID()
+		claims = consumer.session.Claims()
+	}
+	consumer.mu.Unlock()
+
+	for _, topic := range consumer.topics() {
+		partitions, err := consumer.offsetsClient.Partitions(topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get partitions of topic %s: %w", topic, err)
+		}
+
+		for _, partition := range partitions {
+			partitionState, err := consumer.partitionState(topic, partition)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, claimed := range claims[topic] {
+				partitionState.Claimed = partitionState.Claimed || claimed == partition
+			}
+			state.Partitions = append(state.Partitions, partitionState)
+		}
+	}
+
+	return state, nil
+}
+
+func (consumer *Consumer) partitionState(topic string, partition int32) (PartitionState, error) {
+	committedOffset, err := consumer.offsetsClient.FetchOffset(consumer.group, topic, partition)
+	if err != nil {
+		return PartitionState{}, err
+	}
+
+	highWaterMark, err := consumer.offsetsClient.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return PartitionState{}, fmt.Errorf("failed to get high water mark of %s/%d: %w", topic, partition, err)
+	}
+
+	// group without committed offset reads all messages which are not deleted yet.
+	offset := committedOffset
+	if offset < 0 {
+		offset, err = consumer.offsetsClient.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return PartitionState{}, fmt.Errorf("failed to get oldest offset of %s/%d: %w", topic, partition, err)
+		}
+	}
+	lag := highWaterMark - offset
+
+	return PartitionState{
+		Topic:           topic,
+		Partition:       partition,
+		CommittedOffset: committedOffset,
+		HighWaterMark:   highWaterMark,
+		Lag:             lag,
+		Paused:          consumer.isPaused(topic, partition),
+	}, nil
+}
+
+// Pause stops reading of partitions of topic till Resume, empty partitions means all partitions of topic.
+// Partitions stay paused after rebalance.
+func (consumer *Consumer) Pause(topic string, partitions []int32) error {
+	partitions, err := consumer.topicPartitions(topic, partitions)
+	if err != nil {
+		return err
+	}
+
+	consumer.mu.Lock()
+	if consumer.paused[topic] == nil {
+		consumer.paused[topic] = make(map[int32]bool)
+	}
+	for _, partition := range partitions {
+		consumer.paused[topic][partition] = true
+	}
+	consumer.mu.Unlock()
+
+	consumer.consumerGroup.Pause(map[string][]int32{topic: partitions})
+	log.Printf("Pausing consumption of topic = %s, partitions = %v\n", topic, partitions)
+
+	return nil
+}
+
+// Resume continues reading of paused partitions of topic, empty partitions means all partitions of topic.
+func (consumer *Consumer) Resume(topic string, partitions []int32) error {
+	partitions, err := consumer.topicPartitions(topic, partitions)
+	if err != nil {
+		return err
+	}
+
+	consumer.mu.Lock()
+	for _, partition := range partitions {
+		delete(consumer.paused[topic], partition)
+	}
+	consumer.mu.Unlock()
+
+	consumer.consumerGroup.Resume(map[string][]int32{topic: partitions})
+	log.Printf("Resuming consumption of topic = %s, partitions = %v\n", topic, partitions)
+
+	return nil
+}
+
+// PauseAll stops reading of all partitions till ResumeAll, partitions stay paused after rebalance.
+func (consumer *Consumer) PauseAll() {
+	consumer.mu.Lock()
+	consumer.pausedAll = true
+	consumer.mu.Unlock()
+
+	consumer.consumerGroup.PauseAll()
+	log.Println("Pausing consumption of all partitions")
+}
+
+// ResumeAll continues reading of all partitions, also partitions which were paused one by one.
+func (consumer *Consumer) ResumeAll() {
+	consumer.mu.Lock()
+	consumer.pausedAll = false
+	consumer.paused = make(map[string]map[int32]bool)
+	consumer.mu.Unlock()
+
+	consumer.consumerGroup.ResumeAll()
+	log.Println("Resuming consumption of all partitions")
+}
+
+// ResetOffsets moves committed offsets of partitions to the first messages after timestamp for replay,
+// empty partitions means all partitions of topic which this consumer claims. Only claimed partitions can be reset,
+// other partitions return ErrPartitionNotClaimed. Consumer starts new session to read from new offsets,
+// reset of partition which is not claimed by new session after rebalance is dropped. It returns new offsets of partitions.
+func (consumer *Consumer) ResetOffsets(topic string, partitions []int32, timestamp time.Time) (map[int32]int64, error) {
+	if consumer.offsetsClient == nil {
+		return nil, ErrOffsetsClientNotSet
+	}
+
+	claimed := consumer.claims()[topic]
+	if len(partitions) == 0 {
+		partitions = claimed
+	}
+
+	partitions, err := consumer.topicPartitions(topic, partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, partition := range partitions {
+		if !consumer.isClaimed(topic, partition) {
+			return nil, fmt.Errorf("%w: %s/%d, claimed partitions = %v", ErrPartitionNotClaimed, topic, partition, claimed)
+		}
+	}
+
+	offsets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		offset, err := consumer.offsetsClient.GetOffset(topic, partition, timestamp.UnixMilli())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get offset of %s/%d by time: %w", topic, partition, err)
+		}
+
+		// there are no messages after timestamp, so consumer waits new messages.
+		if offset < 0 {
+			offset, err = consumer.offsetsClient.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get high water mark of %s/%d: %w", topic, partition, err)
+			}
+		}
+		offsets[partition] = offset
+	}
+
+	consumer.mu.Lock()
+	if consumer.offsetResets[topic] == nil {
+		consumer.offsetResets[topic] = make(map[int32]int64)
+	}
+	for partition, offset := range offsets {
+		consumer.offsetResets[topic][partition] = offset
+	}
+	cancelSession := consumer.cancelSession
+	consumer.mu.Unlock()
+
+	log.Printf("Resetting offsets of topic = %s to %v: %v\n", topic, timestamp, offsets)
+	if cancelSession != nil {
+		cancelSession()
+	}
+
+	return offsets, nil
+}
+
+// topicPartitions checks that consumer reads topic and returns all partitions of topic when partitions are empty.
+func (consumer *Consumer) topicPartitions(topic string, partitions []int32) ([]int32, error) {
+	isKnown := false
+	for _, consumerTopic := range consumer.topics() {
+		isKnown = isKnown || consumerTopic == topic
+	}
+
+	if !isKnown {
+		return nil, fmt.Errorf("%w: %s, topics = %s", ErrUnknownTopic, topic, strings.Join(consumer.topics(), ","))
+	}
+
+	if len(partitions) > 0 {
+		return partitions, nil
+	}
+
+	if consumer.offsetsClient == nil {
+		return nil, ErrOffsetsClientNotSet
+	}
+
+	partitions, err := consumer.offsetsClient.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions of topic %s: %w", topic, err)
+	}
+
+	return partitions, nil
+}
+
+func (consumer *Consumer) isPaused(topic string, partition int32) bool {
+	consumer.mu.Lock()
+	defer consumer.mu.Unlock()
+
+	return consumer.pausedAll || consumer.paused[topic][partition]
+}
+
+// startSession remembers session for State and applies offset resets of claimed partitions, resets of partitions
+// which went to other consumer in rebalance are dropped, so they are not applied in some later session.
+func (consumer *Consumer) startSession(session sarama.ConsumerGroupSession) {
+	consumer.mu.Lock()
+	defer consumer.mu.Unlock()
+
+	consumer.session = session
+	for topic, partitions := range session.Claims() {
+		for _, partition := range partitions {
+			offset, ok := consumer.offsetResets[topic][partition]
+			if !ok {
+				continue
+			}
+
+			session.ResetOffset(topic, partition, offset, "")
+			delete(consumer.offsetResets[topic], partition)
+		}
+	}
+
+	for topic, offsets := range consumer.offsetResets {
+		if len(offsets) > 0 {
+			log.Printf("Dropping offset resets of topic = %s which are not claimed after rebalance: %v\n", topic, offsets)
+		}
+	}
+	consumer.offsetResets = make(map[string]map[int32]int64)
+}
+
+// claims returns partitions of current session.
+func (consumer *Consumer) claims() map[string][]int32 {
+	consumer.mu.Lock()
+	defer consumer.mu.Unlock()
+
+	if consumer.session == nil {
+		return nil
+	}
+
+	return consumer.session.Claims()
+}
+
+func (consumer *Consumer) isClaimed(topic string, partition int32) bool {
+	for _, claimed := range consumer.claims()[topic] {
+		if claimed == partition {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (consumer *Consumer) endSession() {
+	consumer.mu.Lock()
+	defer consumer.mu.Unlock()
+
+	consumer.session = nil
+}
+
+// consumeSession runs one session of consumer group, session can be restarted by admin, for example to reset offsets.
+func (consumer *Consumer) consumeSession(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	consumer.mu.Lock()
+	consumer.cancelSession = cancel
+	consumer.claimErr = nil
+	consumer.mu.Unlock()
+
+	err := consumer.consumerGroup.Consume(ctx, consumer.topics(), consumer)
+	if err != nil {
+		return err
+	}
+
+	// sarama ends session without error when claim fails, so failed claim is returned instead.
+	consumer.mu.Lock()
+	defer consumer.mu.Unlock()
+
+	return consumer.claimErr
+}
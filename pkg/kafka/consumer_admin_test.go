@@ -0,0 +1,94 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+// receivingHandler sends id of every handled event in channel.
+type receivingHandler struct {
+	ids chan string
+}
+
+func (h receivingHandler) HandleMessage(_ context.Context, event testEvent) error {
+	h.ids <- event.ID
+
+	return nil
+}
+
+func newAdminConsumer(t *testing.T, broker *kafkatest.Broker, registry *kafkatest.SchemaRegistry, handler receivingHandler) *kafka.Consumer {
+	t.Helper()
+
+	consumer, err := kafka.NewSerdeConsumer(
+		kafka.NewSerdeHandler[testEvent](kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema), handler),
+		fakeConsumerOptions(broker, registry, "in"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	consumer.SetOffsetsClient(broker, "in")
+
+	return consumer
+}
+
+func receiveID(ctx context.Context, t *testing.T, ids <-chan string) string {
+	t.Helper()
+
+	select {
+	case id := <-ids:
+		return id
+	case <-ctx.Done():
+		t.Fatal("message was not handled")
+	}
+
+	return ""
+}
+
+func TestResetOffsetsOfNotClaimedPartitionFails(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	publishTestEvent(t, broker, registry, "in", "order-1")
+	consumer := newAdminConsumer(t, broker, registry, receivingHandler{ids: make(chan string, 1)})
+
+	if _, err := consumer.ResetOffsets("in", []int32{0}, time.Time{}); !errors.Is(err, kafka.ErrPartitionNotClaimed) {
+		t.Fatalf("expected error of not claimed partition, got %v", err)
+	}
+}
+
+func TestResetOffsetsReplaysClaimedPartition(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	publishTestEvent(t, broker, registry, "in", "order-1")
+	handler := receivingHandler{ids: make(chan string, 2)}
+	consumer := newAdminConsumer(t, broker, registry, handler)
+	done := runConsumer(ctx, t, consumer)
+
+	receiveID(ctx, t, handler.ids)
+	if err := broker.WaitCommittedOffset(ctx, "in", "in", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	offsets, err := consumer.ResetOffsets("in", nil, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if offset, ok := offsets[0]; !ok || offset != 0 {
+		t.Fatalf("expected reset of partition 0 to offset 0, got %v", offsets)
+	}
+
+	if id := receiveID(ctx, t, handler.ids); id != "order-1" {
+		t.Fatalf("expected replay of order-1, got %s", id)
+	}
+
+	cancel()
+	<-done
+}
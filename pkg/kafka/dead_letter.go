@@ -0,0 +1,132 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/IBM/sarama"
+)
+
+// DeadLetterTopicSuffix is added to topic name, we send messages there which consumer could not handle.
+const DeadLetterTopicSuffix = ".dlq"
+
+const (
+	DeadLetterHeaderError     = "dlq.error"
+	DeadLetterHeaderTopic     = "dlq.topic"
+	DeadLetterHeaderPartition = "dlq.partition"
+	DeadLetterHeaderOffset    = "dlq.offset"
+)
+
+// DeadLetterPublisherInterface sends message which consumer could not handle in retry topic or dead letter topic.
+type DeadLetterPublisherInterface interface {
+	PublishRetry(message *sarama.ConsumerMessage, retryTopic string, cause error) error
+	PublishDeadLetter(message *sarama.ConsumerMessage, cause error) error
+}
+
+// DeadLetterPublisher Sync send original message in retry or dead letter topic, we must be sure that message is saved before we mark offset.
+type DeadLetterPublisher struct {
+	producer sarama.SyncProducer
+}
+
+// NewDeadLetterPublisher Create new DeadLetterPublisher for sending in kafka.
+func NewDeadLetterPublisher(address []string) (*DeadLetterPublisher, error) {
+	return NewDeadLetterPublisherWithSecurity(address, SecurityOptions{})
+}
+
+// NewDeadLetterPublisherWithSecurity Create new DeadLetterPublisher which connects to kafka with TLS and SASL of security.
+func NewDeadLetterPublisherWithSecurity(address []string, security SecurityOptions) (*DeadLetterPublisher, error) {
+	config := sarama.NewConfig()
+	if err := security.apply(config); err != nil {
+		return nil, err
+	}
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	producer, err := sarama.NewSyncProducer(address, config)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new sarama sync producer: %w", err)
+	}
+
+	return NewDeadLetterPublisherWithProducer(producer), nil
+}
+
+// NewDeadLetterPublisherWithProducer Create new DeadLetterPublisher with own producer, for example fake of kafkatest.
+func NewDeadLetterPublisherWithProducer(producer sarama.SyncProducer) *DeadLetterPublisher {
+	return &DeadLetterPublisher{producer: producer}
+}
+
+// PublishDeadLetter sends original bytes of message in <topic>.dlq with headers which describe error and position of message.
+// Message from retry topic goes in dead letter topic of original topic.
+func (publisher *DeadLetterPublisher) PublishDeadLetter(message *sarama.ConsumerMessage, cause error) error {
+	return publisher.republish(message, originalTopic(message)+DeadLetterTopicSuffix, cause)
+}
+
+// PublishRetry sends original bytes of message in delayed retry topic.
+func (publisher *DeadLetterPublisher) PublishRetry(message *sarama.ConsumerMessage, retryTopic string, cause error) error {
+	return publisher.republish(message, retryTopic, cause)
+}
+
+func (publisher *DeadLetterPublisher) republish(message *sarama.ConsumerMessage, topic string, cause error) error {
+	_, _, err := publisher.producer.SendMessage(republishedMessage(message, topic, cause))
+	observeProduced(topic, err)
+	if err != nil {
+		return fmt.Errorf("failed to send message in topic %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+// republishedMessage keeps headers of the first failure, so we always know original topic, partition and offset of message.
+func republishedMessage(message *sarama.ConsumerMessage, topic string, cause error) *sarama.ProducerMessage {
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+4)
+	for _, header := range message.Headers {
+		if header != nil && string(header.Key) != DeadLetterHeaderError {
+			headers = append(headers, *header)
+		}
+	}
+
+	headers = append(headers, sarama.RecordHeader{Key: []byte(DeadLetterHeaderError), Value: []byte(cause.Error())})
+	if headerValue(message, DeadLetterHeaderTopic) == "" {
+		headers = append(
+			headers,
+			sarama.RecordHeader{Key: []byte(DeadLetterHeaderTopic), Value: []byte(message.Topic)},
+			sarama.RecordHeader{Key: []byte(DeadLetterHeaderPartition), Value: []byte(strconv.FormatInt(int64(message.Partition), 10))},
+			sarama.RecordHeader{Key: []byte(DeadLetterHeaderOffset), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		)
+	}
+
+	republished := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+
+	if message.Key != nil {
+		republished.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	return republished
+}
+
+// Close closes producer of dead letter topics.
+func (publisher *DeadLetterPublisher) Close() error {
+	return publisher.producer.Close()
+}
+
+func originalTopic(message *sarama.ConsumerMessage) string {
+	if topic := headerValue(message, DeadLetterHeaderTopic); topic != "" {
+		return topic
+	}
+
+	return message.Topic
+}
+
+func headerValue(message *sarama.ConsumerMessage, key string) string {
+	for _, header := range message.Headers {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,122 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+// failingHandler fails every attempt.
+type failingHandler struct {
+	err error
+}
+
+func (h failingHandler) HandleMessage(_ context.Context, _ testEvent) error {
+	return h.err
+}
+
+func newDeadLetterConsumer(t *testing.T, broker *kafkatest.Broker, registry *kafkatest.SchemaRegistry, handler kafka.Handler[testEvent]) *kafka.Consumer {
+	t.Helper()
+
+	serde := kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema)
+	consumer, err := kafka.NewSerdeConsumer(kafka.NewSerdeHandler[testEvent](serde, handler), fakeConsumerOptions(broker, registry, "in"))
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	consumer.SetRetryPolicy(kafka.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	consumer.SetDeadLetterPublisher(kafka.NewDeadLetterPublisherWithProducer(broker.NewSyncProducer()))
+
+	return consumer
+}
+
+func receiveDeadLetter(t *testing.T, broker *kafkatest.Broker, consumer *kafka.Consumer) *sarama.ConsumerMessage {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := runConsumer(ctx, t, consumer)
+	deadLetters, err := broker.WaitMessages(ctx, "in"+kafka.DeadLetterTopicSuffix, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := broker.WaitCommittedOffset(ctx, "in", "in", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-done
+
+	return deadLetters[0]
+}
+
+func messageHeader(message *sarama.ConsumerMessage, key string) string {
+	for _, header := range message.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+func expectDeadLetterHeaders(t *testing.T, deadLetter *sarama.ConsumerMessage, cause string) {
+	t.Helper()
+
+	expected := map[string]string{
+		kafka.DeadLetterHeaderTopic:     "in",
+		kafka.DeadLetterHeaderPartition: "0",
+		kafka.DeadLetterHeaderOffset:    "0",
+	}
+	for key, value := range expected {
+		if header := messageHeader(deadLetter, key); header != value {
+			t.Fatalf("expected header %s = %s, got %q", key, value, header)
+		}
+	}
+
+	if header := messageHeader(deadLetter, kafka.DeadLetterHeaderError); !strings.Contains(header, cause) {
+		t.Fatalf("expected header %s with %q, got %q", kafka.DeadLetterHeaderError, cause, header)
+	}
+}
+
+func TestConsumerSendsUndecodableMessageInDeadLetterTopic(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	if _, _, err := broker.Produce(&sarama.ProducerMessage{Topic: "in", Key: sarama.StringEncoder("order-1"), Value: sarama.StringEncoder("not confluent")}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := receivingHandler{ids: make(chan string, 1)}
+	deadLetter := receiveDeadLetter(t, broker, newDeadLetterConsumer(t, broker, registry, handler))
+
+	if len(handler.ids) != 0 {
+		t.Fatal("expected undecodable message skipped by handler")
+	}
+
+	if string(deadLetter.Value) != "not confluent" || string(deadLetter.Key) != "order-1" {
+		t.Fatalf("expected original key and value in dead letter topic, got %s = %s", deadLetter.Key, deadLetter.Value)
+	}
+
+	expectDeadLetterHeaders(t, deadLetter, kafka.ErrInvalidMessage.Error())
+}
+
+func TestConsumerSendsMessageInDeadLetterTopicWhenAttemptsAreOver(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	publishTestEvent(t, broker, registry, "in", "order-1")
+	original := broker.Messages("in")[0]
+
+	deadLetter := receiveDeadLetter(t, broker, newDeadLetterConsumer(t, broker, registry, failingHandler{err: errors.New("courier is not available")}))
+
+	if string(deadLetter.Value) != string(original.Value) {
+		t.Fatal("expected original value in dead letter topic")
+	}
+
+	expectDeadLetterHeaders(t, deadLetter, "courier is not available")
+}
@@ -0,0 +1,137 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/avro/v1"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+const (
+	ordersTopic           = "orders.v1"
+	orderValidationsTopic = "order_validations.v1"
+)
+
+// courierOrderHandler assigns courier to created order and publishes validation as courier service does.
+type courierOrderHandler struct {
+	publisher *kafka.Publisher
+	courierID string
+}
+
+func (h *courierOrderHandler) HandleMessage(ctx context.Context, orderMessage avro.OrderMessage) error {
+	validation := avro.NewOrderValidationMessage()
+	validation.Order_id = orderMessage.Payload.Order_id
+	validation.Service_name = "courier"
+	validation.Is_successful = true
+	validation.Payload.Courier_id.String = h.courierID
+
+	message, err := validation.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	return h.publisher.PublishMessage(ctx, message, []byte(validation.Order_id), validation.Schema())
+}
+
+// orderValidationHandler receives validations of order as order service does.
+type orderValidationHandler struct {
+	validations chan avro.OrderValidationMessage
+}
+
+func (h *orderValidationHandler) HandleJSONMessage(_ context.Context, message []byte) error {
+	validation := avro.NewOrderValidationMessage()
+	if err := validation.UnmarshalJSON(message); err != nil {
+		return err
+	}
+
+	h.validations <- validation
+
+	return nil
+}
+
+// fakeConsumerOptions returns options of consumer which reads topic of fake broker in consumer group with name of topic.
+func fakeConsumerOptions(broker *kafkatest.Broker, registry *kafkatest.SchemaRegistry, topic string) kafka.ConsumerOptions {
+	return kafka.ConsumerOptions{
+		Topic:                topic,
+		ConsumerGroup:        broker.NewConsumerGroup(topic),
+		SchemaRegistryClient: registry,
+	}
+}
+
+func runConsumer(ctx context.Context, t *testing.T, consumer *kafka.Consumer) <-chan error {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- consumer.ConsumeMessage(ctx)
+	}()
+
+	return done
+}
+
+func TestOrderIsValidatedByCourierThroughKafka(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(3)
+	registry := kafkatest.NewSchemaRegistry()
+
+	courierPublisher := kafka.NewPublisherWithProducer(broker.NewAsyncProducer(), registry, orderValidationsTopic)
+	courierPublisher.SetSync(true)
+	defer courierPublisher.Close()
+
+	courierConsumer, err := kafka.NewAvroConsumer(
+		kafka.NewAvroHandler[avro.OrderMessage](&courierOrderHandler{publisher: courierPublisher, courierID: "courier-1"}),
+		fakeConsumerOptions(broker, registry, ordersTopic),
+	)
+	if err != nil {
+		t.Fatalf("failed to create courier consumer: %v", err)
+	}
+
+	orderHandler := &orderValidationHandler{validations: make(chan avro.OrderValidationMessage, 1)}
+	orderConsumer, err := kafka.NewConsumer(orderHandler, fakeConsumerOptions(broker, registry, orderValidationsTopic))
+	if err != nil {
+		t.Fatalf("failed to create order consumer: %v", err)
+	}
+
+	courierDone := runConsumer(ctx, t, courierConsumer)
+	orderDone := runConsumer(ctx, t, orderConsumer)
+
+	orderPublisher := kafka.NewPublisherWithProducer(broker.NewAsyncProducer(), registry, ordersTopic)
+	orderPublisher.SetSync(true)
+	defer orderPublisher.Close()
+
+	orderMessage := avro.NewOrderMessage()
+	orderMessage.Payload.Order_id = "order-1"
+	orderMessage.Event = "created"
+	message, err := orderMessage.MarshalJSON()
+	if err != nil {
+		t.Fatalf("failed to marshal order: %v", err)
+	}
+	if err := orderPublisher.PublishMessage(ctx, message, []byte("order-1"), orderMessage.Schema()); err != nil {
+		t.Fatalf("failed to publish order: %v", err)
+	}
+
+	select {
+	case validation := <-orderHandler.validations:
+		if validation.Order_id != "order-1" || validation.Payload.Courier_id.String != "courier-1" {
+			t.Fatalf("unexpected validation: order %s, courier %s", validation.Order_id, validation.Payload.Courier_id.String)
+		}
+	case <-ctx.Done():
+		t.Fatal("order did not get validation of courier")
+	}
+
+	if err := broker.WaitCommittedOffset(ctx, ordersTopic, ordersTopic, broker.Messages(ordersTopic)[0].Partition, 1); err != nil {
+		t.Fatalf("courier did not commit order: %v", err)
+	}
+
+	cancel()
+	for _, done := range []<-chan error{courierDone, orderDone} {
+		if err := <-done; err != nil {
+			t.Fatalf("consumer failed: %v", err)
+		}
+	}
+}
@@ -2,10 +2,20 @@ package kafka
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
+const (
+	// subjectNotFoundErrorCode schema registry returns this code when subject is unknown.
+	subjectNotFoundErrorCode = 40401
+	// versionNotFoundErrorCode schema registry returns this code when subject does not have version.
+	versionNotFoundErrorCode = 40402
+	// schemaNotFoundErrorCode schema registry returns this code when schema id is unknown.
+	schemaNotFoundErrorCode = 40403
+)
+
 // Error holds more detailed information about errors coming back from schema registry.
 type Error struct {
 	ErrorCode int    `json:"error_code"`
@@ -24,3 +34,10 @@ func newError(resp *http.Response) *Error {
 	}
 	return err
 }
+
+// isSchemaNotFound checks that registry does not know schema id of message.
+func isSchemaNotFound(err error) bool {
+	var registryErr *Error
+
+	return errors.As(err, &registryErr) && registryErr.ErrorCode == schemaNotFoundErrorCode
+}
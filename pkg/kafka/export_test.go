@@ -0,0 +1,8 @@
+package kafka
+
+import "github.com/IBM/sarama"
+
+// ApplySecurity sets TLS and SASL of security options in sarama config, it is used by tests of package kafka_test.
+func ApplySecurity(security SecurityOptions, config *sarama.Config) error {
+	return security.apply(config)
+}
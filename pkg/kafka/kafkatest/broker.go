@@ -0,0 +1,602 @@
+// Package kafkatest has in-memory schema registry and broker, so publishers and consumers of services can run in go test.
+package kafkatest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+)
+
+var _ kafka.OffsetsClientInterface = (*Broker)(nil)
+
+// Broker keeps messages of topics in memory, producers and consumer groups of the same broker see the same messages.
+// Topics are created on the first message or subscription, every topic has the same number of partitions.
+// Broker can be used as offsets client of kafka.Consumer.
+type Broker struct {
+	partitions int32
+
+	mu          sync.Mutex
+	topics      map[string][][]*sarama.ConsumerMessage
+	partitioner map[string]sarama.Partitioner
+	offsets     map[string]map[string]map[int32]int64
+	// producers are the latest transactional producers by transactional id, older producers are fenced.
+	producers map[string]*AsyncProducer
+	// changed is closed and replaced when broker gets new message or offset, so waiters wake up.
+	changed chan struct{}
+}
+
+// NewBroker creates broker, messages are partitioned by murmur2 hash of key like in Publisher.
+func NewBroker(partitions int32) *Broker {
+	if partitions < 1 {
+		partitions = 1
+	}
+
+	return &Broker{
+		partitions:  partitions,
+		topics:      make(map[string][][]*sarama.ConsumerMessage),
+		partitioner: make(map[string]sarama.Partitioner),
+		offsets:     make(map[string]map[string]map[int32]int64),
+		producers:   make(map[string]*AsyncProducer),
+		changed:     make(chan struct{}),
+	}
+}
+
+// Produce appends message in partition of topic, it sets partition, offset and timestamp of message.
+func (b *Broker) Produce(message *sarama.ProducerMessage) (int32, int64, error) {
+	consumerMessage := &sarama.ConsumerMessage{
+		Topic:     message.Topic,
+		Timestamp: message.Timestamp,
+	}
+
+	if message.Key != nil {
+		key, err := message.Key.Encode()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to encode key: %w", err)
+		}
+		consumerMessage.Key = key
+	}
+
+	if message.Value != nil {
+		value, err := message.Value.Encode()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to encode value: %w", err)
+		}
+		consumerMessage.Value = value
+	}
+
+	for _, header := range message.Headers {
+		consumerMessage.Headers = append(consumerMessage.Headers, &sarama.RecordHeader{Key: header.Key, Value: header.Value})
+	}
+
+	if consumerMessage.Timestamp.IsZero() {
+		consumerMessage.Timestamp = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	partition, err := b.partition(message)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	partitions := b.topic(message.Topic)
+	consumerMessage.Partition = partition
+	consumerMessage.Offset = int64(len(partitions[partition]))
+	partitions[partition] = append(partitions[partition], consumerMessage)
+	message.Partition = consumerMessage.Partition
+	message.Offset = consumerMessage.Offset
+	message.Timestamp = consumerMessage.Timestamp
+	b.notifyLocked()
+
+	return consumerMessage.Partition, consumerMessage.Offset, nil
+}
+
+// Messages returns messages of topic from all partitions, partitions go one by one.
+func (b *Broker) Messages(topic string) []*sarama.ConsumerMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var messages []*sarama.ConsumerMessage
+	for _, partition := range b.topics[topic] {
+		messages = append(messages, partition...)
+	}
+
+	return messages
+}
+
+// WaitMessages waits till topic has at least count messages and returns them.
+func (b *Broker) WaitMessages(ctx context.Context, topic string, count int) ([]*sarama.ConsumerMessage, error) {
+	for {
+		changed := b.changes()
+		messages := b.Messages(topic)
+		if len(messages) >= count {
+			return messages, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return messages, fmt.Errorf("topic %s has %d messages of %d: %w", topic, len(messages), count, ctx.Err())
+		}
+	}
+}
+
+// CommittedOffset returns offset of the next message which consumer group reads, -1 when group did not commit.
+func (b *Broker) CommittedOffset(group string, topic string, partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	offset, ok := b.offsets[group][topic][partition]
+	if !ok {
+		return -1
+	}
+
+	return offset
+}
+
+// WaitCommittedOffset waits till consumer group commits offset of partition.
+func (b *Broker) WaitCommittedOffset(ctx context.Context, group string, topic string, partition int32, offset int64) error {
+	for {
+		changed := b.changes()
+		if b.CommittedOffset(group, topic, partition) >= offset {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("group %s did not commit offset %d of %s/%d: %w", group, offset, topic, partition, ctx.Err())
+		}
+	}
+}
+
+// Partitions returns partitions of topic.
+func (b *Broker) Partitions(topic string) ([]int32, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	partitions := make([]int32, 0, len(b.topic(topic)))
+	for partition := range b.topic(topic) {
+		partitions = append(partitions, int32(partition))
+	}
+
+	return partitions, nil
+}
+
+// GetOffset returns offset of the first message with timestamp in milliseconds greater or equal time, -1 when there is no such message.
+func (b *Broker) GetOffset(topic string, partition int32, time int64) (int64, error) {
+	if partition < 0 || partition >= b.partitions {
+		return 0, sarama.ErrUnknownTopicOrPartition
+	}
+
+	switch time {
+	case sarama.OffsetNewest:
+		return b.highWaterMark(topic, partition), nil
+	case sarama.OffsetOldest:
+		return 0, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, message := range b.topic(topic)[partition] {
+		if message.Timestamp.UnixMilli() >= time {
+			return message.Offset, nil
+		}
+	}
+
+	return -1, nil
+}
+
+// FetchOffset returns committed offset of consumer group, -1 when group did not commit.
+func (b *Broker) FetchOffset(group string, topic string, partition int32) (int64, error) {
+	return b.CommittedOffset(group, topic, partition), nil
+}
+
+// NewAsyncProducer creates producer which sends messages in broker, it can be used in kafka.NewPublisherWithProducer.
+func (b *Broker) NewAsyncProducer() *AsyncProducer {
+	return newAsyncProducer(b)
+}
+
+// NewTransactionalAsyncProducer creates producer with transactions, messages and offsets of transaction are saved in broker
+// only on commit. Producer fences previous producer with the same transactional id like broker does, it can be returned
+// by factory of kafka.Consumer.SetTransactionalProducers.
+func (b *Broker) NewTransactionalAsyncProducer(transactionalID string) *AsyncProducer {
+	producer := newAsyncProducer(b)
+	producer.transactionalID = transactionalID
+
+	b.mu.Lock()
+	b.producers[transactionalID] = producer
+	b.mu.Unlock()
+
+	return producer
+}
+
+// isFenced checks that producer with the same transactional id was created after producer.
+func (b *Broker) isFenced(producer *AsyncProducer) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.producers[producer.transactionalID] != producer
+}
+
+// NewSyncProducer creates producer which sends messages in broker, it can be used in kafka.NewDeadLetterPublisherWithProducer.
+func (b *Broker) NewSyncProducer() *SyncProducer {
+	return &SyncProducer{broker: b}
+}
+
+// NewConsumerGroup creates consumer group which reads messages of broker, it can be used in kafka.NewConsumerFromConsumerGroup.
+// Consumer group without committed offset starts from the oldest message.
+func (b *Broker) NewConsumerGroup(group string) *ConsumerGroup {
+	return newConsumerGroup(b, group)
+}
+
+func (b *Broker) changes() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.changed
+}
+
+func (b *Broker) notifyLocked() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// topic returns partitions of topic, it creates topic when it does not exist.
+func (b *Broker) topic(topic string) [][]*sarama.ConsumerMessage {
+	partitions, ok := b.topics[topic]
+	if !ok {
+		partitions = make([][]*sarama.ConsumerMessage, b.partitions)
+		b.topics[topic] = partitions
+	}
+
+	return partitions
+}
+
+func (b *Broker) partition(message *sarama.ProducerMessage) (int32, error) {
+	partitioner, ok := b.partitioner[message.Topic]
+	if !ok {
+		constructor, err := kafka.NewPartitioner(kafka.PartitionerMurmur2)
+		if err != nil {
+			return 0, err
+		}
+		partitioner = constructor(message.Topic)
+		b.partitioner[message.Topic] = partitioner
+	}
+
+	return partitioner.Partition(message, b.partitions)
+}
+
+// message returns message of partition by offset or nil when there is no such message yet.
+func (b *Broker) message(topic string, partition int32, offset int64) *sarama.ConsumerMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	messages := b.topic(topic)[partition]
+	if offset < 0 || offset >= int64(len(messages)) {
+		return nil
+	}
+
+	return messages[offset]
+}
+
+func (b *Broker) highWaterMark(topic string, partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return int64(len(b.topic(topic)[partition]))
+}
+
+func (b *Broker) commitOffset(group string, topic string, partition int32, offset int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	topics, ok := b.offsets[group]
+	if !ok {
+		topics = make(map[string]map[int32]int64)
+		b.offsets[group] = topics
+	}
+
+	partitions, ok := topics[topic]
+	if !ok {
+		partitions = make(map[int32]int64)
+		topics[topic] = partitions
+	}
+
+	partitions[partition] = offset
+	b.notifyLocked()
+}
+
+// AsyncProducer sends messages in broker one by one in order of input, successes and errors must be read.
+type AsyncProducer struct {
+	broker    *Broker
+	input     chan *sarama.ProducerMessage
+	successes chan *sarama.ProducerMessage
+	errors    chan *sarama.ProducerError
+	closeOnce sync.Once
+	done      chan struct{}
+	flushes   chan chan struct{}
+
+	transactionalID string
+	txnMu           sync.Mutex
+	inTxn           bool
+	txnMessages     []*sarama.ProducerMessage
+	txnOffsets      []txnOffset
+}
+
+// txnOffset is offset of consumer group which is committed with transaction.
+type txnOffset struct {
+	group     string
+	topic     string
+	partition int32
+	offset    int64
+}
+
+var _ sarama.AsyncProducer = (*AsyncProducer)(nil)
+
+func newAsyncProducer(broker *Broker) *AsyncProducer {
+	producer := &AsyncProducer{
+		broker:    broker,
+		input:     make(chan *sarama.ProducerMessage),
+		successes: make(chan *sarama.ProducerMessage, 256),
+		errors:    make(chan *sarama.ProducerError, 256),
+		done:      make(chan struct{}),
+		flushes:   make(chan chan struct{}),
+	}
+	go producer.run()
+
+	return producer
+}
+
+func (p *AsyncProducer) run() {
+	defer close(p.done)
+	defer close(p.successes)
+	defer close(p.errors)
+
+	for {
+		select {
+		case message, ok := <-p.input:
+			if !ok {
+				return
+			}
+
+			if p.transactionalID != "" {
+				p.addToTxn(message)
+
+				continue
+			}
+
+			if _, _, err := p.broker.Produce(message); err != nil {
+				p.errors <- &sarama.ProducerError{Msg: message, Err: err}
+
+				continue
+			}
+
+			p.successes <- message
+
+		case flushed := <-p.flushes:
+			close(flushed)
+		}
+	}
+}
+
+// addToTxn keeps message till commit, message outside of transaction fails like in sarama.
+func (p *AsyncProducer) addToTxn(message *sarama.ProducerMessage) {
+	p.txnMu.Lock()
+	inTxn := p.inTxn && !p.broker.isFenced(p)
+	if inTxn {
+		p.txnMessages = append(p.txnMessages, message)
+	}
+	p.txnMu.Unlock()
+
+	if !inTxn {
+		p.errors <- &sarama.ProducerError{Msg: message, Err: sarama.ErrTransactionNotReady}
+
+		return
+	}
+
+	p.successes <- message
+}
+
+// flush waits till messages which were already given to input are added in transaction.
+func (p *AsyncProducer) flush() {
+	flushed := make(chan struct{})
+	select {
+	case p.flushes <- flushed:
+		<-flushed
+	case <-p.done:
+	}
+}
+
+// finishTxn saves messages and offsets of transaction in broker on commit and drops them on abort.
+func (p *AsyncProducer) finishTxn(commit bool) error {
+	if p.transactionalID == "" {
+		return sarama.ErrNonTransactedProducer
+	}
+	p.flush()
+
+	p.txnMu.Lock()
+	defer p.txnMu.Unlock()
+
+	if !p.inTxn {
+		return sarama.ErrTransactionNotReady
+	}
+
+	if p.broker.isFenced(p) {
+		return sarama.ErrProducerFenced
+	}
+
+	if commit {
+		for _, message := range p.txnMessages {
+			// success of message was already reported, so broker gets copy and position is not written in it.
+			committed := *message
+			if _, _, err := p.broker.Produce(&committed); err != nil {
+				return err
+			}
+		}
+
+		for _, offset := range p.txnOffsets {
+			p.broker.commitOffset(offset.group, offset.topic, offset.partition, offset.offset)
+		}
+	}
+
+	p.inTxn = false
+	p.txnMessages = nil
+	p.txnOffsets = nil
+
+	return nil
+}
+
+func (p *AsyncProducer) AsyncClose() {
+	p.closeOnce.Do(func() {
+		close(p.input)
+	})
+}
+
+func (p *AsyncProducer) Close() error {
+	p.AsyncClose()
+	<-p.done
+
+	return nil
+}
+
+func (p *AsyncProducer) Input() chan<- *sarama.ProducerMessage {
+	return p.input
+}
+
+func (p *AsyncProducer) Successes() <-chan *sarama.ProducerMessage {
+	return p.successes
+}
+
+func (p *AsyncProducer) Errors() <-chan *sarama.ProducerError {
+	return p.errors
+}
+
+func (p *AsyncProducer) IsTransactional() bool {
+	return p.transactionalID != ""
+}
+
+func (p *AsyncProducer) TxnStatus() sarama.ProducerTxnStatusFlag {
+	p.txnMu.Lock()
+	defer p.txnMu.Unlock()
+
+	if p.inTxn {
+		return sarama.ProducerTxnFlagInTransaction
+	}
+
+	return sarama.ProducerTxnFlagReady
+}
+
+func (p *AsyncProducer) BeginTxn() error {
+	if p.transactionalID == "" {
+		return sarama.ErrNonTransactedProducer
+	}
+
+	p.txnMu.Lock()
+	defer p.txnMu.Unlock()
+
+	if p.inTxn {
+		return sarama.ErrTransactionNotReady
+	}
+
+	if p.broker.isFenced(p) {
+		return sarama.ErrProducerFenced
+	}
+	p.inTxn = true
+
+	return nil
+}
+
+func (p *AsyncProducer) CommitTxn() error {
+	return p.finishTxn(true)
+}
+
+func (p *AsyncProducer) AbortTxn() error {
+	return p.finishTxn(false)
+}
+
+func (p *AsyncProducer) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, group string) error {
+	if p.transactionalID == "" {
+		return sarama.ErrNonTransactedProducer
+	}
+
+	p.txnMu.Lock()
+	defer p.txnMu.Unlock()
+
+	if !p.inTxn {
+		return sarama.ErrTransactionNotReady
+	}
+
+	for topic, partitions := range offsets {
+		for _, partition := range partitions {
+			p.txnOffsets = append(p.txnOffsets, txnOffset{group: group, topic: topic, partition: partition.Partition, offset: partition.Offset})
+		}
+	}
+
+	return nil
+}
+
+func (p *AsyncProducer) AddMessageToTxn(message *sarama.ConsumerMessage, group string, metadata *string) error {
+	return p.AddOffsetsToTxn(map[string][]*sarama.PartitionOffsetMetadata{
+		message.Topic: {{Partition: message.Partition, Offset: message.Offset + 1, Metadata: metadata}},
+	}, group)
+}
+
+// SyncProducer sends messages in broker and returns their position.
+type SyncProducer struct {
+	broker *Broker
+}
+
+var _ sarama.SyncProducer = (*SyncProducer)(nil)
+
+func (p *SyncProducer) SendMessage(message *sarama.ProducerMessage) (int32, int64, error) {
+	return p.broker.Produce(message)
+}
+
+func (p *SyncProducer) SendMessages(messages []*sarama.ProducerMessage) error {
+	for _, message := range messages {
+		if _, _, err := p.broker.Produce(message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *SyncProducer) Close() error {
+	return nil
+}
+
+func (p *SyncProducer) TxnStatus() sarama.ProducerTxnStatusFlag {
+	return sarama.ProducerTxnFlagReady
+}
+
+func (p *SyncProducer) IsTransactional() bool {
+	return false
+}
+
+func (p *SyncProducer) BeginTxn() error {
+	return sarama.ErrNonTransactedProducer
+}
+
+func (p *SyncProducer) CommitTxn() error {
+	return sarama.ErrNonTransactedProducer
+}
+
+func (p *SyncProducer) AbortTxn() error {
+	return sarama.ErrNonTransactedProducer
+}
+
+func (p *SyncProducer) AddOffsetsToTxn(map[string][]*sarama.PartitionOffsetMetadata, string) error {
+	return sarama.ErrNonTransactedProducer
+}
+
+func (p *SyncProducer) AddMessageToTxn(*sarama.ConsumerMessage, string, *string) error {
+	return sarama.ErrNonTransactedProducer
+}
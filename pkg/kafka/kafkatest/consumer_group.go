@@ -0,0 +1,295 @@
+package kafkatest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// ConsumerGroup is the only member of group, so it gets all partitions of topics.
+// Marked offsets are committed at once, errors of ConsumeClaim are sent in Errors channel.
+type ConsumerGroup struct {
+	broker *Broker
+	group  string
+
+	mu       sync.Mutex
+	isClosed bool
+	errors   chan error
+	paused   map[string]map[int32]bool
+	// resumed is closed and replaced when partitions are resumed.
+	resumed   chan struct{}
+	pausedAll bool
+}
+
+var _ sarama.ConsumerGroup = (*ConsumerGroup)(nil)
+
+func newConsumerGroup(broker *Broker, group string) *ConsumerGroup {
+	return &ConsumerGroup{
+		broker:  broker,
+		group:   group,
+		errors:  make(chan error, 16),
+		paused:  make(map[string]map[int32]bool),
+		resumed: make(chan struct{}),
+	}
+}
+
+// Consume runs one session of group, it returns when ctx is done or ConsumeClaim of any partition returns.
+func (g *ConsumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	g.mu.Lock()
+	isClosed := g.isClosed
+	g.mu.Unlock()
+	if isClosed {
+		return sarama.ErrClosedConsumerGroup
+	}
+
+	claims := make(map[string][]int32, len(topics))
+	for _, topic := range topics {
+		partitions := make([]int32, 0, g.broker.partitions)
+		for partition := int32(0); partition < g.broker.partitions; partition++ {
+			partitions = append(partitions, partition)
+		}
+		claims[topic] = partitions
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	session := &consumerGroupSession{ctx: ctx, group: g, claims: claims}
+	if err := handler.Setup(session); err != nil {
+		return err
+	}
+
+	waitGroup := &sync.WaitGroup{}
+	for topic, partitions := range claims {
+		for _, partition := range partitions {
+			claim := newConsumerGroupClaim(g, topic, partition)
+			waitGroup.Add(2)
+			go func() {
+				defer waitGroup.Done()
+				claim.feed(ctx)
+			}()
+
+			go func() {
+				defer waitGroup.Done()
+				if err := handler.ConsumeClaim(session, claim); err != nil && ctx.Err() == nil {
+					g.sendError(err)
+					cancel()
+				}
+			}()
+		}
+	}
+
+	waitGroup.Wait()
+
+	return handler.Cleanup(session)
+}
+
+func (g *ConsumerGroup) Errors() <-chan error {
+	return g.errors
+}
+
+func (g *ConsumerGroup) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.isClosed {
+		return sarama.ErrClosedConsumerGroup
+	}
+
+	g.isClosed = true
+	close(g.errors)
+
+	return nil
+}
+
+func (g *ConsumerGroup) Pause(partitions map[string][]int32) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for topic, topicPartitions := range partitions {
+		if g.paused[topic] == nil {
+			g.paused[topic] = make(map[int32]bool)
+		}
+
+		for _, partition := range topicPartitions {
+			g.paused[topic][partition] = true
+		}
+	}
+}
+
+func (g *ConsumerGroup) Resume(partitions map[string][]int32) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for topic, topicPartitions := range partitions {
+		for _, partition := range topicPartitions {
+			delete(g.paused[topic], partition)
+		}
+	}
+	g.notifyResumedLocked()
+}
+
+func (g *ConsumerGroup) PauseAll() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.pausedAll = true
+}
+
+func (g *ConsumerGroup) ResumeAll() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.pausedAll = false
+	g.paused = make(map[string]map[int32]bool)
+	g.notifyResumedLocked()
+}
+
+func (g *ConsumerGroup) notifyResumedLocked() {
+	close(g.resumed)
+	g.resumed = make(chan struct{})
+}
+
+// isPaused returns channel which is closed on resume when partition is paused.
+func (g *ConsumerGroup) isPaused(topic string, partition int32) (bool, <-chan struct{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.pausedAll || g.paused[topic][partition], g.resumed
+}
+
+func (g *ConsumerGroup) sendError(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.isClosed {
+		return
+	}
+
+	select {
+	case g.errors <- err:
+	default:
+	}
+}
+
+type consumerGroupSession struct {
+	ctx    context.Context
+	group  *ConsumerGroup
+	claims map[string][]int32
+}
+
+func (s *consumerGroupSession) Claims() map[string][]int32 {
+	return s.claims
+}
+
+func (s *consumerGroupSession) MemberID() string {
+	return s.group.group + "-member"
+}
+
+func (s *consumerGroupSession) GenerationID() int32 {
+	return 1
+}
+
+// MarkOffset commits offset when it is greater than committed offset like sarama does.
+func (s *consumerGroupSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	if s.group.broker.CommittedOffset(s.group.group, topic, partition) < offset {
+		s.group.broker.commitOffset(s.group.group, topic, partition, offset)
+	}
+}
+
+func (s *consumerGroupSession) Commit() {}
+
+// ResetOffset commits offset even when it is less than committed offset, new session reads from it.
+func (s *consumerGroupSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+	s.group.broker.commitOffset(s.group.group, topic, partition, offset)
+}
+
+func (s *consumerGroupSession) MarkMessage(message *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(message.Topic, message.Partition, message.Offset+1, metadata)
+}
+
+func (s *consumerGroupSession) Context() context.Context {
+	return s.ctx
+}
+
+type consumerGroupClaim struct {
+	group         *ConsumerGroup
+	topic         string
+	partition     int32
+	initialOffset int64
+	messages      chan *sarama.ConsumerMessage
+}
+
+func newConsumerGroupClaim(group *ConsumerGroup, topic string, partition int32) *consumerGroupClaim {
+	initialOffset := group.broker.CommittedOffset(group.group, topic, partition)
+	if initialOffset < 0 {
+		initialOffset = 0
+	}
+
+	return &consumerGroupClaim{
+		group:         group,
+		topic:         topic,
+		partition:     partition,
+		initialOffset: initialOffset,
+		messages:      make(chan *sarama.ConsumerMessage),
+	}
+}
+
+// feed sends messages of partition in channel till ctx is done, it waits new messages and resume of partition.
+func (c *consumerGroupClaim) feed(ctx context.Context) {
+	defer close(c.messages)
+
+	offset := c.initialOffset
+	for {
+		changed := c.group.broker.changes()
+		isPaused, resumed := c.group.isPaused(c.topic, c.partition)
+		if isPaused {
+			select {
+			case <-resumed:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		message := c.group.broker.message(c.topic, c.partition, offset)
+		if message == nil {
+			select {
+			case <-changed:
+				continue
+			case <-resumed:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case c.messages <- message:
+			offset++
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *consumerGroupClaim) Topic() string {
+	return c.topic
+}
+
+func (c *consumerGroupClaim) Partition() int32 {
+	return c.partition
+}
+
+func (c *consumerGroupClaim) InitialOffset() int64 {
+	return c.initialOffset
+}
+
+func (c *consumerGroupClaim) HighWaterMarkOffset() int64 {
+	return c.group.broker.highWaterMark(c.topic, c.partition)
+}
+
+func (c *consumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
@@ -0,0 +1,413 @@
+package kafkatest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/actgardner/gogen-avro/v10/compiler"
+	"github.com/linkedin/goavro"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+)
+
+// Error codes of Confluent schema registry.
+const (
+	subjectNotFoundErrorCode      = 40401
+	versionNotFoundErrorCode      = 40402
+	schemaNotFoundErrorCode       = 40403
+	incompatibleSchemaErrorCode   = 409
+	invalidSchemaErrorCode        = 42201
+	invalidCompatibilityErrorCode = 42203
+)
+
+var _ kafka.SchemaRegistryClientInterface = (*SchemaRegistry)(nil)
+
+type subjectVersion struct {
+	version int
+	id      int
+}
+
+// SchemaRegistry keeps schemas in memory and checks compatibility like Confluent schema registry,
+// it can be used instead of CachedSchemaRegistryClient in Publisher and Consumer.
+type SchemaRegistry struct {
+	mu          sync.RWMutex
+	schemas     map[int]string
+	schemaTypes map[int]string
+	ids         map[string]int
+	subjects    map[string][]subjectVersion
+	levels      map[string]string
+	globalLevel string
+}
+
+// NewSchemaRegistry creates empty registry with BACKWARD compatibility.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas:     make(map[int]string),
+		schemaTypes: make(map[int]string),
+		ids:         make(map[string]int),
+		subjects:    make(map[string][]subjectVersion),
+		levels:      make(map[string]string),
+		globalLevel: kafka.CompatibilityBackward,
+	}
+}
+
+// GetSchema returns codec of schema by id.
+func (r *SchemaRegistry) GetSchema(_ context.Context, id int) (*goavro.Codec, error) {
+	r.mu.RLock()
+	schema, ok := r.schemas[id]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, &kafka.Error{ErrorCode: schemaNotFoundErrorCode, Message: fmt.Sprintf("Schema %d not found", id)}
+	}
+
+	return goavro.NewCodec(schema)
+}
+
+// GetSubjects returns sorted subjects.
+func (r *SchemaRegistry) GetSubjects(_ context.Context) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subjects := make([]string, 0, len(r.subjects))
+	for subject := range r.subjects {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+
+	return subjects, nil
+}
+
+// GetVersions returns versions of subject.
+func (r *SchemaRegistry) GetVersions(_ context.Context, subject string) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subjectVersions, err := r.subjectVersions(subject)
+	if err != nil {
+		return []int{}, err
+	}
+
+	versions := make([]int, 0, len(subjectVersions))
+	for _, subjectVersion := range subjectVersions {
+		versions = append(versions, subjectVersion.version)
+	}
+
+	return versions, nil
+}
+
+// GetSchemaByVersion returns codec of version of subject.
+func (r *SchemaRegistry) GetSchemaByVersion(ctx context.Context, subject string, version int) (*goavro.Codec, error) {
+	r.mu.RLock()
+	subjectVersion, err := r.subjectVersion(subject, version)
+	r.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetSchema(ctx, subjectVersion.id)
+}
+
+// GetLatestSchema returns codec of latest version of subject.
+func (r *SchemaRegistry) GetLatestSchema(ctx context.Context, subject string) (*goavro.Codec, error) {
+	r.mu.RLock()
+	subjectVersion, err := r.latestVersion(subject)
+	r.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetSchema(ctx, subjectVersion.id)
+}
+
+// LatestVersion returns number and id of latest version of subject.
+func (r *SchemaRegistry) LatestVersion(subject string) (version int, id int, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subjectVersion, err := r.latestVersion(subject)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return subjectVersion.version, subjectVersion.id, nil
+}
+
+func (r *SchemaRegistry) versionID(subject string, version int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subjectVersion, err := r.subjectVersion(subject, version)
+
+	return subjectVersion.id, err
+}
+
+// CreateSubject registers schema in subject and returns its id, the same schema gets the same id in all subjects.
+// Schema which is incompatible with compatibility level of subject is rejected with error 409.
+func (r *SchemaRegistry) CreateSubject(_ context.Context, subject string, codec *goavro.Codec) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.register(subject, codec.Schema(), kafka.SchemaTypeAvro)
+}
+
+// RegisterSchema registers schema of any type in subject, compatibility is checked only for AVRO schemas.
+func (r *SchemaRegistry) RegisterSchema(ctx context.Context, subject string, schema kafka.Schema) (int, error) {
+	if schema.SchemaType == "" || schema.SchemaType == kafka.SchemaTypeAvro {
+		codec, err := goavro.NewCodec(schema.Schema)
+		if err != nil {
+			return 0, &kafka.Error{ErrorCode: invalidSchemaErrorCode, Message: fmt.Sprintf("Invalid schema: %v", err)}
+		}
+
+		return r.CreateSubject(ctx, subject, codec)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.register(subject, schema.Schema, schema.SchemaType)
+}
+
+// GetRawSchema returns schema of any type by id.
+func (r *SchemaRegistry) GetRawSchema(_ context.Context, id int) (*kafka.Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.schemas[id]
+	if !ok {
+		return nil, &kafka.Error{ErrorCode: schemaNotFoundErrorCode, Message: fmt.Sprintf("Schema %d not found", id)}
+	}
+
+	return &kafka.Schema{Schema: schema, SchemaType: r.schemaTypes[id]}, nil
+}
+
+func (r *SchemaRegistry) register(subject string, schema string, schemaType string) (int, error) {
+	subjectVersions := r.subjects[subject]
+	for _, subjectVersion := range subjectVersions {
+		if r.schemas[subjectVersion.id] == schema {
+			return subjectVersion.id, nil
+		}
+	}
+
+	// compatibility of protobuf and json schemas is not checked.
+	if schemaType == kafka.SchemaTypeAvro {
+		if messages := r.checkCompatibility(subject, schema); len(messages) > 0 {
+			return 0, &kafka.Error{ErrorCode: incompatibleSchemaErrorCode, Message: fmt.Sprintf("Schema is incompatible with an earlier schema for subject %q: %v", subject, messages)}
+		}
+	}
+
+	id, ok := r.ids[schema]
+	if !ok {
+		id = len(r.ids) + 1
+		r.ids[schema] = id
+		r.schemas[id] = schema
+		r.schemaTypes[id] = schemaType
+	}
+
+	version := 1
+	if len(subjectVersions) > 0 {
+		version = subjectVersions[len(subjectVersions)-1].version + 1
+	}
+	r.subjects[subject] = append(subjectVersions, subjectVersion{version: version, id: id})
+
+	return id, nil
+}
+
+// IsSchemaRegistered returns id of schema when it is registered in subject.
+func (r *SchemaRegistry) IsSchemaRegistered(_ context.Context, subject string, codec *goavro.Codec) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subjectVersions, err := r.subjectVersions(subject)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, subjectVersion := range subjectVersions {
+		if r.schemas[subjectVersion.id] == codec.Schema() {
+			return subjectVersion.id, nil
+		}
+	}
+
+	return 0, &kafka.Error{ErrorCode: schemaNotFoundErrorCode, Message: "Schema not found"}
+}
+
+// DeleteSubject deletes all versions of subject, schemas are still available by id.
+func (r *SchemaRegistry) DeleteSubject(_ context.Context, subject string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.subjectVersions(subject); err != nil {
+		return err
+	}
+	delete(r.subjects, subject)
+
+	return nil
+}
+
+// DeleteVersion deletes version of subject.
+func (r *SchemaRegistry) DeleteVersion(_ context.Context, subject string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subjectVersions, err := r.subjectVersions(subject)
+	if err != nil {
+		return err
+	}
+
+	for i, subjectVersion := range subjectVersions {
+		if subjectVersion.version == version {
+			r.subjects[subject] = append(subjectVersions[:i:i], subjectVersions[i+1:]...)
+			if len(r.subjects[subject]) == 0 {
+				delete(r.subjects, subject)
+			}
+
+			return nil
+		}
+	}
+
+	return &kafka.Error{ErrorCode: versionNotFoundErrorCode, Message: fmt.Sprintf("Version %d not found", version)}
+}
+
+// TestCompatibility checks schema by compatibility level of subject, schema is compatible when subject does not exist.
+func (r *SchemaRegistry) TestCompatibility(_ context.Context, subject string, codec *goavro.Codec) (*kafka.CompatibilityResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	messages := r.checkCompatibility(subject, codec.Schema())
+
+	return &kafka.CompatibilityResult{IsCompatible: len(messages) == 0, Messages: messages}, nil
+}
+
+// GetCompatibilityLevel returns level of subject or global level.
+func (r *SchemaRegistry) GetCompatibilityLevel(_ context.Context, subject string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.compatibilityLevel(subject), nil
+}
+
+// SetCompatibilityLevel changes level of subject, empty subject changes global level.
+func (r *SchemaRegistry) SetCompatibilityLevel(_ context.Context, subject string, level string) error {
+	switch level {
+	case kafka.CompatibilityNone,
+		kafka.CompatibilityBackward,
+		kafka.CompatibilityBackwardTransitive,
+		kafka.CompatibilityForward,
+		kafka.CompatibilityForwardTransitive,
+		kafka.CompatibilityFull,
+		kafka.CompatibilityFullTransitive:
+	default:
+		return &kafka.Error{ErrorCode: invalidCompatibilityErrorCode, Message: fmt.Sprintf("Invalid compatibility level %q", level)}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if subject == "" {
+		r.globalLevel = level
+
+		return nil
+	}
+	r.levels[subject] = level
+
+	return nil
+}
+
+func (r *SchemaRegistry) compatibilityLevel(subject string) string {
+	if level, ok := r.levels[subject]; ok {
+		return level
+	}
+
+	return r.globalLevel
+}
+
+// checkCompatibility returns reasons why schema can not be registered in subject.
+func (r *SchemaRegistry) checkCompatibility(subject string, schema string) []string {
+	subjectVersions := r.subjects[subject]
+	if len(subjectVersions) == 0 {
+		return nil
+	}
+
+	level := r.compatibilityLevel(subject)
+	backward, forward, transitive := false, false, false
+	switch level {
+	case kafka.CompatibilityBackward:
+		backward = true
+	case kafka.CompatibilityBackwardTransitive:
+		backward, transitive = true, true
+	case kafka.CompatibilityForward:
+		forward = true
+	case kafka.CompatibilityForwardTransitive:
+		forward, transitive = true, true
+	case kafka.CompatibilityFull:
+		backward, forward = true, true
+	case kafka.CompatibilityFullTransitive:
+		backward, forward, transitive = true, true, true
+	}
+
+	checkedVersions := subjectVersions[len(subjectVersions)-1:]
+	if transitive {
+		checkedVersions = subjectVersions
+	}
+
+	var messages []string
+	for _, subjectVersion := range checkedVersions {
+		oldSchema := r.schemas[subjectVersion.id]
+		// new consumers must read messages of old producers.
+		if backward {
+			if err := canRead(oldSchema, schema); err != nil {
+				messages = append(messages, fmt.Sprintf("version %d: %v", subjectVersion.version, err))
+			}
+		}
+
+		// old consumers must read messages of new producers.
+		if forward {
+			if err := canRead(schema, oldSchema); err != nil {
+				messages = append(messages, fmt.Sprintf("version %d: %v", subjectVersion.version, err))
+			}
+		}
+	}
+
+	return messages
+}
+
+func (r *SchemaRegistry) subjectVersions(subject string) ([]subjectVersion, error) {
+	subjectVersions, ok := r.subjects[subject]
+	if !ok {
+		return nil, &kafka.Error{ErrorCode: subjectNotFoundErrorCode, Message: fmt.Sprintf("Subject '%s' not found.", subject)}
+	}
+
+	return subjectVersions, nil
+}
+
+func (r *SchemaRegistry) subjectVersion(subject string, version int) (subjectVersion, error) {
+	subjectVersions, err := r.subjectVersions(subject)
+	if err != nil {
+		return subjectVersion{}, err
+	}
+
+	for _, subjectVersion := range subjectVersions {
+		if subjectVersion.version == version {
+			return subjectVersion, nil
+		}
+	}
+
+	return subjectVersion{}, &kafka.Error{ErrorCode: versionNotFoundErrorCode, Message: fmt.Sprintf("Version %d not found.", version)}
+}
+
+func (r *SchemaRegistry) latestVersion(subject string) (subjectVersion, error) {
+	subjectVersions, err := r.subjectVersions(subject)
+	if err != nil {
+		return subjectVersion{}, err
+	}
+
+	return subjectVersions[len(subjectVersions)-1], nil
+}
+
+// canRead checks that reader schema can resolve messages of writer schema.
+func canRead(writerSchema string, readerSchema string) error {
+	_, err := compiler.CompileSchemaBytes([]byte(writerSchema), []byte(readerSchema))
+
+	return err
+}
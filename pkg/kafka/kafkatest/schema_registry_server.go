@@ -0,0 +1,295 @@
+package kafkatest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/linkedin/goavro"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+type schemaRequest struct {
+	Schema     string                  `json:"schema"`
+	SchemaType string                  `json:"schemaType,omitempty"`
+	References []kafka.SchemaReference `json:"references,omitempty"`
+}
+
+type schemaVersionResponse struct {
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+	ID         int    `json:"id"`
+}
+
+type compatibilityRequest struct {
+	Compatibility string `json:"compatibility"`
+}
+
+type compatibilityLevelResponse struct {
+	CompatibilityLevel string `json:"compatibilityLevel"`
+}
+
+type schemaRegistryHandler struct {
+	registry *SchemaRegistry
+}
+
+// NewSchemaRegistryServer starts http server which speaks REST protocol of Confluent schema registry,
+// so real SchemaRegistryClient can be used with server.URL. Test must close server.
+func NewSchemaRegistryServer(registry *SchemaRegistry) *httptest.Server {
+	return httptest.NewServer(NewSchemaRegistryHandler(registry))
+}
+
+// NewSchemaRegistryHandler creates http handler of schema registry with schemas of registry.
+func NewSchemaRegistryHandler(registry *SchemaRegistry) http.Handler {
+	h := &schemaRegistryHandler{registry: registry}
+	router := mux.NewRouter()
+	router.HandleFunc("/schemas/ids/{id:[0-9]+}", h.getSchema).Methods(http.MethodGet)
+	router.HandleFunc("/subjects", h.getSubjects).Methods(http.MethodGet)
+	router.HandleFunc("/subjects/{subject}", h.lookupSchema).Methods(http.MethodPost)
+	router.HandleFunc("/subjects/{subject}", h.deleteSubject).Methods(http.MethodDelete)
+	router.HandleFunc("/subjects/{subject}/versions", h.getVersions).Methods(http.MethodGet)
+	router.HandleFunc("/subjects/{subject}/versions", h.createSubject).Methods(http.MethodPost)
+	router.HandleFunc("/subjects/{subject}/versions/{version}", h.getSchemaByVersion).Methods(http.MethodGet)
+	router.HandleFunc("/subjects/{subject}/versions/{version:[0-9]+}", h.deleteVersion).Methods(http.MethodDelete)
+	router.HandleFunc("/compatibility/subjects/{subject}/versions/latest", h.testCompatibility).Methods(http.MethodPost)
+	router.HandleFunc("/config/{subject}", h.getCompatibilityLevel).Methods(http.MethodGet)
+	router.HandleFunc("/config/{subject}", h.setCompatibilityLevel).Methods(http.MethodPut)
+	router.HandleFunc("/config", h.setCompatibilityLevel).Methods(http.MethodPut)
+
+	return router
+}
+
+func (h *schemaRegistryHandler) getSchema(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	schema, err := h.registry.GetRawSchema(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	// registry does not return type of avro schemas.
+	if schema.SchemaType == kafka.SchemaTypeAvro {
+		schema.SchemaType = ""
+	}
+
+	writeJSON(w, schemaRequest{Schema: schema.Schema, SchemaType: schema.SchemaType})
+}
+
+func (h *schemaRegistryHandler) getSubjects(w http.ResponseWriter, r *http.Request) {
+	subjects, _ := h.registry.GetSubjects(r.Context())
+	writeJSON(w, subjects)
+}
+
+func (h *schemaRegistryHandler) getVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.registry.GetVersions(r.Context(), mux.Vars(r)["subject"])
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, versions)
+}
+
+func (h *schemaRegistryHandler) getSchemaByVersion(w http.ResponseWriter, r *http.Request) {
+	subject := mux.Vars(r)["subject"]
+	versionParam := mux.Vars(r)["version"]
+
+	var version, id int
+	var err error
+	if versionParam == "latest" {
+		version, id, err = h.registry.LatestVersion(subject)
+	} else {
+		version, err = strconv.Atoi(versionParam)
+		if err != nil {
+			writeError(w, &kafka.Error{ErrorCode: versionNotFoundErrorCode, Message: fmt.Sprintf("Version %s not found.", versionParam)})
+
+			return
+		}
+
+		id, err = h.registry.versionID(subject, version)
+	}
+
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	schema, err := h.registry.GetRawSchema(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	if schema.SchemaType == kafka.SchemaTypeAvro {
+		schema.SchemaType = ""
+	}
+
+	writeJSON(w, schemaVersionResponse{Subject: subject, Version: version, Schema: schema.Schema, SchemaType: schema.SchemaType, ID: id})
+}
+
+func (h *schemaRegistryHandler) createSubject(w http.ResponseWriter, r *http.Request) {
+	var request schemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, &kafka.Error{ErrorCode: invalidSchemaErrorCode, Message: err.Error()})
+
+		return
+	}
+
+	id, err := h.registry.RegisterSchema(r.Context(), mux.Vars(r)["subject"], kafka.Schema{
+		Schema:     request.Schema,
+		SchemaType: request.SchemaType,
+		References: request.References,
+	})
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, schemaVersionResponse{ID: id})
+}
+
+func (h *schemaRegistryHandler) lookupSchema(w http.ResponseWriter, r *http.Request) {
+	codec, ok := readSchema(w, r)
+	if !ok {
+		return
+	}
+
+	subject := mux.Vars(r)["subject"]
+	id, err := h.registry.IsSchemaRegistered(r.Context(), subject, codec)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, schemaVersionResponse{Subject: subject, Schema: codec.Schema(), ID: id})
+}
+
+func (h *schemaRegistryHandler) deleteSubject(w http.ResponseWriter, r *http.Request) {
+	subject := mux.Vars(r)["subject"]
+	versions, err := h.registry.GetVersions(r.Context(), subject)
+	if err == nil {
+		err = h.registry.DeleteSubject(r.Context(), subject)
+	}
+
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, versions)
+}
+
+func (h *schemaRegistryHandler) deleteVersion(w http.ResponseWriter, r *http.Request) {
+	version, _ := strconv.Atoi(mux.Vars(r)["version"])
+	if err := h.registry.DeleteVersion(r.Context(), mux.Vars(r)["subject"], version); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, version)
+}
+
+func (h *schemaRegistryHandler) testCompatibility(w http.ResponseWriter, r *http.Request) {
+	codec, ok := readSchema(w, r)
+	if !ok {
+		return
+	}
+
+	subject := mux.Vars(r)["subject"]
+	if _, err := h.registry.GetVersions(r.Context(), subject); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	result, err := h.registry.TestCompatibility(r.Context(), subject, codec)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	if result.Messages == nil {
+		result.Messages = []string{}
+	}
+
+	writeJSON(w, result)
+}
+
+func (h *schemaRegistryHandler) getCompatibilityLevel(w http.ResponseWriter, r *http.Request) {
+	level, _ := h.registry.GetCompatibilityLevel(r.Context(), mux.Vars(r)["subject"])
+	writeJSON(w, compatibilityLevelResponse{CompatibilityLevel: level})
+}
+
+func (h *schemaRegistryHandler) setCompatibilityLevel(w http.ResponseWriter, r *http.Request) {
+	var request compatibilityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, &kafka.Error{ErrorCode: invalidCompatibilityErrorCode, Message: err.Error()})
+
+		return
+	}
+
+	if err := h.registry.SetCompatibilityLevel(r.Context(), mux.Vars(r)["subject"], request.Compatibility); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, request)
+}
+
+func readSchema(w http.ResponseWriter, r *http.Request) (*goavro.Codec, bool) {
+	var request schemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, &kafka.Error{ErrorCode: invalidSchemaErrorCode, Message: err.Error()})
+
+		return nil, false
+	}
+
+	codec, err := goavro.NewCodec(request.Schema)
+	if err != nil {
+		writeError(w, &kafka.Error{ErrorCode: invalidSchemaErrorCode, Message: fmt.Sprintf("Invalid schema: %v", err)})
+
+		return nil, false
+	}
+
+	return codec, true
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", contentType)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError sends error in format of schema registry, status is the first three digits of error code.
+func writeError(w http.ResponseWriter, err error) {
+	var registryErr *kafka.Error
+	if !errors.As(err, &registryErr) {
+		registryErr = &kafka.Error{ErrorCode: http.StatusInternalServerError, Message: err.Error()}
+	}
+
+	status := registryErr.ErrorCode
+	for status >= 1000 {
+		status /= 10
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(registryErr)
+}
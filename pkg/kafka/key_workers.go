@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// keyWorkerQueueSize how many messages wait for every worker, when queue is full partition waits.
+const keyWorkerQueueSize = 16
+
+// offsetTracker marks offset of partition only up to the lowest message which is not handled yet,
+// so after restart consumer reads again all messages which were not handled.
+type offsetTracker struct {
+	mu      sync.Mutex
+	pending []int64
+	done    map[int64]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{done: make(map[int64]bool)}
+}
+
+// add remembers offset of message which was sent to worker, offsets come in increasing order.
+func (t *offsetTracker) add(offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = append(t.pending, offset)
+}
+
+// complete marks message as handled and returns offset which can be committed, false when the lowest message is still in progress.
+func (t *offsetTracker) complete(offset int64) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done[offset] = true
+	committed := int64(-1)
+	for len(t.pending) > 0 && t.done[t.pending[0]] {
+		committed = t.pending[0] + 1
+		delete(t.done, t.pending[0])
+		t.pending = t.pending[1:]
+	}
+
+	return committed, committed >= 0
+}
+
+// consumeClaimByKeys dispatches messages of partition to workers by key, messages with the same key are handled
+// by the same worker one by one, so order of messages is kept for every key.
+func (consumer *Consumer) consumeClaimByKeys(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx, cancel := context.WithCancel(session.Context())
+	defer cancel()
+
+	tracker := newOffsetTracker()
+	queues := make([]chan *sarama.ConsumerMessage, consumer.keyWorkers)
+	waitGroup := &sync.WaitGroup{}
+
+	var errOnce sync.Once
+	var handleErr error
+	for i := range queues {
+		queues[i] = make(chan *sarama.ConsumerMessage, keyWorkerQueueSize)
+		waitGroup.Add(1)
+		go func(queue <-chan *sarama.ConsumerMessage) {
+			defer waitGroup.Done()
+			for message := range queue {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				if err := consumer.handleMessage(ctx, nil, message); err != nil {
+					errOnce.Do(func() {
+						handleErr = err
+					})
+					cancel()
+
+					continue
+				}
+
+				if offset, ok := tracker.complete(message.Offset); ok {
+					session.MarkOffset(message.Topic, message.Partition, offset, "")
+					consumer.observeLag(claim, offset)
+				}
+			}
+		}(queues[i])
+	}
+
+	err := consumer.dispatchByKeys(ctx, claim, queues, tracker)
+	for _, queue := range queues {
+		close(queue)
+	}
+	waitGroup.Wait()
+
+	if handleErr != nil && session.Context().Err() == nil {
+		return handleErr
+	}
+
+	if session.Context().Err() != nil {
+		return nil
+	}
+
+	return err
+}
+
+func (consumer *Consumer) dispatchByKeys(
+	ctx context.Context,
+	claim sarama.ConsumerGroupClaim,
+	queues []chan *sarama.ConsumerMessage,
+	tracker *offsetTracker,
+) error {
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return errMessageChannelClosed
+			}
+
+			consumer.logClaimedMessage(message)
+			tracker.add(message.Offset)
+			select {
+			case queues[keyWorker(message, len(queues))] <- message:
+			case <-ctx.Done():
+				return nil
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// keyWorker returns number of worker for message, messages without key are spread by offset.
+func keyWorker(message *sarama.ConsumerMessage, workers int) int {
+	if len(message.Key) == 0 {
+		return int(message.Offset % int64(workers))
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write(message.Key)
+
+	return int(hash.Sum32() % uint32(workers))
+}
@@ -0,0 +1,141 @@
+package kafka_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+// orderRecordingHandler remembers order of handled events for every key, events of the first key are handled slower.
+type orderRecordingHandler struct {
+	mu      sync.Mutex
+	handled map[string][]int
+}
+
+func (h *orderRecordingHandler) HandleMessage(_ context.Context, event testEvent) error {
+	var key string
+	var number int
+	if _, err := fmt.Sscanf(strings.Replace(event.ID, "-", " ", 1), "%s %d", &key, &number); err != nil {
+		return err
+	}
+
+	if key == "courier0" {
+		time.Sleep(time.Millisecond)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handled[key] = append(h.handled[key], number)
+
+	return nil
+}
+
+// releasedHandler handles event "slow" only when it is released, other events are handled at once.
+type releasedHandler struct {
+	release chan struct{}
+	handled chan string
+}
+
+func (h releasedHandler) HandleMessage(ctx context.Context, event testEvent) error {
+	if event.ID == "slow" {
+		select {
+		case <-h.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	h.handled <- event.ID
+
+	return nil
+}
+
+func newKeyWorkersConsumer(t *testing.T, broker *kafkatest.Broker, registry *kafkatest.SchemaRegistry, handler kafka.Handler[testEvent]) *kafka.Consumer {
+	t.Helper()
+
+	serde := kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema)
+	consumer, err := kafka.NewSerdeConsumer(kafka.NewSerdeHandler[testEvent](serde, handler), fakeConsumerOptions(broker, registry, "in"))
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	consumer.SetKeyWorkers(4)
+
+	return consumer
+}
+
+func TestKeyWorkersHandleMessagesOfKeyInOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	for number := range 10 {
+		for courier := range 3 {
+			key := fmt.Sprintf("courier%d", courier)
+			publishKeyedTestEvent(t, broker, registry, "in", key, fmt.Sprintf("%s-%d", key, number))
+		}
+	}
+
+	handler := &orderRecordingHandler{handled: make(map[string][]int)}
+	done := runConsumer(ctx, t, newKeyWorkersConsumer(t, broker, registry, handler))
+	if err := broker.WaitCommittedOffset(ctx, "in", "in", 0, 30); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-done
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	for key, numbers := range handler.handled {
+		if len(numbers) != 10 {
+			t.Fatalf("expected 10 messages of %s, got %v", key, numbers)
+		}
+
+		for i, number := range numbers {
+			if number != i {
+				t.Fatalf("expected messages of %s in order, got %v", key, numbers)
+			}
+		}
+	}
+}
+
+func TestKeyWorkersDoNotCommitPastMessageInProgress(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	ids := []string{"first", "slow", "courier1", "courier2", "courier3", "courier4", "courier5", "courier6"}
+	for _, id := range ids {
+		publishTestEvent(t, broker, registry, "in", id)
+	}
+
+	handler := releasedHandler{release: make(chan struct{}), handled: make(chan string, len(ids))}
+	done := runConsumer(ctx, t, newKeyWorkersConsumer(t, broker, registry, handler))
+
+	// keys go to different workers, so messages after slow one are handled while it is in progress.
+	handled := map[string]bool{receiveID(ctx, t, handler.handled): true}
+	for !handled["first"] || len(handled) < 3 {
+		handled[receiveID(ctx, t, handler.handled)] = true
+	}
+
+	if err := broker.WaitCommittedOffset(ctx, "in", "in", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if offset := broker.CommittedOffset("in", "in", 0); offset != 1 {
+		t.Fatalf("expected offset of slow message committed while it is in progress, got %d", offset)
+	}
+
+	close(handler.release)
+	if err := broker.WaitCommittedOffset(ctx, "in", "in", 0, int64(len(ids))); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,162 @@
+package kafka
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are registered in default prometheus registry, service exposes them by promhttp.Handler.
+var (
+	consumedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kafka",
+		Subsystem: "consumer",
+		Name:      "messages_consumed_total",
+		Help:      "Number of messages which consumer group read from topic.",
+	}, []string{"group", "topic"})
+
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "kafka",
+		Subsystem: "consumer",
+		Name:      "handler_duration_seconds",
+		Help:      "Duration of one attempt to handle message, status is success or error.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"group", "topic", "status"})
+
+	decodeFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kafka",
+		Subsystem: "consumer",
+		Name:      "decode_failures_total",
+		Help:      "Number of messages which consumer group could not decode.",
+	}, []string{"group", "topic"})
+
+	consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "kafka",
+		Subsystem: "consumer",
+		Name:      "lag",
+		Help:      "High water mark of partition minus committed offset of consumer group.",
+	}, []string{"group", "topic", "partition"})
+
+	producedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kafka",
+		Subsystem: "publisher",
+		Name:      "messages_produced_total",
+		Help:      "Number of messages which were sent in topic, status is delivered or failed.",
+	}, []string{"topic", "status"})
+
+	schemaCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kafka",
+		Subsystem: "schema_registry",
+		Name:      "cache_requests_total",
+		Help:      "Number of requests to cache of schema registry client, cache is schema, raw_schema, schema_id, version or latest, result is hit or miss.",
+	}, []string{"cache", "result"})
+)
+
+// defaultLagRefreshInterval is how often consumer reads lag of claimed partitions from brokers.
+const defaultLagRefreshInterval = 30 * time.Second
+
+const (
+	statusSuccess   = "success"
+	statusError     = "error"
+	statusDelivered = "delivered"
+	statusFailed    = "failed"
+	cacheHit        = "hit"
+	cacheMiss       = "miss"
+)
+
+// observeHandler records duration of handler since start.
+func (consumer *Consumer) observeHandler(topic string, start time.Time, err error) {
+	status := statusSuccess
+	if err != nil {
+		status = statusError
+	}
+
+	handlerDuration.WithLabelValues(consumer.group, topic, status).Observe(time.Since(start).Seconds())
+}
+
+// observeLag records lag of partition, committedOffset is offset of the next message which consumer group reads.
+func (consumer *Consumer) observeLag(claim sarama.ConsumerGroupClaim, committedOffset int64) {
+	lag := claim.HighWaterMarkOffset() - committedOffset
+	if lag < 0 {
+		lag = 0
+	}
+
+	consumerLag.WithLabelValues(consumer.group, claim.Topic(), strconv.Itoa(int(claim.Partition()))).Set(float64(lag))
+}
+
+// SetLagRefreshInterval sets how often lag of claimed partitions is read from brokers by offsets client, so lag grows
+// also when consumer does not get messages, for example when partition is paused or handler is stuck. Zero disables it.
+func (consumer *Consumer) SetLagRefreshInterval(interval time.Duration) {
+	consumer.lagRefreshInterval = interval
+}
+
+// refreshLag records lag of claimed partitions by committed offsets every lag refresh interval till ctx is done.
+func (consumer *Consumer) refreshLag(ctx context.Context) {
+	if consumer.offsetsClient == nil || consumer.lagRefreshInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(consumer.lagRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			consumer.observeClaimedLag()
+		}
+	}
+}
+
+func (consumer *Consumer) observeClaimedLag() {
+	for topic, partitions := range consumer.claims() {
+		for _, partition := range partitions {
+			state, err := consumer.partitionState(topic, partition)
+			if err != nil {
+				log.Printf("failed to refresh lag of %s/%d: %v\n", topic, partition, err)
+
+				continue
+			}
+
+			// partition could be revoked while we read offsets, its lag is deleted by cleanup of session.
+			if !consumer.isClaimed(topic, partition) {
+				continue
+			}
+
+			consumerLag.WithLabelValues(consumer.group, topic, strconv.Itoa(int(partition))).Set(float64(state.Lag))
+		}
+	}
+}
+
+// deleteLag removes lag of partitions which consumer does not read any more after rebalance.
+func (consumer *Consumer) deleteLag(claims map[string][]int32) {
+	for topic, partitions := range claims {
+		for _, partition := range partitions {
+			consumerLag.DeleteLabelValues(consumer.group, topic, strconv.Itoa(int(partition)))
+		}
+	}
+}
+
+func observeProduced(topic string, err error) {
+	status := statusDelivered
+	if err != nil {
+		status = statusFailed
+	}
+
+	producedMessages.WithLabelValues(topic, status).Inc()
+}
+
+func observeSchemaCache(cache string, isHit bool) {
+	result := cacheMiss
+	if isHit {
+		result = cacheHit
+	}
+
+	schemaCacheRequests.WithLabelValues(cache, result).Inc()
+}
@@ -0,0 +1,86 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+// blockingHandler handles no message till ctx of consumer is done.
+type blockingHandler struct{}
+
+func (h blockingHandler) HandleMessage(ctx context.Context, _ testEvent) error {
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+// consumerLag returns value of lag gauge of partition from default registry.
+func consumerLag(t *testing.T, group string, topic string, partition string) (float64, bool) {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "kafka_consumer_lag" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["group"] == group && labels["topic"] == topic && labels["partition"] == partition {
+				return metric.GetGauge().GetValue(), true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func TestConsumerRefreshesLagWithoutMessages(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	for _, id := range []string{"order-1", "order-2", "order-3"} {
+		publishTestEvent(t, broker, registry, "lag", id)
+	}
+
+	consumer, err := kafka.NewSerdeConsumer(
+		kafka.NewSerdeHandler[testEvent](kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema), blockingHandler{}),
+		fakeConsumerOptions(broker, registry, "lag"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	consumer.SetOffsetsClient(broker, "lag")
+	consumer.SetLagRefreshInterval(10 * time.Millisecond)
+	done := runConsumer(ctx, t, consumer)
+
+	for {
+		if lag, ok := consumerLag(t, "lag", "lag", "0"); ok && lag == 3 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("lag of stuck partition was not refreshed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	<-done
+}
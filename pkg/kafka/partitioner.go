@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	// PartitionerMurmur2 chooses partition by murmur2 hash of key like default partitioner of the java client,
+	// so java and go producers put the same key in the same partition.
+	PartitionerMurmur2 = "murmur2"
+	// PartitionerConsistent chooses partition by crc32 hash of key like consistent partitioner of librdkafka.
+	PartitionerConsistent = "consistent"
+	// PartitionerRoundRobin ignores key and sends messages to partitions one by one.
+	PartitionerRoundRobin = "round_robin"
+)
+
+// NewPartitioner returns sarama partitioner by name, messages without key go to random partition for hash partitioners.
+func NewPartitioner(name string) (sarama.PartitionerConstructor, error) {
+	switch name {
+	case PartitionerMurmur2, "":
+		return sarama.NewCustomPartitioner(
+			sarama.WithCustomHashFunction(newMurmur2Hash),
+			sarama.WithAbsFirst(),
+		), nil
+	case PartitionerConsistent:
+		return sarama.NewConsistentCRCHashPartitioner, nil
+	case PartitionerRoundRobin:
+		return sarama.NewRoundRobinPartitioner, nil
+	}
+
+	return nil, fmt.Errorf("unknown partitioner %q", name)
+}
+
+// murmur2Hash is murmur2 from org.apache.kafka.common.utils.Utils, hash is calculated when Sum32 is called.
+type murmur2Hash struct {
+	data []byte
+}
+
+func newMurmur2Hash() hash.Hash32 {
+	return &murmur2Hash{}
+}
+
+func (h *murmur2Hash) Write(p []byte) (int, error) {
+	h.data = append(h.data, p...)
+
+	return len(p), nil
+}
+
+func (h *murmur2Hash) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint32(b, h.Sum32())
+}
+
+func (h *murmur2Hash) Reset() {
+	h.data = h.data[:0]
+}
+
+func (h *murmur2Hash) Size() int {
+	return 4
+}
+
+func (h *murmur2Hash) BlockSize() int {
+	return 4
+}
+
+func (h *murmur2Hash) Sum32() uint32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+
+	length := len(h.data)
+	hash := seed ^ uint32(length)
+	tail := length &^ 3
+	for i := 0; i < tail; i += 4 {
+		k := binary.LittleEndian.Uint32(h.data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		hash *= m
+		hash ^= k
+	}
+
+	switch length & 3 {
+	case 3:
+		hash ^= uint32(h.data[tail+2]) << 16
+		fallthrough
+	case 2:
+		hash ^= uint32(h.data[tail+1]) << 8
+		fallthrough
+	case 1:
+		hash ^= uint32(h.data[tail])
+		hash *= m
+	}
+
+	hash ^= hash >> 13
+	hash *= m
+	hash ^= hash >> 15
+
+	return hash
+}
@@ -0,0 +1,79 @@
+package kafka_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+)
+
+// murmur2Vectors are hashes of org.apache.kafka.common.utils.UtilsTest.testMurmur2 of the java client.
+var murmur2Vectors = []struct {
+	key  string
+	hash int32
+	// partitions of 12 and 100 partitions by toPositive(murmur2(key)) % partitions of java default partitioner.
+	partitionOf12  int32
+	partitionOf100 int32
+}{
+	{key: "21", hash: -973932308, partitionOf12: 0, partitionOf100: 40},
+	{key: "foobar", hash: -790332482, partitionOf12: 6, partitionOf100: 66},
+	{key: "a-little-bit-long-string", hash: -985981536, partitionOf12: 8, partitionOf100: 12},
+	{key: "a-little-bit-longer-string", hash: -1486304829, partitionOf12: 11, partitionOf100: 19},
+	{key: "lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", hash: -58897971, partitionOf12: 5, partitionOf100: 77},
+	{key: "abc", hash: 479470107, partitionOf12: 3, partitionOf100: 7},
+}
+
+func partitionOfKey(t *testing.T, partitioner sarama.Partitioner, key string, partitions int32) int32 {
+	t.Helper()
+
+	partition, err := partitioner.Partition(&sarama.ProducerMessage{Topic: "orders.v1", Key: sarama.StringEncoder(key)}, partitions)
+	if err != nil {
+		t.Fatalf("failed to choose partition of %s: %v", key, err)
+	}
+
+	return partition
+}
+
+func TestMurmur2PartitionerMatchesJavaClient(t *testing.T) {
+	constructor, err := kafka.NewPartitioner(kafka.PartitionerMurmur2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partitioner := constructor("orders.v1")
+
+	for _, vector := range murmur2Vectors {
+		// with max int32 partitions partition is positive hash itself.
+		if partition := partitionOfKey(t, partitioner, vector.key, math.MaxInt32); partition != vector.hash&0x7fffffff {
+			t.Fatalf("expected positive murmur2 %d of %s, got %d", vector.hash&0x7fffffff, vector.key, partition)
+		}
+
+		if partition := partitionOfKey(t, partitioner, vector.key, 12); partition != vector.partitionOf12 {
+			t.Fatalf("expected partition %d of 12 for %s, got %d", vector.partitionOf12, vector.key, partition)
+		}
+
+		if partition := partitionOfKey(t, partitioner, vector.key, 100); partition != vector.partitionOf100 {
+			t.Fatalf("expected partition %d of 100 for %s, got %d", vector.partitionOf100, vector.key, partition)
+		}
+	}
+}
+
+func TestDefaultPartitionerIsMurmur2(t *testing.T) {
+	constructor, err := kafka.NewPartitioner("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	partitioner := constructor("orders.v1")
+
+	for _, vector := range murmur2Vectors {
+		if partition := partitionOfKey(t, partitioner, vector.key, 12); partition != vector.partitionOf12 {
+			t.Fatalf("expected murmur2 partition %d for %s, got %d", vector.partitionOf12, vector.key, partition)
+		}
+	}
+}
+
+func TestNewPartitionerRejectsUnknownName(t *testing.T) {
+	if _, err := kafka.NewPartitioner("sticky"); err == nil {
+		t.Fatal("expected error of unknown partitioner")
+	}
+}
@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Acknowledgements which can be used in ProducerOptions.
+const (
+	AcksNone  = "none"
+	AcksLocal = "local"
+	AcksAll   = "all"
+)
+
+// Compression codecs which can be used in ProducerOptions.
+const (
+	CompressionNone   = "none"
+	CompressionGzip   = "gzip"
+	CompressionSnappy = "snappy"
+	CompressionLZ4    = "lz4"
+	CompressionZstd   = "zstd"
+)
+
+// ErrInvalidProducerOptions returns when producer options can not be applied, for example unknown compression.
+var ErrInvalidProducerOptions = errors.New("invalid producer options")
+
+// ProducerOptions describes how publisher batches and acknowledges messages.
+// Zero value keeps previous behaviour: acks of leader, no compression and sarama batching.
+// Options can be parsed from env variables, for example with prefix KAFKA_PUBLISHER_ they are
+// KAFKA_PUBLISHER_COMPRESSION, KAFKA_PUBLISHER_LINGER, KAFKA_PUBLISHER_IDEMPOTENT.
+type ProducerOptions struct {
+	// Acks is none, local or all, empty means local. Idempotent producer always uses all.
+	Acks string `env:"ACKS"`
+	// Idempotent enables idempotent producer, so retries don't write duplicates in partition.
+	Idempotent bool `env:"IDEMPOTENT"`
+	// Compression is none, gzip, snappy, lz4 or zstd, empty means none.
+	Compression string `env:"COMPRESSION"`
+	// Linger is how long producer waits messages for batch, zero sends batch as soon as possible.
+	Linger time.Duration `env:"LINGER"`
+	// BatchSize is size of batch in bytes when producer sends it without waiting linger.
+	BatchSize int `env:"BATCH_SIZE"`
+	// BatchMessages is number of messages in batch when producer sends it without waiting linger.
+	BatchMessages int `env:"BATCH_MESSAGES"`
+	// TransactionalID enables transactions of producer, it is set by NewTransactionalProducerFactory for every partition
+	// of consumer and is not parsed from env. Transactional producer is always idempotent.
+	TransactionalID string
+}
+
+// apply sets acks, idempotence, transactions, compression and batching of sarama config.
+func (options ProducerOptions) apply(config *sarama.Config) error {
+	acks, err := requiredAcks(options.Acks)
+	if err != nil {
+		return err
+	}
+	config.Producer.RequiredAcks = acks
+
+	codec, err := compressionCodec(options.Compression)
+	if err != nil {
+		return err
+	}
+	config.Producer.Compression = codec
+
+	config.Producer.Flush.Frequency = options.Linger
+	config.Producer.Flush.Bytes = options.BatchSize
+	config.Producer.Flush.Messages = options.BatchMessages
+
+	if options.TransactionalID != "" {
+		config.Producer.Transaction.ID = options.TransactionalID
+		options.Idempotent = true
+	}
+
+	if options.Idempotent {
+		// broker keeps order of messages of producer only with one in-flight request.
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Net.MaxOpenRequests = 1
+		if config.Producer.Retry.Max < 1 {
+			config.Producer.Retry.Max = 1
+		}
+	}
+
+	return nil
+}
+
+func requiredAcks(acks string) (sarama.RequiredAcks, error) {
+	switch acks {
+	case AcksNone:
+		return sarama.NoResponse, nil
+	case AcksLocal, "":
+		return sarama.WaitForLocal, nil
+	case AcksAll:
+		return sarama.WaitForAll, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown acks %s", ErrInvalidProducerOptions, acks)
+	}
+}
+
+func compressionCodec(compression string) (sarama.CompressionCodec, error) {
+	switch compression {
+	case CompressionNone, "":
+		return sarama.CompressionNone, nil
+	case CompressionGzip:
+		return sarama.CompressionGZIP, nil
+	case CompressionSnappy:
+		return sarama.CompressionSnappy, nil
+	case CompressionLZ4:
+		return sarama.CompressionLZ4, nil
+	case CompressionZstd:
+		return sarama.CompressionZSTD, nil
+	default:
+		return sarama.CompressionNone, fmt.Errorf("%w: unknown compression %s", ErrInvalidProducerOptions, compression)
+	}
+}
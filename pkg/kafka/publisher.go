@@ -3,68 +3,327 @@ package kafka
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
 
 	"github.com/linkedin/goavro"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Publisher Async send message in kafka.
+// ErrPublisherClosed returns when we publish message after Close.
+var ErrPublisherClosed = errors.New("publisher was closed")
+
+// DeliveryCallback is called when broker acknowledges message or sending fails.
+type DeliveryCallback func(message *sarama.ProducerMessage, err error)
+
+// PublisherStats shows how many messages were delivered or failed.
+type PublisherStats struct {
+	Delivered int64
+	Failed    int64
+}
+
+// deliveryReport is saved in metadata of message, so we know who waits result of sending.
+type deliveryReport struct {
+	callback DeliveryCallback
+	done     chan error
+	span     trace.Span
+}
+
+// Publisher Async send message in kafka, in sync mode PublishMessage waits acknowledgement of broker.
 type Publisher struct {
 	producer             sarama.AsyncProducer
 	topic                string
-	schemaRegistryClient *CachedSchemaRegistryClient
+	schemaRegistryClient SchemaRegistryClientInterface
+	serde                MessageSerde
+	isSync               bool
+	mu                   sync.RWMutex
+	isClosed             bool
+	sending              sync.WaitGroup
+	reportsDone          chan struct{}
+	delivered            atomic.Int64
+	failed               atomic.Int64
+}
+
+// PublisherOptions describes topic of Publisher and how it connects to kafka and schema registry.
+type PublisherOptions struct {
+	Address               []string
+	SchemaRegistryAddress []string
+	Topic                 string
+	// Partitioner chooses partition of message, empty means murmur2 hash of key like java clients.
+	Partitioner string
+	// Security enables TLS and authentication of kafka and schema registry.
+	Security SecurityOptions
+	// Producer sets acks, idempotence, compression and batching of producer.
+	Producer ProducerOptions
+	// Serde serializes messages of PublishValue, for example NewMessageSerde[*pb.Location](protobufSerde).
+	// PublishMessage always sends avro.
+	Serde MessageSerde
 }
 
 // NewPublisher Create new Publisher Async for sending in kafka.
-func NewPublisher(address []string, schemaRegistryServers []string, topic string) (*Publisher, error) {
-	publisher := Publisher{}
+func NewPublisher(options PublisherOptions) (*Publisher, error) {
+	schemaRegistryClient, err := NewCachedSchemaRegistryClientWithOptions(options.SchemaRegistryAddress, options.Security.SchemaRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	producer, err := newAsyncProducer(options)
+	if err != nil {
+		return nil, err
+	}
+
+	publisher := NewPublisherWithProducer(producer, schemaRegistryClient, options.Topic)
+	publisher.SetSerde(options.Serde)
+
+	return publisher, nil
+}
+
+// newAsyncProducer creates sarama producer which returns successes and errors for delivery reports of Publisher.
+func newAsyncProducer(options PublisherOptions) (sarama.AsyncProducer, error) {
+	partitionerConstructor, err := NewPartitioner(options.Partitioner)
+	if err != nil {
+		return nil, err
+	}
+
 	config := sarama.NewConfig()
-	config.Producer.Partitioner = sarama.NewManualPartitioner
-	config.Producer.RequiredAcks = sarama.WaitForLocal
-	producer, err := sarama.NewAsyncProducer(address, config)
-	schemaRegistryClient := NewCachedSchemaRegistryClient(schemaRegistryServers)
-	publisher.schemaRegistryClient = schemaRegistryClient
+	if err := options.Security.apply(config); err != nil {
+		return nil, err
+	}
+	if err := options.Producer.apply(config); err != nil {
+		return nil, err
+	}
+	config.Producer.Partitioner = partitionerConstructor
+	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
 
+	producer, err := sarama.NewAsyncProducer(options.Address, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a new sarama async producer: %w", err)
 	}
 
-	publisher.producer = producer
-	publisher.topic = topic
+	return producer, nil
+}
+
+// NewPublisherWithProducer Create new Publisher with own producer and schema registry client, for example fakes of kafkatest.
+// Producer must return successes and errors.
+func NewPublisherWithProducer(producer sarama.AsyncProducer, schemaRegistryClient SchemaRegistryClientInterface, topic string) *Publisher {
+	publisher := &Publisher{
+		producer:             producer,
+		topic:                topic,
+		schemaRegistryClient: schemaRegistryClient,
+		reportsDone:          make(chan struct{}),
+	}
+	go publisher.handleDeliveryReports()
 
-	return &publisher, nil
+	return publisher
 }
 
-func (publisher *Publisher) publish(message sarama.ProducerMessage) {
-	publisher.producer.Input() <- &message
+// SetSync enables sync mode, PublishMessage waits till broker acknowledges message.
+func (publisher *Publisher) SetSync(isSync bool) {
+	publisher.isSync = isSync
+}
+
+// SetSerde sets serde of PublishValue.
+func (publisher *Publisher) SetSerde(serde MessageSerde) {
+	publisher.serde = serde
+}
+
+// Stats returns counters of delivered and failed messages.
+func (publisher *Publisher) Stats() PublisherStats {
+	return PublisherStats{
+		Delivered: publisher.delivered.Load(),
+		Failed:    publisher.failed.Load(),
+	}
+}
+
+// Close flushes in-flight messages and waits all delivery reports, after that publisher can not send messages.
+func (publisher *Publisher) Close() error {
+	publisher.mu.Lock()
+	if publisher.isClosed {
+		publisher.mu.Unlock()
+
+		return nil
+	}
+	publisher.isClosed = true
+	publisher.mu.Unlock()
+
+	// input of producer is closed only when nobody sends in it.
+	publisher.sending.Wait()
+	publisher.producer.AsyncClose()
+	<-publisher.reportsDone
+
+	return nil
+}
+
+// handleDeliveryReports reads successes and errors of producer, otherwise producer deadlocks when channels are full.
+func (publisher *Publisher) handleDeliveryReports() {
+	defer close(publisher.reportsDone)
+
+	successes := publisher.producer.Successes()
+	errs := publisher.producer.Errors()
+	for successes != nil || errs != nil {
+		select {
+		case message, ok := <-successes:
+			if !ok {
+				successes = nil
+
+				continue
+			}
+
+			publisher.delivered.Add(1)
+			observeProduced(message.Topic, nil)
+			reportDelivery(message, nil)
+
+		case producerErr, ok := <-errs:
+			if !ok {
+				errs = nil
+
+				continue
+			}
+
+			publisher.failed.Add(1)
+			observeProduced(producerErr.Msg.Topic, producerErr.Err)
+			log.Printf("failed to deliver message in topic %s: %v\n", producerErr.Msg.Topic, producerErr.Err)
+			reportDelivery(producerErr.Msg, producerErr.Err)
+		}
+	}
+}
+
+func reportDelivery(message *sarama.ProducerMessage, err error) {
+	report, ok := message.Metadata.(*deliveryReport)
+	if !ok {
+		return
+	}
+
+	if report.span != nil {
+		endProduceSpan(report.span, message, err)
+	}
+
+	if report.callback != nil {
+		report.callback(message, err)
+	}
+
+	if report.done != nil {
+		report.done <- err
+	}
+}
+
+// publish sends message in transaction of partition when message is published by handler of transactional consumer.
+func (publisher *Publisher) publish(ctx context.Context, message *sarama.ProducerMessage, callback DeliveryCallback) error {
+	if transactionPublisher := publisherFromContext(ctx); transactionPublisher != nil && transactionPublisher != publisher {
+		return transactionPublisher.publish(ctx, message, callback)
+	}
+
+	report := &deliveryReport{callback: callback}
+	if publisher.isSync {
+		report.done = make(chan error, 1)
+	}
+	span := startProduceSpan(ctx, message)
+	report.span = span
+	message.Metadata = report
+
+	publisher.mu.RLock()
+	if publisher.isClosed {
+		publisher.mu.RUnlock()
+		endProduceSpan(span, message, ErrPublisherClosed)
+
+		return ErrPublisherClosed
+	}
+	publisher.sending.Add(1)
+	publisher.mu.RUnlock()
+
+	// producer can block input when its buffer is full, so message is sent without lock and Close waits for it.
+	select {
+	case publisher.producer.Input() <- message:
+		publisher.sending.Done()
+	case <-ctx.Done():
+		publisher.sending.Done()
+		endProduceSpan(span, message, ctx.Err())
+
+		return ctx.Err()
+	}
+
+	if report.done == nil {
+		return nil
+	}
+
+	select {
+	case err := <-report.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CheckSchemaCompatibility checks on start of service that schema can be registered in subject of topic,
+// when level is not empty it is set as compatibility level of subject.
+func (publisher *Publisher) CheckSchemaCompatibility(ctx context.Context, schema string, level string) error {
+	return CheckSchemaCompatibility(ctx, publisher.schemaRegistryClient, valueSubject(publisher.topic), schema, level)
 }
 
 // GetSchemaId get schema id from schema-registry service.
-func (publisher *Publisher) GetSchemaId(topic string, avroCodec *goavro.Codec) (int, error) {
-	schemaId, err := publisher.schemaRegistryClient.CreateSubject(topic+"-value", avroCodec)
+func (publisher *Publisher) GetSchemaId(ctx context.Context, topic string, avroCodec *goavro.Codec) (int, error) {
+	schemaId, err := publisher.schemaRegistryClient.CreateSubject(ctx, valueSubject(topic), avroCodec)
 	if err != nil {
 		return 0, err
 	}
 	return schemaId, nil
 }
 
-// PublishMessage  Send async message in kafka.
-func (publisher *Publisher) PublishMessage(ctx context.Context, message []byte, key []byte, schema string) error {
-	avroCodec, err := goavro.NewCodec(schema)
+// PublishValue Send message serialized by serde of publisher, in sync mode it waits acknowledgement of broker.
+func (publisher *Publisher) PublishValue(ctx context.Context, message any, key []byte) error {
+	return publisher.PublishValueWithCallback(ctx, message, key, nil)
+}
+
+// PublishValueWithCallback Send message serialized by serde of publisher and calls callback when broker acknowledges message or sending fails.
+func (publisher *Publisher) PublishValueWithCallback(ctx context.Context, message any, key []byte, callback DeliveryCallback) error {
+	if publisher.serde == nil {
+		return ErrSerdeNotSet
+	}
+
+	value, err := publisher.serde.SerializeMessage(ctx, publisher.topic, message)
 	if err != nil {
 		return err
 	}
 
-	schemaId, err := publisher.GetSchemaId(publisher.topic, avroCodec)
+	return publisher.publishValue(ctx, value, key, callback)
+}
 
+// publishValue sends value which is already in Confluent wire format.
+func (publisher *Publisher) publishValue(ctx context.Context, value []byte, key []byte, callback DeliveryCallback) error {
+	messageKafka := sarama.ProducerMessage{
+		Topic: publisher.topic,
+		Value: sarama.ByteEncoder(value),
+	}
+
+	if key != nil {
+		messageKafka.Key = sarama.StringEncoder(key)
+	}
+
+	return publisher.publish(ctx, &messageKafka, callback)
+}
+
+// PublishMessage  Send async message in kafka, in sync mode it waits acknowledgement of broker.
+func (publisher *Publisher) PublishMessage(ctx context.Context, message []byte, key []byte, schema string) error {
+	return publisher.PublishMessageWithCallback(ctx, message, key, schema, nil)
+}
+
+// PublishMessageWithCallback Send async message in kafka and calls callback when broker acknowledges message or sending fails.
+func (publisher *Publisher) PublishMessageWithCallback(ctx context.Context, message []byte, key []byte, schema string, callback DeliveryCallback) error {
+	avroCodec, err := goavro.NewCodec(schema)
 	if err != nil {
 		return err
 	}
 
-	if schemaId == 0 {
+	schemaId, err := publisher.GetSchemaId(ctx, publisher.topic, avroCodec)
 
+	if err != nil {
+		return err
 	}
 
 	native, _, err := avroCodec.NativeFromTextual(message)
@@ -77,22 +336,24 @@ func (publisher *Publisher) PublishMessage(ctx context.Context, message []byte,
 		return err
 	}
 
-	binaryMsg := &AvroEncoder{
-		SchemaID: schemaId,
-		Content:  binaryValue,
-	}
+	return publisher.publishAvro(ctx, schemaId, binaryValue, key, callback)
+}
+
+// publishAvro sends binary avro content with schema id in Confluent wire format.
+func (publisher *Publisher) publishAvro(ctx context.Context, schemaId int, content []byte, key []byte, callback DeliveryCallback) error {
 	messageKafka := sarama.ProducerMessage{
 		Topic: publisher.topic,
-		Value: binaryMsg,
+		Value: &AvroEncoder{
+			SchemaID: schemaId,
+			Content:  content,
+		},
 	}
 
 	if key != nil {
 		messageKafka.Key = sarama.StringEncoder(key)
 	}
 
-	publisher.publish(messageKafka)
-
-	return nil
+	return publisher.publish(ctx, &messageKafka, callback)
 }
 
 // AvroEncoder encodes schemaId and Avro message.
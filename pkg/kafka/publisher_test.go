@@ -0,0 +1,138 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+func newTestEventPublisher(producer sarama.AsyncProducer, registry *kafkatest.SchemaRegistry) *kafka.Publisher {
+	publisher := kafka.NewPublisherWithProducer(producer, registry, "out")
+	publisher.SetSerde(kafka.NewMessageSerde[testEvent](kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema)))
+
+	return publisher
+}
+
+// newFailingProducer creates producer which fails every message.
+func newFailingProducer(t *testing.T, messages int, err error) *mocks.AsyncProducer {
+	config := mocks.NewTestConfig()
+	config.Producer.Return.Successes = true
+	producer := mocks.NewAsyncProducer(t, config)
+	for range messages {
+		producer.ExpectInputAndFail(err)
+	}
+
+	return producer
+}
+
+func TestPublisherInSyncModeWaitsAcknowledgementOfBroker(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	publisher := newTestEventPublisher(broker.NewAsyncProducer(), kafkatest.NewSchemaRegistry())
+	publisher.SetSync(true)
+	defer publisher.Close()
+
+	if err := publisher.PublishValue(context.Background(), testEvent{ID: "order-1"}, []byte("order-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(broker.Messages("out")) != 1 || publisher.Stats().Delivered != 1 {
+		t.Fatalf("expected message delivered when publish returns, got %d messages and %+v", len(broker.Messages("out")), publisher.Stats())
+	}
+}
+
+func TestPublisherInSyncModeReturnsDeliveryError(t *testing.T) {
+	publisher := newTestEventPublisher(newFailingProducer(t, 1, sarama.ErrNotLeaderForPartition), kafkatest.NewSchemaRegistry())
+	publisher.SetSync(true)
+	defer publisher.Close()
+
+	err := publisher.PublishValue(context.Background(), testEvent{ID: "order-1"}, []byte("order-1"))
+	if !errors.Is(err, sarama.ErrNotLeaderForPartition) {
+		t.Fatalf("expected delivery error, got %v", err)
+	}
+
+	if stats := publisher.Stats(); stats.Failed != 1 || stats.Delivered != 0 {
+		t.Fatalf("expected one failed message, got %+v", stats)
+	}
+}
+
+func TestPublisherCallsDeliveryCallback(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	publisher := newTestEventPublisher(broker.NewAsyncProducer(), kafkatest.NewSchemaRegistry())
+	defer publisher.Close()
+
+	delivered := make(chan *sarama.ProducerMessage, 1)
+	err := publisher.PublishValueWithCallback(context.Background(), testEvent{ID: "order-1"}, []byte("order-1"), func(message *sarama.ProducerMessage, err error) {
+		if err != nil {
+			t.Errorf("expected message delivered, got %v", err)
+		}
+		delivered <- message
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case message := <-delivered:
+		if message.Topic != "out" || message.Offset != 0 {
+			t.Fatalf("expected acknowledged message of out topic, got %s at %d", message.Topic, message.Offset)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("delivery callback was not called")
+	}
+}
+
+func TestPublisherCallsDeliveryCallbackWithError(t *testing.T) {
+	publisher := newTestEventPublisher(newFailingProducer(t, 1, sarama.ErrNotEnoughReplicas), kafkatest.NewSchemaRegistry())
+	defer publisher.Close()
+
+	failed := make(chan error, 1)
+	err := publisher.PublishValueWithCallback(context.Background(), testEvent{ID: "order-1"}, []byte("order-1"), func(_ *sarama.ProducerMessage, err error) {
+		failed <- err
+	})
+	if err != nil {
+		t.Fatalf("expected async publish without error, got %v", err)
+	}
+
+	select {
+	case err := <-failed:
+		if !errors.Is(err, sarama.ErrNotEnoughReplicas) {
+			t.Fatalf("expected delivery error in callback, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("delivery callback was not called")
+	}
+}
+
+func TestPublisherFlushesMessagesOnClose(t *testing.T) {
+	broker := kafkatest.NewBroker(3)
+	publisher := newTestEventPublisher(broker.NewAsyncProducer(), kafkatest.NewSchemaRegistry())
+
+	var reported atomic.Int64
+	for _, id := range []string{"order-1", "order-2", "order-3", "order-4", "order-5"} {
+		err := publisher.PublishValueWithCallback(context.Background(), testEvent{ID: id}, []byte(id), func(_ *sarama.ProducerMessage, _ error) {
+			reported.Add(1)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if reported.Load() != 5 || len(broker.Messages("out")) != 5 || publisher.Stats().Delivered != 5 {
+		t.Fatalf("expected 5 messages delivered before close returns, got %d reports and %d messages", reported.Load(), len(broker.Messages("out")))
+	}
+
+	if err := publisher.PublishValue(context.Background(), testEvent{ID: "order-6"}, []byte("order-6")); !errors.Is(err, kafka.ErrPublisherClosed) {
+		t.Fatalf("expected closed publisher, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// RetryTopicInfix is added between topic name and delay of retry topic, for example orders.v1.retry.1m.
+const RetryTopicInfix = ".retry."
+
+// ErrInvalidRetryTopicDelay returns when delay of retry topic is not whole number of seconds,
+// name of retry topic has delay in seconds, so other delay can not be found by name of topic.
+var ErrInvalidRetryTopicDelay = errors.New("delay of retry topic must be whole number of seconds")
+
+// ErrRetryTopicsWithoutDeadLetter returns when retry topics are set, but consumer does not have dead letter publisher.
+var ErrRetryTopicsWithoutDeadLetter = errors.New("retry topics need dead letter publisher")
+
+// RetryPolicy describes how consumer retries message when handler fails.
+// At first consumer retries message in process MaxAttempts times with jittered exponential backoff,
+// after that it sends message in next delayed retry topic and in dead letter topic at the end.
+type RetryPolicy struct {
+	MaxAttempts      int
+	InitialBackoff   time.Duration
+	MaxBackoff       time.Duration
+	RetryTopicDelays []time.Duration
+}
+
+// Backoff returns jittered delay before next attempt, attempt starts from 1.
+func (policy RetryPolicy) Backoff(attempt int) time.Duration {
+	if policy.InitialBackoff <= 0 {
+		return 0
+	}
+
+	backoff := policy.InitialBackoff
+	for i := 1; i < attempt && (policy.MaxBackoff <= 0 || backoff < policy.MaxBackoff); i++ {
+		backoff *= 2
+	}
+
+	if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+
+	// equal jitter, so consumers don't retry at the same time.
+	half := backoff / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// validateRetryTopicDelays checks that every retry topic has own name which has delay without rounding.
+func (policy RetryPolicy) validateRetryTopicDelays() error {
+	names := make(map[string]bool, len(policy.RetryTopicDelays))
+	for _, delay := range policy.RetryTopicDelays {
+		if delay < time.Second || delay%time.Second != 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidRetryTopicDelay, delay)
+		}
+
+		name := formatDelay(delay)
+		if names[name] {
+			return fmt.Errorf("%w: %v is repeated", ErrInvalidRetryTopicDelay, delay)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+// RetryTopics returns names of delayed retry topics for topic.
+func (policy RetryPolicy) RetryTopics(topic string) []string {
+	retryTopics := make([]string, 0, len(policy.RetryTopicDelays))
+	for _, delay := range policy.RetryTopicDelays {
+		retryTopics = append(retryTopics, topic+RetryTopicInfix+formatDelay(delay))
+	}
+
+	return retryTopics
+}
+
+// retryStage returns original topic and index of delay for retry topic, index is -1 for original topic.
+func (policy RetryPolicy) retryStage(topic string) (string, int) {
+	for i, delay := range policy.RetryTopicDelays {
+		suffix := RetryTopicInfix + formatDelay(delay)
+		if strings.HasSuffix(topic, suffix) {
+			return strings.TrimSuffix(topic, suffix), i
+		}
+	}
+
+	return topic, -1
+}
+
+// nextRetryTopic returns retry topic where message goes after failure in topic or false when retries are over.
+func (policy RetryPolicy) nextRetryTopic(topic string) (string, bool) {
+	originalTopic, stage := policy.retryStage(topic)
+	if stage+1 >= len(policy.RetryTopicDelays) {
+		return "", false
+	}
+
+	return originalTopic + RetryTopicInfix + formatDelay(policy.RetryTopicDelays[stage+1]), true
+}
+
+// formatDelay returns delay for name of retry topic, part of second is rounded up, so delay is never 0s.
+func formatDelay(delay time.Duration) string {
+	if delay%time.Second != 0 {
+		delay = delay.Truncate(time.Second) + time.Second
+	}
+
+	switch {
+	case delay%time.Hour == 0:
+		return fmt.Sprintf("%dh", delay/time.Hour)
+	case delay%time.Minute == 0:
+		return fmt.Sprintf("%dm", delay/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", delay/time.Second)
+	}
+}
+
+// sleep waits duration or returns error when context is cancelled.
+func sleep(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,119 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+func TestBackoffIsBetweenHalfAndWholeExponentialBackoff(t *testing.T) {
+	policy := kafka.RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+
+	for i, backoff := range expected {
+		for range 100 {
+			if jittered := policy.Backoff(i + 1); jittered < backoff/2 || jittered > backoff {
+				t.Fatalf("expected backoff of attempt %d between %v and %v, got %v", i+1, backoff/2, backoff, jittered)
+			}
+		}
+	}
+}
+
+func TestBackoffIsZeroWithoutInitialBackoff(t *testing.T) {
+	if backoff := (kafka.RetryPolicy{MaxBackoff: time.Second}).Backoff(3); backoff != 0 {
+		t.Fatalf("expected zero backoff, got %v", backoff)
+	}
+}
+
+func TestRetryTopicsRoundUpPartOfSecond(t *testing.T) {
+	topics := kafka.RetryPolicy{RetryTopicDelays: []time.Duration{500 * time.Millisecond, 90 * time.Second, 2 * time.Hour}}.RetryTopics("in")
+	expected := []string{"in.retry.1s", "in.retry.90s", "in.retry.2h"}
+
+	for i, topic := range expected {
+		if topics[i] != topic {
+			t.Fatalf("expected retry topics %v, got %v", expected, topics)
+		}
+	}
+}
+
+func TestSetRetryPolicyRejectsInvalidDelays(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	consumer := newDeadLetterConsumer(t, broker, kafkatest.NewSchemaRegistry(), failingHandler{})
+
+	for _, delays := range [][]time.Duration{
+		{500 * time.Millisecond},
+		{1500 * time.Millisecond},
+		{time.Minute, 60 * time.Second},
+	} {
+		if err := consumer.SetRetryPolicy(kafka.RetryPolicy{RetryTopicDelays: delays}); !errors.Is(err, kafka.ErrInvalidRetryTopicDelay) {
+			t.Fatalf("expected invalid delays %v, got %v", delays, err)
+		}
+	}
+}
+
+func TestSetRetryPolicyNeedsDeadLetterPublisherForRetryTopics(t *testing.T) {
+	registry := kafkatest.NewSchemaRegistry()
+	serde := kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema)
+	consumer, err := kafka.NewSerdeConsumer(kafka.NewSerdeHandler[testEvent](serde, failingHandler{}), fakeConsumerOptions(kafkatest.NewBroker(1), registry, "in"))
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+
+	if err := consumer.SetRetryPolicy(kafka.RetryPolicy{RetryTopicDelays: []time.Duration{time.Second}}); !errors.Is(err, kafka.ErrRetryTopicsWithoutDeadLetter) {
+		t.Fatalf("expected error of retry topics without dead letter publisher, got %v", err)
+	}
+
+	if err := consumer.SetRetryPolicy(kafka.RetryPolicy{MaxAttempts: 3}); err != nil {
+		t.Fatalf("expected retry policy without retry topics, got %v", err)
+	}
+}
+
+func TestConsumerSendsFailedMessageThroughRetryTopicsToDeadLetterTopic(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	publishTestEvent(t, broker, registry, "in", "order-1")
+	original := broker.Messages("in")[0]
+
+	consumer := newDeadLetterConsumer(t, broker, registry, failingHandler{err: errors.New("courier is not available")})
+	err := consumer.SetRetryPolicy(kafka.RetryPolicy{MaxAttempts: 1, RetryTopicDelays: []time.Duration{time.Second, 2 * time.Second}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := runConsumer(ctx, t, consumer)
+	for _, topic := range []string{"in.retry.1s", "in.retry.2s", "in" + kafka.DeadLetterTopicSuffix} {
+		messages, err := broker.WaitMessages(ctx, topic, 1)
+		if err != nil {
+			t.Fatalf("expected message in %s: %v", topic, err)
+		}
+
+		if string(messages[0].Value) != string(original.Value) {
+			t.Fatalf("expected original value in %s", topic)
+		}
+
+		if header := messageHeader(messages[0], kafka.DeadLetterHeaderTopic); header != "in" {
+			t.Fatalf("expected original topic in header of %s, got %q", topic, header)
+		}
+	}
+
+	// message waits delay of retry topic after it was sent in first topic.
+	deadLetter := broker.Messages("in" + kafka.DeadLetterTopicSuffix)[0]
+	if elapsed := time.Since(original.Timestamp); elapsed < 2*time.Second {
+		t.Fatalf("expected dead letter after delays of retry topics, got it in %v", elapsed)
+	}
+
+	if len(broker.Messages("in.retry.1s")) != 1 || len(broker.Messages("in.retry.2s")) != 1 {
+		t.Fatal("expected one message in every retry topic")
+	}
+	expectDeadLetterHeaders(t, deadLetter, "courier is not available")
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,244 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linkedin/goavro"
+)
+
+// ErrIncompatibleSubjectSchema returns when schema of service can not be registered in subject.
+var ErrIncompatibleSubjectSchema = errors.New("schema is incompatible with latest version of subject")
+
+// Compatibility levels of schema registry.
+const (
+	CompatibilityNone               = "NONE"
+	CompatibilityBackward           = "BACKWARD"
+	CompatibilityBackwardTransitive = "BACKWARD_TRANSITIVE"
+	CompatibilityForward            = "FORWARD"
+	CompatibilityForwardTransitive  = "FORWARD_TRANSITIVE"
+	CompatibilityFull               = "FULL"
+	CompatibilityFullTransitive     = "FULL_TRANSITIVE"
+)
+
+var compatibilityLevels = map[string]bool{
+	CompatibilityNone:               true,
+	CompatibilityBackward:           true,
+	CompatibilityBackwardTransitive: true,
+	CompatibilityForward:            true,
+	CompatibilityForwardTransitive:  true,
+	CompatibilityFull:               true,
+	CompatibilityFullTransitive:     true,
+}
+
+// SchemaCompatibilityError describes why schema is incompatible, diff compares schema with latest version of subject.
+type SchemaCompatibilityError struct {
+	Subject  string
+	Level    string
+	Messages []string
+	Diff     []string
+}
+
+func (e *SchemaCompatibilityError) Error() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%v: subject %s, compatibility %s", ErrIncompatibleSubjectSchema, e.Subject, e.Level)
+	for _, message := range e.Messages {
+		fmt.Fprintf(&builder, "\n  %s", message)
+	}
+
+	if len(e.Diff) > 0 {
+		builder.WriteString("\n  diff with latest version:")
+	}
+	for _, line := range e.Diff {
+		fmt.Fprintf(&builder, "\n    %s", line)
+	}
+
+	return builder.String()
+}
+
+func (e *SchemaCompatibilityError) Unwrap() error {
+	return ErrIncompatibleSubjectSchema
+}
+
+// valueSubject returns subject of message value for topic.
+func valueSubject(topic string) string {
+	return topic + "-value"
+}
+
+// CheckSchemaCompatibility sets compatibility level of subject when level is not empty and checks that schema
+// can be registered in subject, service should call it on start so incompatible schema is not deployed.
+func CheckSchemaCompatibility(ctx context.Context, client SchemaRegistryClientInterface, subject string, schema string, level string) error {
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return fmt.Errorf("failed to create avro codec: %w", err)
+	}
+
+	if level != "" {
+		if !compatibilityLevels[level] {
+			return fmt.Errorf("unknown compatibility level %q", level)
+		}
+
+		if err := client.SetCompatibilityLevel(ctx, subject, level); err != nil {
+			return fmt.Errorf("failed to set compatibility level of subject %s: %w", subject, err)
+		}
+	}
+
+	result, err := client.TestCompatibility(ctx, subject, codec)
+	if err != nil {
+		return fmt.Errorf("failed to test compatibility of subject %s: %w", subject, err)
+	}
+
+	if result.IsCompatible {
+		return nil
+	}
+
+	compatibilityErr := &SchemaCompatibilityError{
+		Subject:  subject,
+		Level:    level,
+		Messages: result.Messages,
+	}
+
+	if compatibilityErr.Level == "" {
+		compatibilityErr.Level, _ = client.GetCompatibilityLevel(ctx, subject)
+	}
+
+	latestCodec, err := client.GetLatestSchema(ctx, subject)
+	if err == nil {
+		compatibilityErr.Diff, _ = SchemaDiff(latestCodec.Schema(), codec.Schema())
+	}
+
+	return compatibilityErr
+}
+
+// SchemaDiff compares fields of two record schemas, nested records are compared by path of field.
+// Lines start with "+" for new field, "-" for removed field and "~" for changed type or default.
+func SchemaDiff(oldSchema string, newSchema string) ([]string, error) {
+	oldFields := make(map[string]string)
+	if err := collectSchemaFields(oldSchema, oldFields); err != nil {
+		return nil, fmt.Errorf("failed to parse old schema: %w", err)
+	}
+
+	newFields := make(map[string]string)
+	if err := collectSchemaFields(newSchema, newFields); err != nil {
+		return nil, fmt.Errorf("failed to parse new schema: %w", err)
+	}
+
+	var diff []string
+	for path, newField := range newFields {
+		oldField, ok := oldFields[path]
+		if !ok {
+			diff = append(diff, fmt.Sprintf("+ %s %s", path, newField))
+
+			continue
+		}
+
+		if oldField != newField {
+			diff = append(diff, fmt.Sprintf("~ %s %s -> %s", path, oldField, newField))
+		}
+	}
+
+	for path, oldField := range oldFields {
+		if _, ok := newFields[path]; !ok {
+			diff = append(diff, fmt.Sprintf("- %s %s", path, oldField))
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i][2:] < diff[j][2:]
+	})
+
+	return diff, nil
+}
+
+func collectSchemaFields(schema string, fields map[string]string) error {
+	var schemaType any
+	if err := json.Unmarshal([]byte(schema), &schemaType); err != nil {
+		return err
+	}
+
+	collectTypeFields("", schemaType, fields)
+
+	return nil
+}
+
+// collectTypeFields walks in records, also records inside unions, arrays and maps.
+func collectTypeFields(path string, schemaType any, fields map[string]string) {
+	switch t := schemaType.(type) {
+	case []any:
+		for _, unionType := range t {
+			collectTypeFields(path, unionType, fields)
+		}
+	case map[string]any:
+		switch t["type"] {
+		case "array":
+			collectTypeFields(path+"[]", t["items"], fields)
+		case "map":
+			collectTypeFields(path+"{}", t["values"], fields)
+		case "record":
+			recordFields, _ := t["fields"].([]any)
+			for _, recordField := range recordFields {
+				field, ok := recordField.(map[string]any)
+				if !ok {
+					continue
+				}
+
+				fieldPath, _ := field["name"].(string)
+				if path != "" {
+					fieldPath = path + "." + fieldPath
+				}
+
+				fields[fieldPath] = describeField(field)
+				collectTypeFields(fieldPath, field["type"], fields)
+			}
+		}
+	}
+}
+
+// describeField returns short description of field type, nested records are shown only by name.
+func describeField(field map[string]any) string {
+	description := describeType(field["type"])
+	if defaultValue, ok := field["default"]; ok {
+		defaultJSON, _ := json.Marshal(defaultValue)
+		description += " default " + string(defaultJSON)
+	}
+
+	return description
+}
+
+func describeType(schemaType any) string {
+	switch t := schemaType.(type) {
+	case string:
+		return t
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, unionType := range t {
+			types = append(types, describeType(unionType))
+		}
+
+		return "[" + strings.Join(types, ", ") + "]"
+	case map[string]any:
+		switch t["type"] {
+		case "record", "enum", "fixed":
+			name, _ := t["name"].(string)
+
+			return name
+		case "array":
+			return "array<" + describeType(t["items"]) + ">"
+		case "map":
+			return "map<" + describeType(t["values"]) + ">"
+		}
+
+		description := describeType(t["type"])
+		if logicalType, ok := t["logicalType"].(string); ok {
+			description += "(" + logicalType + ")"
+		}
+
+		return description
+	}
+
+	return fmt.Sprint(schemaType)
+}
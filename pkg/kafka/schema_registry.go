@@ -2,7 +2,9 @@ package kafka
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -14,15 +16,20 @@ import (
 
 // SchemaRegistryClientInterface defines the api for all clients interfacing with schema registry.
 type SchemaRegistryClientInterface interface {
-	GetSchema(int) (*goavro.Codec, error)
-	GetSubjects() ([]string, error)
-	GetVersions(string) ([]int, error)
-	GetSchemaByVersion(string, int) (*goavro.Codec, error)
-	GetLatestSchema(string) (*goavro.Codec, error)
-	CreateSubject(string, *goavro.Codec) (int, error)
-	IsSchemaRegistered(string, *goavro.Codec) (int, error)
-	DeleteSubject(string) error
-	DeleteVersion(string, int) error
+	GetSchema(context.Context, int) (*goavro.Codec, error)
+	GetSubjects(context.Context) ([]string, error)
+	GetVersions(context.Context, string) ([]int, error)
+	GetSchemaByVersion(context.Context, string, int) (*goavro.Codec, error)
+	GetLatestSchema(context.Context, string) (*goavro.Codec, error)
+	CreateSubject(context.Context, string, *goavro.Codec) (int, error)
+	IsSchemaRegistered(context.Context, string, *goavro.Codec) (int, error)
+	DeleteSubject(context.Context, string) error
+	DeleteVersion(context.Context, string, int) error
+	TestCompatibility(context.Context, string, *goavro.Codec) (*CompatibilityResult, error)
+	GetCompatibilityLevel(context.Context, string) (string, error)
+	SetCompatibilityLevel(context.Context, string, string) error
+	RegisterSchema(context.Context, string, Schema) (int, error)
+	GetRawSchema(context.Context, int) (*Schema, error)
 }
 
 // SchemaRegistryClient is a basic http client to interact with schema registry.
@@ -30,6 +37,29 @@ type SchemaRegistryClient struct {
 	SchemaRegistryConnect []string
 	httpClient            *http.Client
 	retries               int
+	retryPolicy           RetryPolicy
+	options               SchemaRegistryOptions
+}
+
+// Schema types of schema registry, registry returns empty type for AVRO.
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+	SchemaTypeJSON     = "JSON"
+)
+
+// SchemaReference is schema of other subject which schema imports, for example other proto file.
+type SchemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// Schema is schema of any type, it is used by serdes of protobuf and json schema which don't have goavro.Codec.
+type Schema struct {
+	Schema     string            `json:"schema"`
+	SchemaType string            `json:"schemaType,omitempty"`
+	References []SchemaReference `json:"references,omitempty"`
 }
 
 type schemaResponse struct {
@@ -47,27 +77,43 @@ type idResponse struct {
 	ID int `json:"id"`
 }
 
+// CompatibilityResult shows can schema be registered in subject, messages explain why schema is incompatible.
+type CompatibilityResult struct {
+	IsCompatible bool     `json:"is_compatible"`
+	Messages     []string `json:"messages"`
+}
+
+type compatibilityLevelRequest struct {
+	Compatibility string `json:"compatibility"`
+}
+
+type compatibilityLevelResponse struct {
+	CompatibilityLevel string `json:"compatibilityLevel"`
+}
+
 const (
 	schemaByID       = "/schemas/ids/%d"
 	subjects         = "/subjects"
 	subjectVersions  = "/subjects/%s/versions"
 	deleteSubject    = "/subjects/%s"
 	subjectByVersion = "/subjects/%s/versions/%s"
+	compatibility    = "/compatibility/subjects/%s/versions/latest?verbose=true"
+	subjectConfig    = "/config/%s"
 
 	latestVersion = "latest"
 
 	contentType = "application/vnd.schemaregistry.v1+json"
 
 	timeout = 2 * time.Second
+
+	retryInitialBackoff = 100 * time.Millisecond
+	retryMaxBackoff     = 2 * time.Second
 )
 
 // NewSchemaRegistryClient creates a client to talk with the schema registry at the connect string
-// By default it will retry failed requests (5XX responses and http errors) len(connect) number of times.
+// By default it will retry failed requests (5XX, 429 responses and http errors) len(connect) number of times with backoff.
 func NewSchemaRegistryClient(connect []string) *SchemaRegistryClient {
-	client := &http.Client{
-		Timeout: timeout,
-	}
-	return &SchemaRegistryClient{connect, client, len(connect)}
+	return NewSchemaRegistryClientWithRetries(connect, len(connect))
 }
 
 // NewSchemaRegistryClientWithRetries creates an http client with a configurable amount of retries on 5XX responses.
@@ -75,12 +121,38 @@ func NewSchemaRegistryClientWithRetries(connect []string, retries int) *SchemaRe
 	client := &http.Client{
 		Timeout: timeout,
 	}
-	return &SchemaRegistryClient{connect, client, retries}
+	return &SchemaRegistryClient{
+		SchemaRegistryConnect: connect,
+		httpClient:            client,
+		retries:               retries,
+		retryPolicy:           RetryPolicy{InitialBackoff: retryInitialBackoff, MaxBackoff: retryMaxBackoff},
+	}
+}
+
+// NewSchemaRegistryClientWithOptions creates an http client with TLS and basic or bearer authentication of options.
+func NewSchemaRegistryClientWithOptions(connect []string, retries int, options SchemaRegistryOptions) (*SchemaRegistryClient, error) {
+	client, err := options.httpClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema registry http client: %w", err)
+	}
+
+	return &SchemaRegistryClient{
+		SchemaRegistryConnect: connect,
+		httpClient:            client,
+		retries:               retries,
+		retryPolicy:           RetryPolicy{InitialBackoff: retryInitialBackoff, MaxBackoff: retryMaxBackoff},
+		options:               options,
+	}, nil
+}
+
+// SetRetryBackoff changes jittered exponential backoff between retries of failed requests, zero initial backoff disables it.
+func (client *SchemaRegistryClient) SetRetryBackoff(initialBackoff time.Duration, maxBackoff time.Duration) {
+	client.retryPolicy = RetryPolicy{InitialBackoff: initialBackoff, MaxBackoff: maxBackoff}
 }
 
 // GetSchema returns a goavro.Codec by unique id.
-func (client *SchemaRegistryClient) GetSchema(id int) (*goavro.Codec, error) {
-	resp, err := client.httpCall("GET", fmt.Sprintf(schemaByID, id), nil)
+func (client *SchemaRegistryClient) GetSchema(ctx context.Context, id int) (*goavro.Codec, error) {
+	resp, err := client.httpCall(ctx, "GET", fmt.Sprintf(schemaByID, id), nil)
 	if nil != err {
 		return nil, err
 	}
@@ -92,8 +164,8 @@ func (client *SchemaRegistryClient) GetSchema(id int) (*goavro.Codec, error) {
 }
 
 // GetSubjects returns a list of all subjects in the schema registry.
-func (client *SchemaRegistryClient) GetSubjects() ([]string, error) {
-	resp, err := client.httpCall("GET", subjects, nil)
+func (client *SchemaRegistryClient) GetSubjects(ctx context.Context) ([]string, error) {
+	resp, err := client.httpCall(ctx, "GET", subjects, nil)
 	if nil != err {
 		return []string{}, err
 	}
@@ -103,8 +175,8 @@ func (client *SchemaRegistryClient) GetSubjects() ([]string, error) {
 }
 
 // GetVersions returns a list of the versions of a subject.
-func (client *SchemaRegistryClient) GetVersions(subject string) ([]int, error) {
-	resp, err := client.httpCall("GET", fmt.Sprintf(subjectVersions, subject), nil)
+func (client *SchemaRegistryClient) GetVersions(ctx context.Context, subject string) ([]int, error) {
+	resp, err := client.httpCall(ctx, "GET", fmt.Sprintf(subjectVersions, subject), nil)
 	if nil != err {
 		return []int{}, err
 	}
@@ -113,8 +185,8 @@ func (client *SchemaRegistryClient) GetVersions(subject string) ([]int, error) {
 	return result, err
 }
 
-func (client *SchemaRegistryClient) getSchemaByVersionInternal(subject string, version string) (*goavro.Codec, error) {
-	resp, err := client.httpCall("GET", fmt.Sprintf(subjectByVersion, subject, version), nil)
+func (client *SchemaRegistryClient) getSchemaByVersionInternal(ctx context.Context, subject string, version string) (*goavro.Codec, error) {
+	resp, err := client.httpCall(ctx, "GET", fmt.Sprintf(subjectByVersion, subject, version), nil)
 	if nil != err {
 		return nil, err
 	}
@@ -128,24 +200,23 @@ func (client *SchemaRegistryClient) getSchemaByVersionInternal(subject string, v
 }
 
 // GetSchemaByVersion returns a goavro.Codec for the version of the subject.
-func (client *SchemaRegistryClient) GetSchemaByVersion(subject string, version int) (*goavro.Codec, error) {
-	return client.getSchemaByVersionInternal(subject, fmt.Sprintf("%d", version))
+func (client *SchemaRegistryClient) GetSchemaByVersion(ctx context.Context, subject string, version int) (*goavro.Codec, error) {
+	return client.getSchemaByVersionInternal(ctx, subject, fmt.Sprintf("%d", version))
 }
 
 // GetLatestSchema returns a goavro.Codec for the latest version of the subject.
-func (client *SchemaRegistryClient) GetLatestSchema(subject string) (*goavro.Codec, error) {
-	return client.getSchemaByVersionInternal(subject, latestVersion)
+func (client *SchemaRegistryClient) GetLatestSchema(ctx context.Context, subject string) (*goavro.Codec, error) {
+	return client.getSchemaByVersionInternal(ctx, subject, latestVersion)
 }
 
 // CreateSubject adds a schema to the subject.
-func (client *SchemaRegistryClient) CreateSubject(subject string, codec *goavro.Codec) (int, error) {
+func (client *SchemaRegistryClient) CreateSubject(ctx context.Context, subject string, codec *goavro.Codec) (int, error) {
 	schema := schemaResponse{codec.Schema()}
 	json, err := json.Marshal(schema)
 	if err != nil {
 		return 0, err
 	}
-	payload := bytes.NewBuffer(json)
-	resp, err := client.httpCall("POST", fmt.Sprintf(subjectVersions, subject), payload)
+	resp, err := client.httpCall(ctx, "POST", fmt.Sprintf(subjectVersions, subject), json)
 	if err != nil {
 		return 0, err
 	}
@@ -153,14 +224,13 @@ func (client *SchemaRegistryClient) CreateSubject(subject string, codec *goavro.
 }
 
 // IsSchemaRegistered tests if the schema is registered, if so it returns the unique id of that schema.
-func (client *SchemaRegistryClient) IsSchemaRegistered(subject string, codec *goavro.Codec) (int, error) {
+func (client *SchemaRegistryClient) IsSchemaRegistered(ctx context.Context, subject string, codec *goavro.Codec) (int, error) {
 	schema := schemaResponse{codec.Schema()}
 	json, err := json.Marshal(schema)
 	if err != nil {
 		return 0, err
 	}
-	payload := bytes.NewBuffer(json)
-	resp, err := client.httpCall("POST", fmt.Sprintf(deleteSubject, subject), payload)
+	resp, err := client.httpCall(ctx, "POST", fmt.Sprintf(deleteSubject, subject), json)
 	if err != nil {
 		return 0, err
 	}
@@ -168,17 +238,88 @@ func (client *SchemaRegistryClient) IsSchemaRegistered(subject string, codec *go
 }
 
 // DeleteSubject deletes a subject. It should only be used in development.
-func (client *SchemaRegistryClient) DeleteSubject(subject string) error {
-	_, err := client.httpCall("DELETE", fmt.Sprintf(deleteSubject, subject), nil)
+func (client *SchemaRegistryClient) DeleteSubject(ctx context.Context, subject string) error {
+	_, err := client.httpCall(ctx, "DELETE", fmt.Sprintf(deleteSubject, subject), nil)
 	return err
 }
 
 // DeleteVersion deletes a subject. It should only be used in development.
-func (client *SchemaRegistryClient) DeleteVersion(subject string, version int) error {
-	_, err := client.httpCall("DELETE", fmt.Sprintf(subjectByVersion, subject, fmt.Sprintf("%d", version)), nil)
+func (client *SchemaRegistryClient) DeleteVersion(ctx context.Context, subject string, version int) error {
+	_, err := client.httpCall(ctx, "DELETE", fmt.Sprintf(subjectByVersion, subject, fmt.Sprintf("%d", version)), nil)
 	return err
 }
 
+// TestCompatibility checks schema against latest version of subject, schema is compatible when subject does not exist.
+func (client *SchemaRegistryClient) TestCompatibility(ctx context.Context, subject string, codec *goavro.Codec) (*CompatibilityResult, error) {
+	schema := schemaResponse{codec.Schema()}
+	json, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.httpCall(ctx, "POST", fmt.Sprintf(compatibility, subject), json)
+	var registryErr *Error
+	if errors.As(err, &registryErr) && (registryErr.ErrorCode == subjectNotFoundErrorCode || registryErr.ErrorCode == versionNotFoundErrorCode) {
+		return &CompatibilityResult{IsCompatible: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseCompatibility(resp)
+}
+
+// GetCompatibilityLevel returns compatibility level of subject, it is global level when subject does not have own level.
+func (client *SchemaRegistryClient) GetCompatibilityLevel(ctx context.Context, subject string) (string, error) {
+	resp, err := client.httpCall(ctx, "GET", fmt.Sprintf(subjectConfig, subject)+"?defaultToGlobal=true", nil)
+	if err != nil {
+		return "", err
+	}
+	var level = new(compatibilityLevelResponse)
+	err = json.Unmarshal(resp, &level)
+	return level.CompatibilityLevel, err
+}
+
+// SetCompatibilityLevel changes compatibility level of subject, for example BACKWARD or FULL_TRANSITIVE.
+func (client *SchemaRegistryClient) SetCompatibilityLevel(ctx context.Context, subject string, level string) error {
+	json, err := json.Marshal(compatibilityLevelRequest{level})
+	if err != nil {
+		return err
+	}
+	_, err = client.httpCall(ctx, "PUT", fmt.Sprintf(subjectConfig, subject), json)
+	return err
+}
+
+// RegisterSchema adds schema of any type to the subject and returns unique id of schema.
+func (client *SchemaRegistryClient) RegisterSchema(ctx context.Context, subject string, schema Schema) (int, error) {
+	if schema.SchemaType == SchemaTypeAvro {
+		schema.SchemaType = ""
+	}
+	json, err := json.Marshal(schema)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.httpCall(ctx, "POST", fmt.Sprintf(subjectVersions, subject), json)
+	if err != nil {
+		return 0, err
+	}
+	return parseID(resp)
+}
+
+// GetRawSchema returns schema of any type by unique id, type of avro schema is AVRO.
+func (client *SchemaRegistryClient) GetRawSchema(ctx context.Context, id int) (*Schema, error) {
+	resp, err := client.httpCall(ctx, "GET", fmt.Sprintf(schemaByID, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	var schema = new(Schema)
+	if err := json.Unmarshal(resp, schema); err != nil {
+		return nil, err
+	}
+	if schema.SchemaType == "" {
+		schema.SchemaType = SchemaTypeAvro
+	}
+	return schema, nil
+}
+
 func parseSchema(str []byte) (*schemaResponse, error) {
 	var schema = new(schemaResponse)
 	err := json.Unmarshal(str, &schema)
@@ -191,35 +332,61 @@ func parseID(str []byte) (int, error) {
 	return id.ID, err
 }
 
-func (client *SchemaRegistryClient) httpCall(method, uri string, payload io.Reader) ([]byte, error) {
+func parseCompatibility(str []byte) (*CompatibilityResult, error) {
+	var result = new(CompatibilityResult)
+	err := json.Unmarshal(str, &result)
+	return result, err
+}
+
+// httpCall sends request to random server and retries it on the next servers with backoff,
+// payload is sent again from the beginning in every attempt.
+func (client *SchemaRegistryClient) httpCall(ctx context.Context, method, uri string, payload []byte) ([]byte, error) {
 	nServers := len(client.SchemaRegistryConnect)
 	offset := rand.Intn(nServers)
 	for i := 0; ; i++ {
 		url := fmt.Sprintf("%s%s", client.SchemaRegistryConnect[(i+offset)%nServers], uri)
-		req, err := http.NewRequest(method, url, payload)
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Content-Type", contentType)
-		resp, err := client.httpClient.Do(req)
-		if resp != nil {
-			defer resp.Body.Close()
+		resp, isRetriable, err := client.do(ctx, method, url, payload)
+		if err == nil {
+			return resp, nil
 		}
-		if i < client.retries && (err != nil || retriable(resp)) {
-			continue
-		}
-		if err != nil {
+		if i >= client.retries || !isRetriable || ctx.Err() != nil {
 			return nil, err
 		}
-		if !okStatus(resp) {
-			return nil, newError(resp)
+		if err := sleep(ctx, client.retryPolicy.Backoff(i+1)); err != nil {
+			return nil, err
 		}
-		return io.ReadAll(resp.Body)
 	}
 }
 
+// do sends one request and reads response, it returns true when request can be retried.
+func (client *SchemaRegistryClient) do(ctx context.Context, method, url string, payload []byte) ([]byte, bool, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	client.options.authorize(req)
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	if !okStatus(resp) {
+		return nil, retriable(resp), newError(resp)
+	}
+	result, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	return result, false, nil
+}
+
 func retriable(resp *http.Response) bool {
-	return resp.StatusCode >= 500 && resp.StatusCode < 600
+	return resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode >= 500 && resp.StatusCode < 600)
 }
 
 func okStatus(resp *http.Response) bool {
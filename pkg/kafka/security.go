@@ -0,0 +1,203 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// SASL mechanisms which can be used in SASLOptions.
+const (
+	SASLMechanismPlain       = "PLAIN"
+	SASLMechanismScramSHA256 = "SCRAM-SHA-256"
+	SASLMechanismScramSHA512 = "SCRAM-SHA-512"
+)
+
+// ErrInvalidSecurityOptions returns when security options can not be applied, for example unknown SASL mechanism.
+var ErrInvalidSecurityOptions = errors.New("invalid security options")
+
+// TLSOptions enables TLS, CA file is needed when certificate of server is not signed by system CA,
+// client certificate and key are needed when server checks clients.
+type TLSOptions struct {
+	Enabled            bool   `env:"ENABLED" envDefault:"false"`
+	CAFile             string `env:"CA_FILE"`
+	CertFile           string `env:"CERT_FILE"`
+	KeyFile            string `env:"KEY_FILE"`
+	InsecureSkipVerify bool   `env:"INSECURE_SKIP_VERIFY" envDefault:"false"`
+}
+
+// SASLOptions enables SASL authentication in kafka, empty mechanism disables it.
+type SASLOptions struct {
+	Mechanism string `env:"MECHANISM"`
+	Username  string `env:"USERNAME"`
+	Password  string `env:"PASSWORD"`
+}
+
+// SchemaRegistryOptions enables authentication in schema registry, bearer token is used instead of basic auth when it is set.
+type SchemaRegistryOptions struct {
+	Username    string     `env:"USERNAME"`
+	Password    string     `env:"PASSWORD"`
+	BearerToken string     `env:"BEARER_TOKEN"`
+	TLS         TLSOptions `envPrefix:"TLS_"`
+}
+
+// SecurityOptions describes how publishers, consumers and schema registry client connect to secured cluster.
+// Zero value means plain connection without authentication. Options can be parsed from env variables,
+// for example with prefix KAFKA_ they are KAFKA_TLS_ENABLED, KAFKA_SASL_MECHANISM, KAFKA_SCHEMA_REGISTRY_BEARER_TOKEN.
+type SecurityOptions struct {
+	TLS            TLSOptions            `envPrefix:"TLS_"`
+	SASL           SASLOptions           `envPrefix:"SASL_"`
+	SchemaRegistry SchemaRegistryOptions `envPrefix:"SCHEMA_REGISTRY_"`
+}
+
+// apply sets TLS and SASL of sarama config.
+func (security SecurityOptions) apply(config *sarama.Config) error {
+	tlsConfig, err := security.TLS.config()
+	if err != nil {
+		return err
+	}
+
+	if tlsConfig != nil {
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	switch security.SASL.Mechanism {
+	case "":
+		return nil
+	case SASLMechanismPlain:
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case SASLMechanismScramSHA256:
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hashGenerator: scram.SHA256}
+		}
+	case SASLMechanismScramSHA512:
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hashGenerator: scram.SHA512}
+		}
+	default:
+		return fmt.Errorf("%w: unknown SASL mechanism %s", ErrInvalidSecurityOptions, security.SASL.Mechanism)
+	}
+
+	config.Net.SASL.Enable = true
+	config.Net.SASL.User = security.SASL.Username
+	config.Net.SASL.Password = security.SASL.Password
+
+	return nil
+}
+
+// NewClient creates sarama client with TLS and SASL of security options, tools use it to read topics directly.
+func NewClient(address []string, security SecurityOptions) (sarama.Client, error) {
+	config := sarama.NewConfig()
+	// sync producer can be created from client only with successes.
+	config.Producer.Return.Successes = true
+	// tools read only committed messages like consumers, aborted messages of transactions are skipped.
+	config.Consumer.IsolationLevel = sarama.ReadCommitted
+	if err := security.apply(config); err != nil {
+		return nil, err
+	}
+
+	client, err := sarama.NewClient(address, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+
+	return client, nil
+}
+
+// config returns nil when TLS is disabled.
+func (options TLSOptions) config() (*tls.Config, error) {
+	if !options.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	}
+
+	if options.CAFile != "" {
+		ca, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("%w: CA file %s has no certificates", ErrInvalidSecurityOptions, options.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if options.CertFile != "" || options.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// httpClient returns http client of schema registry with TLS of options.
+func (options SchemaRegistryOptions) httpClient() (*http.Client, error) {
+	tlsConfig, err := options.TLS.config()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: timeout}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+	}
+
+	return client, nil
+}
+
+// authorize adds credentials of schema registry in request.
+func (options SchemaRegistryOptions) authorize(req *http.Request) {
+	if options.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+options.BearerToken)
+
+		return
+	}
+
+	if options.Username != "" {
+		req.SetBasicAuth(options.Username, options.Password)
+	}
+}
+
+// scramClient implements sarama.SCRAMClient by xdg-go/scram.
+type scramClient struct {
+	hashGenerator scram.HashGeneratorFcn
+	conversation  *scram.ClientConversation
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hashGenerator.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conversation = client.NewConversation()
+
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
@@ -0,0 +1,238 @@
+package kafka_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+	"github.com/xdg-go/scram"
+)
+
+func writePEM(t *testing.T, name string, blockType string, content []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: content}), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+
+	return path
+}
+
+// writeClientCertificate writes self-signed certificate and its key, it returns paths of files.
+func writeClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return writePEM(t, "client.crt", "CERTIFICATE", certificate), writePEM(t, "client.key", "EC PRIVATE KEY", privateKey)
+}
+
+func applySecurity(t *testing.T, security kafka.SecurityOptions) *sarama.Config {
+	t.Helper()
+
+	config := sarama.NewConfig()
+	if err := kafka.ApplySecurity(security, config); err != nil {
+		t.Fatalf("failed to apply security options: %v", err)
+	}
+
+	return config
+}
+
+func TestSecurityOptionsWithoutTLSAndSASLKeepPlainConnection(t *testing.T) {
+	config := applySecurity(t, kafka.SecurityOptions{})
+
+	if config.Net.TLS.Enable || config.Net.SASL.Enable {
+		t.Fatal("expected plain connection without authentication")
+	}
+}
+
+func TestSecurityOptionsEnableTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	certFile, keyFile := writeClientCertificate(t)
+
+	config := applySecurity(t, kafka.SecurityOptions{TLS: kafka.TLSOptions{
+		Enabled:  true,
+		CAFile:   writePEM(t, "ca.crt", "CERTIFICATE", server.Certificate().Raw),
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	}})
+
+	tlsConfig := config.Net.TLS.Config
+	if !config.Net.TLS.Enable || tlsConfig == nil {
+		t.Fatal("expected TLS enabled")
+	}
+
+	if tlsConfig.MinVersion != tls.VersionTLS12 || tlsConfig.InsecureSkipVerify {
+		t.Fatalf("expected verified TLS 1.2 at least, got version %x and skip verify %v", tlsConfig.MinVersion, tlsConfig.InsecureSkipVerify)
+	}
+
+	if tlsConfig.RootCAs == nil || len(tlsConfig.Certificates) != 1 {
+		t.Fatal("expected CA and client certificate in TLS config")
+	}
+}
+
+func TestSecurityOptionsRejectInvalidTLSFiles(t *testing.T) {
+	certFile, _ := writeClientCertificate(t)
+	for name, options := range map[string]kafka.TLSOptions{
+		"missing CA file":         {Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.crt")},
+		"CA without certificates": {Enabled: true, CAFile: writePEM(t, "ca.crt", "PRIVATE KEY", []byte("key"))},
+		"certificate without key": {Enabled: true, CertFile: certFile},
+	} {
+		if err := kafka.ApplySecurity(kafka.SecurityOptions{TLS: options}, sarama.NewConfig()); err == nil {
+			t.Fatalf("expected error of %s", name)
+		}
+	}
+}
+
+func TestSecurityOptionsSelectSASLMechanism(t *testing.T) {
+	mechanisms := map[string]sarama.SASLMechanism{
+		kafka.SASLMechanismPlain:       sarama.SASLTypePlaintext,
+		kafka.SASLMechanismScramSHA256: sarama.SASLTypeSCRAMSHA256,
+		kafka.SASLMechanismScramSHA512: sarama.SASLTypeSCRAMSHA512,
+	}
+
+	for mechanism, expected := range mechanisms {
+		config := applySecurity(t, kafka.SecurityOptions{SASL: kafka.SASLOptions{Mechanism: mechanism, Username: "courier", Password: "secret"}})
+
+		if !config.Net.SASL.Enable || config.Net.SASL.Mechanism != expected {
+			t.Fatalf("expected SASL %s for %s, got %s", expected, mechanism, config.Net.SASL.Mechanism)
+		}
+
+		if config.Net.SASL.User != "courier" || config.Net.SASL.Password != "secret" {
+			t.Fatalf("expected credentials of %s", mechanism)
+		}
+
+		if (config.Net.SASL.SCRAMClientGeneratorFunc != nil) != (mechanism != kafka.SASLMechanismPlain) {
+			t.Fatalf("expected SCRAM client only for SCRAM mechanism, got it for %s", mechanism)
+		}
+	}
+
+	err := kafka.ApplySecurity(kafka.SecurityOptions{SASL: kafka.SASLOptions{Mechanism: "GSSAPI"}}, sarama.NewConfig())
+	if !errors.Is(err, kafka.ErrInvalidSecurityOptions) {
+		t.Fatalf("expected error of unknown mechanism, got %v", err)
+	}
+}
+
+func TestSecurityOptionsUseHashOfSCRAMMechanism(t *testing.T) {
+	hashes := map[string]scram.HashGeneratorFcn{
+		kafka.SASLMechanismScramSHA256: scram.SHA256,
+		kafka.SASLMechanismScramSHA512: scram.SHA512,
+	}
+
+	for mechanism, hash := range hashes {
+		config := applySecurity(t, kafka.SecurityOptions{SASL: kafka.SASLOptions{Mechanism: mechanism}})
+
+		credentialsClient, err := hash.NewClient("courier", "secret", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		credentials := credentialsClient.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+		server, err := hash.NewServer(func(string) (scram.StoredCredentials, error) {
+			return credentials, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// client of sarama config authenticates only on server with the same hash.
+		client := config.Net.SASL.SCRAMClientGeneratorFunc()
+		if err := client.Begin("courier", "secret", ""); err != nil {
+			t.Fatal(err)
+		}
+		conversation := server.NewConversation()
+		challenge := ""
+		for !client.Done() {
+			response, err := client.Step(challenge)
+			if err != nil {
+				t.Fatalf("failed SCRAM conversation of %s: %v", mechanism, err)
+			}
+
+			if client.Done() {
+				break
+			}
+
+			challenge, err = conversation.Step(response)
+			if err != nil {
+				t.Fatalf("failed SCRAM conversation of %s: %v", mechanism, err)
+			}
+		}
+
+		if !conversation.Valid() {
+			t.Fatalf("expected client authenticated by %s", mechanism)
+		}
+	}
+}
+
+// authorizationRecorder remembers Authorization header of the last request to schema registry.
+type authorizationRecorder struct {
+	handler       http.Handler
+	authorization chan string
+}
+
+func (h authorizationRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.authorization <- r.Header.Get("Authorization")
+	h.handler.ServeHTTP(w, r)
+}
+
+func TestSchemaRegistryClientSendsCredentialsOverTLS(t *testing.T) {
+	registry := kafkatest.NewSchemaRegistry()
+	recorder := authorizationRecorder{handler: kafkatest.NewSchemaRegistryHandler(registry), authorization: make(chan string, 1)}
+	server := httptest.NewTLSServer(recorder)
+	defer server.Close()
+	tlsOptions := kafka.TLSOptions{Enabled: true, CAFile: writePEM(t, "ca.crt", "CERTIFICATE", server.Certificate().Raw)}
+
+	basic := httptest.NewRequest(http.MethodGet, "/", nil)
+	basic.SetBasicAuth("courier", "secret")
+	expected := map[string]kafka.SchemaRegistryOptions{
+		basic.Header.Get("Authorization"): {Username: "courier", Password: "secret", TLS: tlsOptions},
+		// bearer token is used instead of basic auth.
+		"Bearer token": {Username: "courier", Password: "secret", BearerToken: "token", TLS: tlsOptions},
+		"":             {TLS: tlsOptions},
+	}
+
+	for authorization, options := range expected {
+		client, err := kafka.NewCachedSchemaRegistryClientWithOptions([]string{server.URL}, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.GetSubjects(context.Background()); err != nil {
+			t.Fatalf("failed to request schema registry over TLS: %v", err)
+		}
+
+		if header := <-recorder.authorization; header != authorization {
+			t.Fatalf("expected authorization %q, got %q", authorization, header)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// wireFormatMagicByte is the first byte of value in Confluent wire format, next 4 bytes are schema id.
+const wireFormatMagicByte = 0
+
+// Serde serializes message T in value of kafka message and deserializes it back, value is in Confluent wire format
+// with id of schema in registry, so consumers of other languages can read it. Serde is chosen per topic,
+// topic is used for name of subject <topic>-value.
+type Serde[T any] interface {
+	Serialize(ctx context.Context, topic string, message T) ([]byte, error)
+	Deserialize(ctx context.Context, topic string, value []byte) (T, error)
+}
+
+// ErrSerdeNotSet returns when publisher or consumer needs serde of options, but it was not set.
+var ErrSerdeNotSet = errors.New("serde was not set")
+
+// MessageSerde is Serde without type of message, so serde of topic can be set in PublisherOptions and ConsumerOptions.
+type MessageSerde interface {
+	SerializeMessage(ctx context.Context, topic string, message any) ([]byte, error)
+	DeserializeMessage(ctx context.Context, topic string, value []byte) (any, error)
+}
+
+// messageSerde adapts typed serde to MessageSerde.
+type messageSerde[T any] struct {
+	serde Serde[T]
+}
+
+// NewMessageSerde wraps typed serde for options, for example NewMessageSerde[*pb.Location](protobufSerde).
+func NewMessageSerde[T any](serde Serde[T]) MessageSerde {
+	return messageSerde[T]{serde: serde}
+}
+
+// SerializeMessage serializes message, message of other type than T is not serialized.
+func (s messageSerde[T]) SerializeMessage(ctx context.Context, topic string, message any) ([]byte, error) {
+	typed, ok := message.(T)
+	if !ok {
+		var expected T
+
+		return nil, fmt.Errorf("serde of %T can not serialize message %T", expected, message)
+	}
+
+	return s.serde.Serialize(ctx, topic, typed)
+}
+
+// DeserializeMessage deserializes value in T.
+func (s messageSerde[T]) DeserializeMessage(ctx context.Context, topic string, value []byte) (any, error) {
+	return s.serde.Deserialize(ctx, topic, value)
+}
+
+// ValueHandler decodes value of message for Consumer and returns function which handles decoded message,
+// consumer calls this function again on retry, so message is decoded only once.
+type ValueHandler interface {
+	DecodeValue(ctx context.Context, topic string, value []byte) (func(ctx context.Context) error, error)
+}
+
+// SerdeHandler deserializes message by serde and calls typed handler.
+type SerdeHandler[T any] struct {
+	serde   Serde[T]
+	handler Handler[T]
+}
+
+// NewSerdeHandler creates handler for Consumer, for example NewSerdeHandler[*pb.Location](protobufSerde, handler).
+func NewSerdeHandler[T any](serde Serde[T], handler Handler[T]) *SerdeHandler[T] {
+	return &SerdeHandler[T]{serde: serde, handler: handler}
+}
+
+// DecodeValue deserializes value, message which we can not deserialize is invalid.
+func (h *SerdeHandler[T]) DecodeValue(ctx context.Context, topic string, value []byte) (func(ctx context.Context) error, error) {
+	message, err := h.serde.Deserialize(ctx, topic, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		return h.handler.HandleMessage(ctx, message)
+	}, nil
+}
+
+// messageSerdeHandler deserializes message by serde of consumer options and calls typed handler.
+type messageSerdeHandler[T any] struct {
+	serde   MessageSerde
+	handler Handler[T]
+}
+
+// DecodeValue deserializes value, message of other type than handler expects is invalid.
+func (h *messageSerdeHandler[T]) DecodeValue(ctx context.Context, topic string, value []byte) (func(ctx context.Context) error, error) {
+	decoded, err := h.serde.DeserializeMessage(ctx, topic, value)
+	if err != nil {
+		return nil, err
+	}
+
+	message, ok := decoded.(T)
+	if !ok {
+		return nil, fmt.Errorf("%w: serde returned %T instead of %T", ErrInvalidMessage, decoded, message)
+	}
+
+	return func(ctx context.Context) error {
+		return h.handler.HandleMessage(ctx, message)
+	}, nil
+}
+
+// SerdePublisher sends message T in topic of publisher, message is serialized by serde.
+type SerdePublisher[T any] struct {
+	publisher *Publisher
+	serde     Serde[T]
+}
+
+// NewSerdePublisher creates typed publisher which serializes messages by serde.
+func NewSerdePublisher[T any](publisher *Publisher, serde Serde[T]) *SerdePublisher[T] {
+	return &SerdePublisher[T]{publisher: publisher, serde: serde}
+}
+
+// PublishMessage sends message in kafka, in sync mode it waits acknowledgement of broker.
+func (p *SerdePublisher[T]) PublishMessage(ctx context.Context, message T, key []byte) error {
+	return p.PublishMessageWithCallback(ctx, message, key, nil)
+}
+
+// PublishMessageWithCallback sends message in kafka and calls callback when broker acknowledges message or sending fails.
+func (p *SerdePublisher[T]) PublishMessageWithCallback(ctx context.Context, message T, key []byte, callback DeliveryCallback) error {
+	value, err := p.serde.Serialize(ctx, p.publisher.topic, message)
+	if err != nil {
+		return err
+	}
+
+	return p.publisher.publishValue(ctx, value, key, callback)
+}
+
+// encodeWireFormat writes magic byte, schema id and payload.
+func encodeWireFormat(schemaID int, payload []byte) []byte {
+	value := make([]byte, 5, 5+len(payload))
+	value[0] = wireFormatMagicByte
+	binary.BigEndian.PutUint32(value[1:5], uint32(schemaID))
+
+	return append(value, payload...)
+}
+
+// decodeWireFormat returns schema id and payload of value in Confluent wire format.
+func decodeWireFormat(value []byte) (int, []byte, error) {
+	if len(value) < 5 || value[0] != wireFormatMagicByte {
+		return 0, nil, ErrInvalidMessage
+	}
+
+	return int(binary.BigEndian.Uint32(value[1:5])), value[5:], nil
+}
+
+// checkSchemaType checks that message was written with schema of serde type, for example avro message
+// in topic of protobuf messages is invalid.
+func checkSchemaType(ctx context.Context, registry SchemaRegistryClientInterface, schemaID int, schemaType string) error {
+	schema, err := registry.GetRawSchema(ctx, schemaID)
+	if isSchemaNotFound(err) {
+		return fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if schema.SchemaType != schemaType {
+		return fmt.Errorf("%w: schema %d has type %s instead of %s", ErrInvalidMessage, schemaID, schema.SchemaType, schemaType)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/actgardner/gogen-avro/v10/vm"
+	"github.com/actgardner/gogen-avro/v10/vm/types"
+	"github.com/linkedin/goavro"
+)
+
+// AvroSerde serializes generated avro struct T in binary avro, it is the same format as AvroPublisher and AvroHandler use.
+// Message is resolved from writer schema to schema of T on deserialization.
+type AvroSerde[T AvroRecord, PT interface {
+	*T
+	types.Field
+}] struct {
+	registry SchemaRegistryClientInterface
+	codec    *goavro.Codec
+	programs sync.Map
+}
+
+// NewAvroSerde creates serde of generated avro struct, usually only T is specified: NewAvroSerde[avro.OrderMessage](registry).
+func NewAvroSerde[T AvroRecord, PT interface {
+	*T
+	types.Field
+}](registry SchemaRegistryClientInterface) (*AvroSerde[T, PT], error) {
+	var record T
+	codec, err := goavro.NewCodec(record.Schema())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create avro codec: %w", err)
+	}
+
+	return &AvroSerde[T, PT]{registry: registry, codec: codec}, nil
+}
+
+// Serialize registers schema of T in subject of topic and writes message in binary avro.
+func (s *AvroSerde[T, PT]) Serialize(ctx context.Context, topic string, message T) ([]byte, error) {
+	schemaId, err := s.registry.CreateSubject(ctx, valueSubject(topic), s.codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	if err := message.Serialize(&buffer); err != nil {
+		return nil, fmt.Errorf("failed to serialize avro message: %w", err)
+	}
+
+	return encodeWireFormat(schemaId, buffer.Bytes()), nil
+}
+
+// Deserialize reads message by writer schema from registry.
+func (s *AvroSerde[T, PT]) Deserialize(ctx context.Context, _ string, value []byte) (T, error) {
+	var record T
+	writerCodec, content, err := decodeAvroValue(ctx, s.registry, value)
+	if err != nil {
+		return record, err
+	}
+
+	program, err := avroProgram(&s.programs, writerCodec, record.Schema())
+	if err != nil {
+		return record, err
+	}
+
+	if err := vm.Eval(bytes.NewReader(content), program, PT(&record)); err != nil {
+		return record, fmt.Errorf("%w: %w", ErrInvalidAvroMessage, err)
+	}
+
+	return record, nil
+}
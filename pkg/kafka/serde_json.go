@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONSchemaSerde serializes message T in json, json schema is registered in subject of topic,
+// so registry checks compatibility of changes. Messages are not validated by schema.
+type JSONSchemaSerde[T any] struct {
+	registry SchemaRegistryClientInterface
+	schema   Schema
+}
+
+// NewJSONSchemaSerde creates serde of message T, schema is json schema of T.
+func NewJSONSchemaSerde[T any](registry SchemaRegistryClientInterface, schema string, references ...SchemaReference) *JSONSchemaSerde[T] {
+	return &JSONSchemaSerde[T]{
+		registry: registry,
+		schema:   Schema{Schema: schema, SchemaType: SchemaTypeJSON, References: references},
+	}
+}
+
+// Serialize registers json schema in subject of topic and writes message in json.
+func (s *JSONSchemaSerde[T]) Serialize(ctx context.Context, topic string, message T) ([]byte, error) {
+	schemaId, err := s.registry.RegisterSchema(ctx, valueSubject(topic), s.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize json message: %w", err)
+	}
+
+	return encodeWireFormat(schemaId, content), nil
+}
+
+// Deserialize reads json in T, fields which T does not know are skipped.
+func (s *JSONSchemaSerde[T]) Deserialize(ctx context.Context, _ string, value []byte) (T, error) {
+	var message T
+	schemaId, content, err := decodeWireFormat(value)
+	if err != nil {
+		return message, err
+	}
+
+	if err := checkSchemaType(ctx, s.registry, schemaId, SchemaTypeJSON); err != nil {
+		return message, err
+	}
+
+	if err := json.Unmarshal(content, &message); err != nil {
+		return message, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+
+	return message, nil
+}
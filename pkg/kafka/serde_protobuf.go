@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtobufSerde serializes protobuf message T in Confluent protobuf format: after schema id value has indexes
+// of message in proto file, so consumers find message type when file has several messages.
+type ProtobufSerde[T proto.Message] struct {
+	registry SchemaRegistryClientInterface
+	schema   Schema
+	indexes  []byte
+}
+
+// NewProtobufSerde creates serde of protobuf message T, schema is text of proto file with T,
+// references are subjects of imported proto files.
+func NewProtobufSerde[T proto.Message](registry SchemaRegistryClientInterface, schema string, references ...SchemaReference) *ProtobufSerde[T] {
+	var message T
+
+	return &ProtobufSerde[T]{
+		registry: registry,
+		schema:   Schema{Schema: schema, SchemaType: SchemaTypeProtobuf, References: references},
+		indexes:  encodeMessageIndexes(messageIndexes(message.ProtoReflect().Descriptor())),
+	}
+}
+
+// Serialize registers proto file in subject of topic and writes message indexes and binary protobuf.
+func (s *ProtobufSerde[T]) Serialize(ctx context.Context, topic string, message T) ([]byte, error) {
+	schemaId, err := s.registry.RegisterSchema(ctx, valueSubject(topic), s.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := proto.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize protobuf message: %w", err)
+	}
+
+	// indexes are shared by goroutines, so payload gets own slice.
+	payload := make([]byte, 0, len(s.indexes)+len(content))
+	payload = append(append(payload, s.indexes...), content...)
+
+	return encodeWireFormat(schemaId, payload), nil
+}
+
+// Deserialize skips message indexes and reads message in T, fields which T does not know are kept as unknown fields.
+func (s *ProtobufSerde[T]) Deserialize(ctx context.Context, _ string, value []byte) (T, error) {
+	var message T
+	schemaId, payload, err := decodeWireFormat(value)
+	if err != nil {
+		return message, err
+	}
+
+	if err := checkSchemaType(ctx, s.registry, schemaId, SchemaTypeProtobuf); err != nil {
+		return message, err
+	}
+
+	content, err := skipMessageIndexes(payload)
+	if err != nil {
+		return message, err
+	}
+
+	message = message.ProtoReflect().Type().New().Interface().(T)
+	if err := proto.Unmarshal(content, message); err != nil {
+		return message, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+
+	return message, nil
+}
+
+// messageIndexes returns path of message in proto file, for example [1, 0] is the first nested message of the second message.
+func messageIndexes(descriptor protoreflect.MessageDescriptor) []int {
+	var indexes []int
+	var current protoreflect.Descriptor = descriptor
+	for {
+		indexes = append([]int{current.Index()}, indexes...)
+		parent, ok := current.Parent().(protoreflect.MessageDescriptor)
+		if !ok {
+			return indexes
+		}
+		current = parent
+	}
+}
+
+// encodeMessageIndexes writes zigzag varint count and indexes, the first message of file is written as single 0.
+func encodeMessageIndexes(indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return []byte{0}
+	}
+
+	encoded := binary.AppendVarint(nil, int64(len(indexes)))
+	for _, index := range indexes {
+		encoded = binary.AppendVarint(encoded, int64(index))
+	}
+
+	return encoded
+}
+
+// skipMessageIndexes returns binary protobuf after message indexes.
+func skipMessageIndexes(payload []byte) ([]byte, error) {
+	count, n := binary.Varint(payload)
+	if n <= 0 || count < 0 {
+		return nil, fmt.Errorf("%w: invalid message indexes", ErrInvalidMessage)
+	}
+	payload = payload[n:]
+
+	for i := int64(0); i < count; i++ {
+		_, n = binary.Varint(payload)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: invalid message indexes", ErrInvalidMessage)
+		}
+		payload = payload[n:]
+	}
+
+	return payload, nil
+}
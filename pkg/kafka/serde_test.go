@@ -0,0 +1,128 @@
+package kafka_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptorProtoSchema is not parsed by fake registry, descriptor.proto is used only as file with nested messages.
+const descriptorProtoSchema = `syntax = "proto2"; package google.protobuf;`
+
+func TestProtobufSerdeWritesIndexesOfNestedMessage(t *testing.T) {
+	ctx := context.Background()
+	registry := kafkatest.NewSchemaRegistry()
+	serde := kafka.NewProtobufSerde[*descriptorpb.DescriptorProto_ExtensionRange](registry, descriptorProtoSchema)
+
+	sent := &descriptorpb.DescriptorProto_ExtensionRange{Start: proto.Int32(10), End: proto.Int32(20)}
+	value, err := serde.Serialize(ctx, "ranges", sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ExtensionRange is the first nested message of DescriptorProto, the third message of descriptor.proto:
+	// zigzag varints of count 2 and indexes 2, 0.
+	if indexes := value[5:8]; !bytes.Equal(indexes, []byte{4, 4, 0}) {
+		t.Fatalf("expected message indexes [4 4 0], got %v", indexes)
+	}
+
+	received, err := serde.Deserialize(ctx, "ranges", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !proto.Equal(sent, received) {
+		t.Fatalf("expected message %v, got %v", sent, received)
+	}
+}
+
+func TestProtobufSerdeWritesSingleIndexOfFirstMessage(t *testing.T) {
+	ctx := context.Background()
+	registry := kafkatest.NewSchemaRegistry()
+	serde := kafka.NewProtobufSerde[*descriptorpb.FileDescriptorSet](registry, descriptorProtoSchema)
+
+	sent := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{Name: proto.String("order.proto")}}}
+	value, err := serde.Serialize(ctx, "files", sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value[5] != 0 {
+		t.Fatalf("expected single index 0 of the first message, got %v", value[5])
+	}
+
+	received, err := serde.Deserialize(ctx, "files", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !proto.Equal(sent, received) {
+		t.Fatalf("expected message %v, got %v", sent, received)
+	}
+}
+
+func TestProtobufSerdeReturnsInvalidMessage(t *testing.T) {
+	ctx := context.Background()
+	registry := kafkatest.NewSchemaRegistry()
+	serde := kafka.NewProtobufSerde[*descriptorpb.DescriptorProto_ExtensionRange](registry, descriptorProtoSchema)
+
+	value, err := serde.Serialize(ctx, "ranges", &descriptorpb.DescriptorProto_ExtensionRange{Start: proto.Int32(10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the count of indexes says that three indexes follow, but value ends.
+	_, err = serde.Deserialize(ctx, "ranges", append(value[:5:5], 6))
+	if !errors.Is(err, kafka.ErrInvalidMessage) || errors.Is(err, kafka.ErrInvalidAvroMessage) {
+		t.Fatalf("expected format neutral error of invalid message, got %v", err)
+	}
+}
+
+func TestSerdeOfOptionsIsUsedByPublisherAndTypedConsumer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	serde := kafka.NewMessageSerde[testEvent](kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema))
+
+	publisher := kafka.NewPublisherWithProducer(broker.NewAsyncProducer(), registry, "in")
+	publisher.SetSync(true)
+	defer publisher.Close()
+
+	if err := publisher.PublishValue(ctx, testEvent{ID: "order-1"}, []byte("order-1")); !errors.Is(err, kafka.ErrSerdeNotSet) {
+		t.Fatalf("expected error of publisher without serde, got %v", err)
+	}
+
+	publisher.SetSerde(serde)
+	if err := publisher.PublishValue(ctx, testEvent{ID: "order-1"}, []byte("order-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := receivingHandler{ids: make(chan string, 1)}
+	if _, err := kafka.NewTypedConsumer[testEvent](handler, fakeConsumerOptions(broker, registry, "in")); !errors.Is(err, kafka.ErrSerdeNotSet) {
+		t.Fatalf("expected error of consumer without serde, got %v", err)
+	}
+
+	options := fakeConsumerOptions(broker, registry, "in")
+	options.Serde = serde
+	consumer, err := kafka.NewTypedConsumer[testEvent](handler, options)
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	done := runConsumer(ctx, t, consumer)
+
+	if id := receiveID(ctx, t, handler.ids); id != "order-1" {
+		t.Fatalf("expected order-1, got %s", id)
+	}
+
+	cancel()
+	<-done
+}
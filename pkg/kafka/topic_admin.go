@@ -0,0 +1,421 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/linkedin/goavro"
+)
+
+// ErrInvalidTopicSpec returns when spec of topics can not be applied, for example partitions are decreased.
+var ErrInvalidTopicSpec = errors.New("invalid topic spec")
+
+// Kinds of changes which are needed to bring topic in cluster to spec.
+const (
+	TopicChangeCreate        = "create"
+	TopicChangeAddPartitions = "add_partitions"
+	TopicChangeAlterConfig   = "alter_config"
+)
+
+const (
+	configRetentionMs   = "retention.ms"
+	configCleanupPolicy = "cleanup.policy"
+)
+
+const cleanupPolicyDelete = "delete"
+
+var cleanupPolicies = map[string]bool{
+	"delete":         true,
+	"compact":        true,
+	"compact,delete": true,
+	"delete,compact": true,
+}
+
+// TopicSpec declares topic with partitions, retention and compaction, value schema is path to avro schema
+// which is registered in subject <topic>-value with compatibility level. Retry delays add retry topics
+// <topic>.retry.<delay>, they must be the same as retry topic delays of consumers of topic.
+type TopicSpec struct {
+	Name               string            `json:"name"`
+	Partitions         int32             `json:"partitions"`
+	ReplicationFactor  int16             `json:"replication_factor,omitempty"`
+	RetentionMs        int64             `json:"retention_ms,omitempty"`
+	CleanupPolicy      string            `json:"cleanup_policy,omitempty"`
+	Config             map[string]string `json:"config,omitempty"`
+	ValueSchema        string            `json:"value_schema,omitempty"`
+	CompatibilityLevel string            `json:"compatibility_level,omitempty"`
+	RetryDelays        []string          `json:"retry_delays,omitempty"`
+}
+
+// TopicsSpec is checked-in description of all topics of services, replication factor is used
+// for topics which don't have own replication factor.
+type TopicsSpec struct {
+	ReplicationFactor int16       `json:"replication_factor"`
+	Topics            []TopicSpec `json:"topics"`
+}
+
+// TopicChange is one change of topic in cluster, details describe it for people.
+type TopicChange struct {
+	Kind    string
+	Topic   TopicSpec
+	Details []string
+}
+
+func (change TopicChange) String() string {
+	return fmt.Sprintf("%s %s %v", change.Kind, change.Topic.Name, change.Details)
+}
+
+// SchemaChange is schema which is not registered in subject yet or compatibility level of subject
+// which is different from spec.
+type SchemaChange struct {
+	Subject      string
+	Level        string
+	CurrentLevel string
+	Register     bool
+	Codec        *goavro.Codec
+}
+
+func (change SchemaChange) levelChanged() bool {
+	return change.Level != "" && change.Level != change.CurrentLevel
+}
+
+func (change SchemaChange) String() string {
+	var details []string
+	if change.Register {
+		details = append(details, "register schema")
+	}
+
+	if change.levelChanged() {
+		details = append(details, fmt.Sprintf("compatibility: %s -> %s", change.CurrentLevel, change.Level))
+	}
+
+	return fmt.Sprintf("%s %v", change.Subject, details)
+}
+
+// LoadTopicsSpec reads json spec of topics, paths of value schemas are relative to directory of spec.
+func LoadTopicsSpec(path string) (*TopicsSpec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topics spec: %w", err)
+	}
+
+	spec := &TopicsSpec{ReplicationFactor: 1}
+	if err := json.Unmarshal(content, spec); err != nil {
+		return nil, fmt.Errorf("failed to parse topics spec: %w", err)
+	}
+
+	names := make(map[string]bool, len(spec.Topics))
+	// retry topics are appended to spec in loop, so they are checked as other topics.
+	for i := 0; i < len(spec.Topics); i++ {
+		topic := &spec.Topics[i]
+		if topic.Name == "" || names[topic.Name] {
+			return nil, fmt.Errorf("%w: topic name %q is empty or duplicated", ErrInvalidTopicSpec, topic.Name)
+		}
+		names[topic.Name] = true
+
+		if topic.Partitions <= 0 {
+			return nil, fmt.Errorf("%w: topic %s must have partitions", ErrInvalidTopicSpec, topic.Name)
+		}
+
+		if topic.CleanupPolicy != "" && !cleanupPolicies[topic.CleanupPolicy] {
+			return nil, fmt.Errorf("%w: unknown cleanup policy %q of topic %s", ErrInvalidTopicSpec, topic.CleanupPolicy, topic.Name)
+		}
+
+		if topic.CompatibilityLevel != "" && !compatibilityLevels[topic.CompatibilityLevel] {
+			return nil, fmt.Errorf("%w: unknown compatibility level %q of topic %s", ErrInvalidTopicSpec, topic.CompatibilityLevel, topic.Name)
+		}
+
+		if topic.ReplicationFactor == 0 {
+			topic.ReplicationFactor = spec.ReplicationFactor
+		}
+
+		if topic.ValueSchema != "" && !filepath.IsAbs(topic.ValueSchema) {
+			topic.ValueSchema = filepath.Join(filepath.Dir(path), topic.ValueSchema)
+		}
+
+		retryTopics, err := topic.retryTopics()
+		if err != nil {
+			return nil, err
+		}
+		spec.Topics = append(spec.Topics, retryTopics...)
+	}
+
+	return spec, nil
+}
+
+// retryTopics returns delayed retry topics of topic with the same partitions, replication and retention,
+// messages are only deleted in retry topics, they are not compacted.
+func (topic TopicSpec) retryTopics() ([]TopicSpec, error) {
+	delays := make([]time.Duration, 0, len(topic.RetryDelays))
+	for _, value := range topic.RetryDelays {
+		delay, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid retry delay %q of topic %s", ErrInvalidTopicSpec, value, topic.Name)
+		}
+		delays = append(delays, delay)
+	}
+
+	policy := RetryPolicy{RetryTopicDelays: delays}
+	if err := policy.validateRetryTopicDelays(); err != nil {
+		return nil, fmt.Errorf("%w: invalid retry delays of topic %s: %w", ErrInvalidTopicSpec, topic.Name, err)
+	}
+
+	names := policy.RetryTopics(topic.Name)
+	retryTopics := make([]TopicSpec, 0, len(names))
+	for _, name := range names {
+		retryTopics = append(retryTopics, TopicSpec{
+			Name:              name,
+			Partitions:        topic.Partitions,
+			ReplicationFactor: topic.ReplicationFactor,
+			RetentionMs:       topic.RetentionMs,
+			CleanupPolicy:     cleanupPolicyDelete,
+		})
+	}
+
+	return retryTopics, nil
+}
+
+// configEntries returns config of topic in format of sarama.
+func (topic TopicSpec) configEntries() map[string]*string {
+	entries := make(map[string]*string, len(topic.Config)+2)
+	for name, value := range topic.Config {
+		value := value
+		entries[name] = &value
+	}
+
+	if topic.RetentionMs != 0 {
+		retention := fmt.Sprintf("%d", topic.RetentionMs)
+		entries[configRetentionMs] = &retention
+	}
+
+	if topic.CleanupPolicy != "" {
+		cleanupPolicy := topic.CleanupPolicy
+		entries[configCleanupPolicy] = &cleanupPolicy
+	}
+
+	return entries
+}
+
+// NewClusterAdmin creates sarama cluster admin with TLS and SASL of security options.
+func NewClusterAdmin(address []string, security SecurityOptions) (sarama.ClusterAdmin, error) {
+	config := sarama.NewConfig()
+	if err := security.apply(config); err != nil {
+		return nil, err
+	}
+
+	admin, err := sarama.NewClusterAdmin(address, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster admin: %w", err)
+	}
+
+	return admin, nil
+}
+
+// DiffTopics compares topics in cluster with spec, topics which are not in spec are not changed.
+// Only config which is set for topic is compared, config of topic which is missing in spec is returned
+// to value of broker. Static and dynamic config of broker is not changed by topic, so it is not compared.
+// Replication factor is used only for new topics, it is not changed for existing topics.
+func DiffTopics(admin sarama.ClusterAdmin, spec *TopicsSpec) ([]TopicChange, error) {
+	topics, err := admin.ListTopics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+
+	var changes []TopicChange
+	for _, topic := range spec.Topics {
+		detail, ok := topics[topic.Name]
+		if !ok {
+			changes = append(changes, TopicChange{
+				Kind:    TopicChangeCreate,
+				Topic:   topic,
+				Details: []string{fmt.Sprintf("partitions: %d, replication factor: %d", topic.Partitions, topic.ReplicationFactor)},
+			})
+
+			continue
+		}
+
+		if topic.Partitions < detail.NumPartitions {
+			return nil, fmt.Errorf("%w: topic %s has %d partitions, they can not be decreased to %d", ErrInvalidTopicSpec, topic.Name, detail.NumPartitions, topic.Partitions)
+		}
+
+		if topic.Partitions > detail.NumPartitions {
+			changes = append(changes, TopicChange{
+				Kind:    TopicChangeAddPartitions,
+				Topic:   topic,
+				Details: []string{fmt.Sprintf("partitions: %d -> %d", detail.NumPartitions, topic.Partitions)},
+			})
+		}
+
+		current, err := topicConfig(admin, topic.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if details := diffConfig(current, topic.configEntries()); len(details) > 0 {
+			changes = append(changes, TopicChange{Kind: TopicChangeAlterConfig, Topic: topic, Details: details})
+		}
+	}
+
+	return changes, nil
+}
+
+// topicConfig returns config which is set for topic, ListTopics also returns config of broker which is not default.
+func topicConfig(admin sarama.ClusterAdmin, topic string) (map[string]*string, error) {
+	entries, err := admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe config of topic %s: %w", topic, err)
+	}
+
+	config := make(map[string]*string, len(entries))
+	for _, entry := range entries {
+		if entry.Source != sarama.SourceTopic || entry.Sensitive {
+			continue
+		}
+
+		value := entry.Value
+		config[entry.Name] = &value
+	}
+
+	return config, nil
+}
+
+// diffConfig returns sorted lines "name: current -> desired" for config which is different.
+func diffConfig(current map[string]*string, desired map[string]*string) []string {
+	var details []string
+	for name, value := range desired {
+		if currentValue, ok := current[name]; !ok || currentValue == nil || *currentValue != *value {
+			details = append(details, fmt.Sprintf("%s: %s -> %s", name, configValue(currentValue), *value))
+		}
+	}
+
+	for name, value := range current {
+		if _, ok := desired[name]; !ok {
+			details = append(details, fmt.Sprintf("%s: %s -> default", name, configValue(value)))
+		}
+	}
+	sort.Strings(details)
+
+	return details
+}
+
+func configValue(value *string) string {
+	if value == nil {
+		return "default"
+	}
+
+	return *value
+}
+
+// ApplyTopicChanges creates topics, adds partitions and changes config of topics.
+func ApplyTopicChanges(admin sarama.ClusterAdmin, changes []TopicChange) error {
+	for _, change := range changes {
+		var err error
+		switch change.Kind {
+		case TopicChangeCreate:
+			err = admin.CreateTopic(change.Topic.Name, &sarama.TopicDetail{
+				NumPartitions:     change.Topic.Partitions,
+				ReplicationFactor: change.Topic.ReplicationFactor,
+				ConfigEntries:     change.Topic.configEntries(),
+			}, false)
+		case TopicChangeAddPartitions:
+			err = admin.CreatePartitions(change.Topic.Name, change.Topic.Partitions, nil, false)
+		case TopicChangeAlterConfig:
+			err = admin.AlterConfig(sarama.TopicResource, change.Topic.Name, change.Topic.configEntries(), false)
+		default:
+			err = fmt.Errorf("%w: unknown change %s", ErrInvalidTopicSpec, change.Kind)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to %s: %w", change, err)
+		}
+	}
+
+	return nil
+}
+
+// DiffTopicSchemas returns value schemas of spec which are not registered in subjects of topics and subjects
+// which have other compatibility level, schema which is incompatible with subject returns SchemaCompatibilityError.
+func DiffTopicSchemas(ctx context.Context, client SchemaRegistryClientInterface, spec *TopicsSpec) ([]SchemaChange, error) {
+	var changes []SchemaChange
+	for _, topic := range spec.Topics {
+		if topic.ValueSchema == "" {
+			continue
+		}
+
+		schema, err := os.ReadFile(topic.ValueSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema of topic %s: %w", topic.Name, err)
+		}
+
+		codec, err := goavro.NewCodec(string(schema))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create avro codec of %s: %w", topic.ValueSchema, err)
+		}
+
+		subject := valueSubject(topic.Name)
+		change := SchemaChange{Subject: subject, Level: topic.CompatibilityLevel, Codec: codec}
+		if topic.CompatibilityLevel != "" {
+			change.CurrentLevel, err = client.GetCompatibilityLevel(ctx, subject)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get compatibility level of subject %s: %w", subject, err)
+			}
+		}
+
+		_, err = client.IsSchemaRegistered(ctx, subject, codec)
+		if err == nil {
+			if change.levelChanged() {
+				changes = append(changes, change)
+			}
+
+			continue
+		}
+
+		var registryErr *Error
+		if !errors.As(err, &registryErr) || (registryErr.ErrorCode != subjectNotFoundErrorCode && registryErr.ErrorCode != schemaNotFoundErrorCode) {
+			return nil, fmt.Errorf("failed to check schema of subject %s: %w", subject, err)
+		}
+
+		result, err := client.TestCompatibility(ctx, subject, codec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to test compatibility of subject %s: %w", subject, err)
+		}
+
+		if !result.IsCompatible {
+			return nil, &SchemaCompatibilityError{Subject: subject, Level: topic.CompatibilityLevel, Messages: result.Messages}
+		}
+
+		change.Register = true
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// ApplySchemaChanges sets compatibility level of subjects and registers schemas.
+func ApplySchemaChanges(ctx context.Context, client SchemaRegistryClientInterface, changes []SchemaChange) error {
+	for _, change := range changes {
+		if !change.Register {
+			if err := client.SetCompatibilityLevel(ctx, change.Subject, change.Level); err != nil {
+				return fmt.Errorf("failed to set compatibility level of subject %s: %w", change.Subject, err)
+			}
+
+			continue
+		}
+
+		if err := CheckSchemaCompatibility(ctx, client, change.Subject, change.Codec.Schema(), change.Level); err != nil {
+			return err
+		}
+
+		if _, err := client.CreateSubject(ctx, change.Subject, change.Codec); err != nil {
+			return fmt.Errorf("failed to register schema in subject %s: %w", change.Subject, err)
+		}
+	}
+
+	return nil
+}
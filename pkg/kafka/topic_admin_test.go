@@ -0,0 +1,109 @@
+package kafka_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linkedin/goavro"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+const topicSchema = `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}]}`
+
+func writeTopicsSpec(t *testing.T, spec string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "order.avsc"), []byte(topicSchema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "topics.json")
+	if err := os.WriteFile(path, []byte(spec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadTopicsSpecAddsRetryTopics(t *testing.T) {
+	path := writeTopicsSpec(t, `{
+		"replication_factor": 3,
+		"topics": [{"name": "orders.v1", "partitions": 3, "cleanup_policy": "compact", "retry_delays": ["1m", "10m"]}]
+	}`)
+
+	spec, err := kafka.LoadTopicsSpec(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"orders.v1", "orders.v1.retry.1m", "orders.v1.retry.10m"}
+	if len(spec.Topics) != len(expected) {
+		t.Fatalf("expected topics %v, got %v", expected, spec.Topics)
+	}
+
+	for i, topic := range spec.Topics {
+		if topic.Name != expected[i] || topic.Partitions != 3 || topic.ReplicationFactor != 3 {
+			t.Fatalf("unexpected topic %+v", topic)
+		}
+	}
+
+	if spec.Topics[1].CleanupPolicy != "delete" {
+		t.Fatalf("expected retry topic without compaction, got %s", spec.Topics[1].CleanupPolicy)
+	}
+}
+
+func TestLoadTopicsSpecRejectsInvalidRetryDelay(t *testing.T) {
+	path := writeTopicsSpec(t, `{"topics": [{"name": "orders.v1", "partitions": 3, "retry_delays": ["soon"]}]}`)
+
+	if _, err := kafka.LoadTopicsSpec(path); err == nil {
+		t.Fatal("expected error of invalid retry delay")
+	}
+}
+
+func TestDiffTopicSchemasChangesCompatibilityLevelOfRegisteredSchema(t *testing.T) {
+	ctx := context.Background()
+	path := writeTopicsSpec(t, `{
+		"topics": [{"name": "orders.v1", "partitions": 3, "value_schema": "order.avsc", "compatibility_level": "FULL"}]
+	}`)
+
+	spec, err := kafka.LoadTopicsSpec(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := kafkatest.NewSchemaRegistry()
+	codec, err := goavro.NewCodec(topicSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := registry.CreateSubject(ctx, "orders.v1-value", codec); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := kafka.DiffTopicSchemas(ctx, registry, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || changes[0].Register || changes[0].CurrentLevel != kafka.CompatibilityBackward {
+		t.Fatalf("expected only change of compatibility level, got %v", changes)
+	}
+
+	if err := kafka.ApplySchemaChanges(ctx, registry, changes); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err = kafka.DiffTopicSchemas(ctx, registry, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 0 {
+		t.Fatalf("expected registry up to date with spec, got %v", changes)
+	}
+}
@@ -0,0 +1,185 @@
+package kafka
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CorrelationIDHeader is header of kafka message with id which is the same for all messages of one business flow,
+// for example from http request of order till validation of order.
+const CorrelationIDHeader = "correlation-id"
+
+const tracerName = "github.com/steteruk/go-delivery-service/pkg/kafka"
+
+// tracePropagator writes W3C trace context in headers, it does not depend on global propagator of service.
+var tracePropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns context with correlation id, publisher sends it in header of message.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationID returns correlation id of context or empty string.
+func CorrelationID(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+
+	return correlationID
+}
+
+// NewCorrelationID returns trace id of span in context or random id, when there is no span.
+func NewCorrelationID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// producerHeaders lets propagator write headers of message which is sent.
+type producerHeaders struct {
+	message *sarama.ProducerMessage
+}
+
+func (c producerHeaders) Get(key string) string {
+	for _, header := range c.message.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+func (c producerHeaders) Set(key string, value string) {
+	for i, header := range c.message.Headers {
+		if string(header.Key) == key {
+			c.message.Headers[i].Value = []byte(value)
+
+			return
+		}
+	}
+
+	c.message.Headers = append(c.message.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerHeaders) Keys() []string {
+	keys := make([]string, 0, len(c.message.Headers))
+	for _, header := range c.message.Headers {
+		keys = append(keys, string(header.Key))
+	}
+
+	return keys
+}
+
+// consumerHeaders lets propagator read headers of consumed message.
+type consumerHeaders struct {
+	message *sarama.ConsumerMessage
+}
+
+func (c consumerHeaders) Get(key string) string {
+	return headerValue(c.message, key)
+}
+
+func (c consumerHeaders) Set(string, string) {}
+
+func (c consumerHeaders) Keys() []string {
+	keys := make([]string, 0, len(c.message.Headers))
+	for _, header := range c.message.Headers {
+		if header != nil {
+			keys = append(keys, string(header.Key))
+		}
+	}
+
+	return keys
+}
+
+// startProduceSpan starts span of sending message and writes trace context and correlation id in headers of message.
+func startProduceSpan(ctx context.Context, message *sarama.ProducerMessage) trace.Span {
+	ctx, span := otel.Tracer(tracerName).Start(
+		ctx,
+		fmt.Sprintf("%s publish", message.Topic),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation", "publish"),
+			attribute.String("messaging.destination.name", message.Topic),
+		),
+	)
+
+	correlationID := CorrelationID(ctx)
+	if correlationID == "" {
+		correlationID = NewCorrelationID(ctx)
+	}
+	span.SetAttributes(attribute.String("messaging.message.conversation_id", correlationID))
+
+	headers := producerHeaders{message: message}
+	tracePropagator.Inject(ctx, headers)
+	headers.Set(CorrelationIDHeader, correlationID)
+
+	return span
+}
+
+// endProduceSpan ends span when broker acknowledges message or sending fails.
+func endProduceSpan(span trace.Span, message *sarama.ProducerMessage, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(
+			attribute.Int("messaging.kafka.destination.partition", int(message.Partition)),
+			attribute.Int64("messaging.kafka.message.offset", message.Offset),
+		)
+	}
+
+	span.End()
+}
+
+// startConsumeSpan reads trace context and correlation id from headers of message in context of handler
+// and starts span of handling message, so consumed message is child of span which sent it.
+func (consumer *Consumer) startConsumeSpan(ctx context.Context, message *sarama.ConsumerMessage) (context.Context, trace.Span) {
+	ctx = tracePropagator.Extract(ctx, consumerHeaders{message: message})
+
+	correlationID := headerValue(message, CorrelationIDHeader)
+	if correlationID != "" {
+		ctx = WithCorrelationID(ctx, correlationID)
+	}
+
+	return otel.Tracer(tracerName).Start(
+		ctx,
+		fmt.Sprintf("%s process", message.Topic),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.source.name", message.Topic),
+			attribute.String("messaging.kafka.consumer.group", consumer.group),
+			attribute.Int("messaging.kafka.source.partition", int(message.Partition)),
+			attribute.Int64("messaging.kafka.message.offset", message.Offset),
+			attribute.String("messaging.message.conversation_id", correlationID),
+		),
+	)
+}
+
+func endConsumeSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,97 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextHandler sends context of handled message, so test can check trace and correlation id.
+type contextHandler struct {
+	contexts chan context.Context
+}
+
+func (h *contextHandler) HandleMessage(ctx context.Context, _ testEvent) error {
+	h.contexts <- ctx
+
+	return nil
+}
+
+func TestTraceContextGoesFromPublisherToConsumer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracerProvider)
+	defer otel.SetTracerProvider(previous)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	serde := kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema)
+
+	publisher := kafka.NewPublisherWithProducer(broker.NewAsyncProducer(), registry, "in")
+	publisher.SetSync(true)
+	defer publisher.Close()
+
+	requestCtx, requestSpan := tracerProvider.Tracer("test").Start(ctx, "http request")
+	requestCtx = kafka.WithCorrelationID(requestCtx, "correlation-1")
+	err := kafka.NewSerdePublisher[testEvent](publisher, serde).PublishMessage(requestCtx, testEvent{ID: "order-1"}, []byte("order-1"))
+	requestSpan.End()
+	if err != nil {
+		t.Fatalf("failed to publish event: %v", err)
+	}
+
+	handler := &contextHandler{contexts: make(chan context.Context, 1)}
+	consumer, err := kafka.NewSerdeConsumer(kafka.NewSerdeHandler[testEvent](serde, handler), fakeConsumerOptions(broker, registry, "in"))
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	done := runConsumer(ctx, t, consumer)
+
+	var handlerCtx context.Context
+	select {
+	case handlerCtx = <-handler.contexts:
+	case <-ctx.Done():
+		t.Fatal("message was not handled")
+	}
+	cancel()
+	<-done
+
+	requestTraceID := requestSpan.SpanContext().TraceID()
+	if traceID := trace.SpanContextFromContext(handlerCtx).TraceID(); traceID != requestTraceID {
+		t.Fatalf("expected trace %s in handler, got %s", requestTraceID, traceID)
+	}
+	if correlationID := kafka.CorrelationID(handlerCtx); correlationID != "correlation-1" {
+		t.Fatalf("expected correlation id correlation-1 in handler, got %q", correlationID)
+	}
+
+	spans := make(map[trace.SpanKind]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.SpanKind()] = span
+	}
+
+	producerSpan, ok := spans[trace.SpanKindProducer]
+	if !ok {
+		t.Fatal("producer span was not recorded")
+	}
+	if producerSpan.Parent().SpanID() != requestSpan.SpanContext().SpanID() {
+		t.Fatal("producer span is not child of request span")
+	}
+
+	consumerSpan, ok := spans[trace.SpanKindConsumer]
+	if !ok {
+		t.Fatal("consumer span was not recorded")
+	}
+	if consumerSpan.Parent().SpanID() != producerSpan.SpanContext().SpanID() || !consumerSpan.Parent().IsRemote() {
+		t.Fatal("consumer span is not remote child of producer span")
+	}
+}
@@ -0,0 +1,144 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/IBM/sarama"
+)
+
+// TransactionalProducerFactory creates transactional producer with transactional id, consumer creates own producer
+// for every claimed partition.
+type TransactionalProducerFactory func(transactionalID string) (sarama.AsyncProducer, error)
+
+// NewTransactionalProducerFactory creates factory of sarama transactional producers with options of publisher,
+// topic of options is not used, producer of partition sends messages in any topic.
+func NewTransactionalProducerFactory(options PublisherOptions) TransactionalProducerFactory {
+	return func(transactionalID string) (sarama.AsyncProducer, error) {
+		options.Producer.TransactionalID = transactionalID
+
+		return newAsyncProducer(options)
+	}
+}
+
+// transactionContextKey keeps publisher of transaction in ctx of handler.
+type transactionContextKey struct{}
+
+func contextWithPublisher(ctx context.Context, publisher *Publisher) context.Context {
+	return context.WithValue(ctx, transactionContextKey{}, publisher)
+}
+
+// publisherFromContext returns publisher of transaction or nil when message is not handled in transaction.
+func publisherFromContext(ctx context.Context) *Publisher {
+	publisher, _ := ctx.Value(transactionContextKey{}).(*Publisher)
+
+	return publisher
+}
+
+// SetTransactionalProducers enables exactly-once handling: every attempt to handle message runs in own transaction,
+// messages which handler publishes by any Publisher and offset of consumed message are committed together, messages of
+// failed attempt are aborted. Messages of retry and dead letter topics are sent in transaction too.
+// Transactional id of partition producer is <group>-<topic>-<partition>, so new owner of partition after rebalance
+// or restart fences old one. Consumers of output topics must read committed messages only, key workers are not used.
+func (consumer *Consumer) SetTransactionalProducers(factory TransactionalProducerFactory) {
+	consumer.transactionalProducers = factory
+}
+
+// transaction sends messages of handler and offset of consumed message with transactional producer of partition.
+type transaction struct {
+	publisher *Publisher
+	group     string
+}
+
+func (consumer *Consumer) newTransaction(claim sarama.ConsumerGroupClaim) (*transaction, error) {
+	transactionalID := fmt.Sprintf("%s-%s-%d", consumer.group, claim.Topic(), claim.Partition())
+	producer, err := consumer.transactionalProducers(transactionalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transactional producer %s: %w", transactionalID, err)
+	}
+
+	return &transaction{
+		publisher: NewPublisherWithProducer(producer, consumer.schemaRegistryClient, claim.Topic()),
+		group:     consumer.group,
+	}, nil
+}
+
+// run calls fn in new transaction and commits offset of message with messages which fn publishes,
+// transaction is aborted when fn or commit fails.
+func (txn *transaction) run(ctx context.Context, message *sarama.ConsumerMessage, fn func(ctx context.Context) error) error {
+	producer := txn.publisher.producer
+	if err := producer.BeginTxn(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(contextWithPublisher(ctx, txn.publisher)); err != nil {
+		return txn.abort(err)
+	}
+
+	if err := producer.AddMessageToTxn(message, txn.group, nil); err != nil {
+		return txn.abort(fmt.Errorf("failed to add offset in transaction: %w", err))
+	}
+
+	if err := producer.CommitTxn(); err != nil {
+		return txn.abort(fmt.Errorf("failed to commit transaction: %w", err))
+	}
+
+	return nil
+}
+
+// republish sends original message in retry or dead letter topic in transaction with offset of message.
+func (txn *transaction) republish(ctx context.Context, message *sarama.ConsumerMessage, topic string, cause error) error {
+	return txn.run(ctx, message, func(ctx context.Context) error {
+		return txn.publisher.publish(ctx, republishedMessage(message, topic, cause), nil)
+	})
+}
+
+func (txn *transaction) abort(cause error) error {
+	if err := txn.publisher.producer.AbortTxn(); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to abort transaction: %w", err))
+	}
+
+	return cause
+}
+
+// close waits delivery reports of producer, transaction is always finished before.
+func (txn *transaction) close() {
+	if err := txn.publisher.Close(); err != nil {
+		log.Printf("failed to close transactional producer: %v\n", err)
+	}
+}
+
+// consumeClaimInTransactions handles messages of partition one by one with producer of partition,
+// partitions don't wait each other.
+func (consumer *Consumer) consumeClaimInTransactions(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	txn, err := consumer.newTransaction(claim)
+	if err != nil {
+		return err
+	}
+	defer txn.close()
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return errMessageChannelClosed
+			}
+
+			consumer.logClaimedMessage(message)
+			if err := consumer.handleMessage(session.Context(), txn, message); err != nil {
+				if session.Context().Err() != nil {
+					return nil
+				}
+
+				return err
+			}
+
+			consumer.observeLag(claim, message.Offset+1)
+
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
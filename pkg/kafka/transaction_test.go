@@ -0,0 +1,171 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/steteruk/go-delivery-service/pkg/kafka"
+	"github.com/steteruk/go-delivery-service/pkg/kafka/kafkatest"
+)
+
+const testEventSchema = `{"type": "object", "properties": {"id": {"type": "string"}}}`
+
+type testEvent struct {
+	ID string `json:"id"`
+}
+
+// forwardHandler publishes every event in output topic and fails first attempts after publishing.
+type forwardHandler struct {
+	publisher *kafka.SerdePublisher[testEvent]
+	failures  int
+}
+
+func (h *forwardHandler) HandleMessage(ctx context.Context, event testEvent) error {
+	if err := h.publisher.PublishMessage(ctx, event, []byte(event.ID)); err != nil {
+		return err
+	}
+
+	if h.failures > 0 {
+		h.failures--
+
+		return errors.New("handler failed after publishing")
+	}
+
+	return nil
+}
+
+func newTransactionalConsumer(
+	t *testing.T,
+	broker *kafkatest.Broker,
+	registry *kafkatest.SchemaRegistry,
+	handler *forwardHandler,
+) *kafka.Consumer {
+	t.Helper()
+
+	serde := kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema)
+	consumer, err := kafka.NewSerdeConsumer(kafka.NewSerdeHandler[testEvent](serde, handler), fakeConsumerOptions(broker, registry, "in"))
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	consumer.SetOffsetsClient(broker, "in")
+	consumer.SetRetryPolicy(kafka.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	consumer.SetTransactionalProducers(func(transactionalID string) (sarama.AsyncProducer, error) {
+		return broker.NewTransactionalAsyncProducer(transactionalID), nil
+	})
+
+	return consumer
+}
+
+func publishTestEvent(t *testing.T, broker *kafkatest.Broker, registry *kafkatest.SchemaRegistry, topic string, id string) {
+	t.Helper()
+
+	publishKeyedTestEvent(t, broker, registry, topic, id, id)
+}
+
+func publishKeyedTestEvent(t *testing.T, broker *kafkatest.Broker, registry *kafkatest.SchemaRegistry, topic string, key string, id string) {
+	t.Helper()
+
+	publisher := kafka.NewPublisherWithProducer(broker.NewAsyncProducer(), registry, topic)
+	publisher.SetSync(true)
+	defer publisher.Close()
+
+	serdePublisher := kafka.NewSerdePublisher[testEvent](publisher, kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema))
+	if err := serdePublisher.PublishMessage(context.Background(), testEvent{ID: id}, []byte(key)); err != nil {
+		t.Fatalf("failed to publish event: %v", err)
+	}
+}
+
+func TestConsumerCommitsOnlyOutputOfSuccessfulAttemptInTransaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	publishTestEvent(t, broker, registry, "in", "order-1")
+
+	output := kafka.NewPublisherWithProducer(broker.NewAsyncProducer(), registry, "out")
+	output.SetSync(true)
+	defer output.Close()
+
+	handler := &forwardHandler{
+		publisher: kafka.NewSerdePublisher[testEvent](output, kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema)),
+		failures:  1,
+	}
+	consumer := newTransactionalConsumer(t, broker, registry, handler)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- consumer.ConsumeMessage(ctx)
+	}()
+
+	if err := broker.WaitCommittedOffset(ctx, "in", "in", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("consumer failed: %v", err)
+	}
+
+	if messages := broker.Messages("out"); len(messages) != 1 {
+		t.Fatalf("expected 1 message in output topic, got %d", len(messages))
+	}
+}
+
+func TestConsumerSendsDeadLetterInTransaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := kafkatest.NewBroker(1)
+	registry := kafkatest.NewSchemaRegistry()
+	publishTestEvent(t, broker, registry, "in", "order-1")
+
+	output := kafka.NewPublisherWithProducer(broker.NewAsyncProducer(), registry, "out")
+	defer output.Close()
+
+	handler := &forwardHandler{
+		publisher: kafka.NewSerdePublisher[testEvent](output, kafka.NewJSONSchemaSerde[testEvent](registry, testEventSchema)),
+		failures:  3,
+	}
+	consumer := newTransactionalConsumer(t, broker, registry, handler)
+	consumer.SetDeadLetterPublisher(kafka.NewDeadLetterPublisherWithProducer(broker.NewSyncProducer()))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- consumer.ConsumeMessage(ctx)
+	}()
+
+	if err := broker.WaitCommittedOffset(ctx, "in", "in", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-done
+
+	if messages := broker.Messages("out"); len(messages) != 0 {
+		t.Fatalf("expected aborted output of failed attempts, got %d messages", len(messages))
+	}
+
+	deadLetters := broker.Messages("in" + kafka.DeadLetterTopicSuffix)
+	if len(deadLetters) != 1 {
+		t.Fatalf("expected 1 message in dead letter topic, got %d", len(deadLetters))
+	}
+}
+
+func TestTransactionalProducerIsFencedByNewProducer(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	old := broker.NewTransactionalAsyncProducer("in-in-0")
+	defer old.Close()
+
+	if err := old.BeginTxn(); err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	current := broker.NewTransactionalAsyncProducer("in-in-0")
+	defer current.Close()
+
+	if err := old.CommitTxn(); !errors.Is(err, sarama.ErrProducerFenced) {
+		t.Fatalf("expected fenced producer, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Options describes how service exports spans by OTLP gRPC, with prefix TRACING_ they are
+// TRACING_ENABLED, TRACING_ENDPOINT, TRACING_INSECURE and TRACING_SAMPLE_RATIO.
+type Options struct {
+	// Enabled installs tracer provider, without it spans of kafka and http are not recorded.
+	Enabled bool `env:"ENABLED" envDefault:"false"`
+	// Endpoint is host:port of OTLP collector.
+	Endpoint string `env:"ENDPOINT" envDefault:"localhost:4317"`
+	// Insecure sends spans without TLS, collector usually runs near service.
+	Insecure bool `env:"INSECURE" envDefault:"true"`
+	// SampleRatio is part of new traces which are sampled, child span follows decision of parent.
+	SampleRatio float64 `env:"SAMPLE_RATIO" envDefault:"1"`
+}
+
+// Shutdown sends spans which are not exported yet and stops tracer provider.
+type Shutdown func(ctx context.Context) error
+
+// NewTracerProvider installs global tracer provider with OTLP exporter and W3C propagator,
+// when tracing is disabled only propagator is installed, so trace context still goes through services.
+func NewTracerProvider(ctx context.Context, serviceName string, options Options) (Shutdown, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !options.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporterOptions := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(options.Endpoint)}
+	if options.Insecure {
+		exporterOptions = append(exporterOptions, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, exporterOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp trace exporter: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	log.Printf("tracing is enabled, spans are sent to %s\n", options.Endpoint)
+
+	return tracerProvider.Shutdown, nil
+}